@@ -43,6 +43,8 @@ type Executor struct {
 	OnSuccess FuncReact
 	// Optional: respond to errors when they occur
 	OnFailure FuncReact
+	// Optional: do not prefix output with the server it came from
+	Unprefixed bool
 }
 
 // Invoke the appropriate job on each server and return the set of data
@@ -108,7 +110,7 @@ func (e Executor) StreamAndExit() {
 func (e *Executor) run(gather bool) ([]*CliJobResponse, error) {
 	on := e.On
 	remote := on.Group()
-	single := len(on) == 1
+	single := len(on) == 1 || e.Unprefixed
 	responses := []*CliJobResponse{}
 
 	// Check each job first, return the first error (coding bugs)
@@ -43,3 +43,23 @@ func ExtendCommands(parent *cobra.Command, local bool) {
 		}
 	}
 }
+
+// A service started alongside the daemon
+type DaemonRegistration func() error
+
+var daemonExtensions []DaemonRegistration
+
+// Register a service to start with the daemon during init()
+func AddDaemonExtension(ext DaemonRegistration) {
+	daemonExtensions = append(daemonExtensions, ext)
+}
+
+// Start all registered daemon services, returning the first error.
+func StartDaemonExtensions() error {
+	for i := range daemonExtensions {
+		if err := daemonExtensions[i](); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/openshift/geard/port"
 	"log"
 	"os"
+	"sort"
+	"strings"
 )
 
 func GenerateId() string {
@@ -71,6 +73,145 @@ func (n *NetworkLinks) Set(s string) error {
 	return nil
 }
 
+type VolumeMounts struct {
+	*containers.VolumeMounts
+}
+
+func (v *VolumeMounts) String() string {
+	if v.VolumeMounts == nil {
+		return ""
+	}
+	return v.VolumeMounts.ToCompact()
+}
+
+func (v *VolumeMounts) Set(s string) error {
+	mounts, err := containers.NewVolumeMountsFromString(s)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return err
+	}
+	v.VolumeMounts = &mounts
+	return nil
+}
+
+// The target of a health check, http:<port>[/<path>], tcp:<port>, or
+// exec:<command>.  The other settings of Value are unchanged.
+type HealthCheck struct {
+	Value *containers.HealthCheck
+}
+
+func (h HealthCheck) String() string {
+	if h.Value == nil || h.Value.Type == "" {
+		return ""
+	}
+	switch h.Value.Type {
+	case containers.HealthCheckExec:
+		return h.Value.Type + ":" + strings.Join(h.Value.Command, " ")
+	default:
+		return fmt.Sprintf("%s:%d%s", h.Value.Type, h.Value.Port, h.Value.Path)
+	}
+}
+
+func (h HealthCheck) Set(s string) error {
+	check, err := containers.NewHealthCheckFromString(s)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return err
+	}
+	h.Value.Type = check.Type
+	h.Value.Port = check.Port
+	h.Value.Path = check.Path
+	h.Value.Command = check.Command
+	return nil
+}
+
+// An idle policy of the form never, after:<minutes>, or
+// schedule:<HH:MM-HH:MM>[,...].
+type IdlePolicy struct {
+	Value *containers.IdlePolicy
+}
+
+func (p IdlePolicy) String() string {
+	if p.Value == nil || p.Value.Mode == "" {
+		return ""
+	}
+	return p.Value.String()
+}
+
+func (p IdlePolicy) Set(s string) error {
+	policy, err := containers.NewIdlePolicyFromString(s)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return err
+	}
+	*p.Value = *policy
+	return nil
+}
+
+// A flag that may be repeated, collecting each value.
+type StringList struct {
+	Value *[]string
+}
+
+func (l StringList) String() string {
+	if l.Value == nil {
+		return ""
+	}
+	return strings.Join(*l.Value, ",")
+}
+
+func (l StringList) Set(s string) error {
+	*l.Value = append(*l.Value, s)
+	return nil
+}
+
+// A size in bytes with an optional K, M, G, or T suffix.
+type ByteSize struct {
+	Value *uint64
+}
+
+func (b ByteSize) String() string {
+	if b.Value == nil || *b.Value == 0 {
+		return ""
+	}
+	return containers.FormatByteSize(*b.Value)
+}
+
+func (b ByteSize) Set(s string) error {
+	size, err := containers.ParseByteSize(s)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return err
+	}
+	*b.Value = size
+	return nil
+}
+
+// A set of key=value labels, which may be repeated.
+type Labels map[string]string
+
+func (l Labels) String() string {
+	pairs := make([]string, 0, len(l))
+	for k, v := range l {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (l Labels) Set(s string) error {
+	for _, pair := range strings.Split(s, ",") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			err := fmt.Errorf("Labels must be of the form key=value: %s", pair)
+			fmt.Fprintln(os.Stderr, err.Error())
+			return err
+		}
+		l[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	return nil
+}
+
 type EnvironmentDescription struct {
 	Description containers.EnvironmentDescription
 	Path        string
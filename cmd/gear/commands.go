@@ -1,8 +1,10 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	. "github.com/openshift/geard/cmd"
 	"github.com/openshift/geard/config"
@@ -18,12 +20,15 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 	"time"
 	// "github.com/openshift/geard/encrypted"
 	"github.com/openshift/geard/http"
 	"github.com/openshift/geard/jobs"
 	"github.com/openshift/geard/port"
+	rjobs "github.com/openshift/geard/router/jobs"
 	"github.com/openshift/geard/sti"
+	"github.com/openshift/geard/systemd"
 	"github.com/openshift/geard/transport"
 )
 
@@ -35,6 +40,22 @@ var (
 	start   bool
 	isolate bool
 	sockAct bool
+	quiesce bool
+
+	execTty         bool
+	execInteractive bool
+	execEnv         []string
+	execUser        string
+
+	logOptions systemd.LogOptions
+	logSince   string
+	logUntil   string
+	logJson    bool
+
+	rollbackTo string
+
+	pullFirst           bool
+	registryCredentials containers.RegistryCredentials
 
 	keyPath   string
 	expiresAt int64
@@ -42,12 +63,25 @@ var (
 	environment  EnvironmentDescription
 	portPairs    PortPairs
 	networkLinks = NetworkLinks{}
+	limits       containers.ResourceLimits
+	volumeMounts = VolumeMounts{}
+	volume       containers.Volume
+	healthCheck  containers.HealthCheck
+	idlePolicy   containers.IdlePolicy
+	idlePolicyIn string
 
 	gitKeys     bool
 	gitRepoName string
 	gitRepoURL  string
 
 	deploymentPath string
+	deployStrategy string
+	rollingUpdate  deployment.RollingUpdate
+	rolloutTimeout int64
+	planDeploy     bool
+	planJson       bool
+	placementName  string
+	routerHost     string
 
 	buildReq    sti.BuildRequest
 	keyFile     string
@@ -58,6 +92,9 @@ var (
 	timeout    int64
 	listenAddr string
 
+	eventIds   string
+	eventTypes string
+
 	defaultTransport LocalTransportFlag
 )
 
@@ -67,6 +104,8 @@ var conf = http.HttpConfiguration{
 		QueueSlow:         1,
 		Concurrent:        2,
 		TrackDuplicateIds: 1000,
+		JournalRetention:  7 * 24 * time.Hour,
+		JobOutputLimit:    32 * 1024,
 	},
 }
 
@@ -99,6 +138,14 @@ func Execute() {
 	}
 	deployCmd.Flags().BoolVar(&isolate, "isolate", false, "Use an isolated container running as a user")
 	deployCmd.Flags().Int64VarP(&timeout, "timeout", "", 300, "Number of seconds to wait for HTTP/S server")
+	deployCmd.Flags().StringVar(&deployStrategy, "strategy", "recreate", "How instances with a changed image are updated: 'recreate' keeps them in place, 'rolling' replaces them in batches")
+	deployCmd.Flags().IntVar(&rollingUpdate.MaxUnavailable, "max-unavailable", 1, "Number of old instances of a container that may be stopped at once during a rolling update")
+	deployCmd.Flags().IntVar(&rollingUpdate.MaxSurge, "max-surge", 0, "Number of new instances of a container that may be started ahead of the old instances during a rolling update")
+	deployCmd.Flags().Int64Var(&rolloutTimeout, "rollout-timeout", 300, "Number of seconds to wait for each batch of a rolling update to become active")
+	deployCmd.Flags().StringVar(&placementName, "placement", "simple", "How new instances are assigned to hosts: 'simple' round robin, or 'resources' based on the capacity, labels, and affinity rules of each host")
+	deployCmd.Flags().BoolVar(&planDeploy, "plan", false, "Print the changes the deployment would make without applying them")
+	deployCmd.Flags().BoolVar(&planJson, "json", false, "Print the plan as JSON")
+	deployCmd.Flags().StringVar(&routerHost, "router", "", "The host running the router that the routes of the deployment are sent to")
 	AddCommand(gearCmd, deployCmd, false)
 
 	installImageCmd := &cobra.Command{
@@ -110,13 +157,68 @@ func Execute() {
 	installImageCmd.Flags().VarP(&portPairs, "ports", "p", "List of comma separated port pairs to bind '<internal>:<external>,...'. Use zero to request a port be assigned.")
 	installImageCmd.Flags().VarP(&networkLinks, "net-links", "n", "List of comma separated port pairs to wire '<local_host>:<local_port>:<remote_host>:<remote_port>,...'. local_host may be empty. It defaults to 127.0.0.1.")
 	installImageCmd.Flags().BoolVar(&start, "start", false, "Start the container immediately")
+	installImageCmd.Flags().BoolVar(&pullFirst, "pull", false, "Pull the image before installing, and fail if it cannot be pulled")
 	installImageCmd.Flags().BoolVar(&isolate, "isolate", false, "Use an isolated container running as a user")
 	installImageCmd.Flags().BoolVar(&sockAct, "socket-activated", false, "Use a socket-activated container (experimental, requires Docker branch)")
 	installImageCmd.Flags().StringVar(&environment.Path, "env-file", "", "Path to an environment file to load")
 	installImageCmd.Flags().StringVar(&environment.Description.Source, "env-url", "", "A url to download environment files from")
 	installImageCmd.Flags().StringVar((*string)(&environment.Description.Id), "env-id", "", "An optional identifier for the environment being set")
+	installImageCmd.Flags().Var(&volumeMounts, "volumes", "List of comma separated volumes to mount '<volume>:<path>[:ro],...'. Volumes must be created first.")
+	addLimitFlags(installImageCmd)
+	installImageCmd.Flags().Var(HealthCheck{Value: &healthCheck}, "health-check", "Check the health of the container with 'http:<port>[/<path>]', 'tcp:<port>', or 'exec:<command>'")
+	installImageCmd.Flags().IntVar(&healthCheck.Interval, "health-interval", 0, "Seconds between health checks (default 30)")
+	installImageCmd.Flags().IntVar(&healthCheck.Timeout, "health-timeout", 0, "Seconds before a health check fails (default 5)")
+	installImageCmd.Flags().IntVar(&healthCheck.FailureThreshold, "health-retries", 0, "Consecutive failed health checks before the container is unhealthy (default 3)")
+	installImageCmd.Flags().StringVar(&healthCheck.Action, "health-action", "", "What to do when the container is unhealthy, 'restart' (default) or 'none'")
+	installImageCmd.Flags().Var(IdlePolicy{Value: &idlePolicy}, "idle", "When the idler may stop the container, 'never', 'after:<minutes>', or 'schedule:<HH:MM-HH:MM>[,...]'")
 	AddCommand(gearCmd, installImageCmd, false)
 
+	pullCmd := &cobra.Command{
+		Use:   "pull <host>... <image>",
+		Short: "Pull an image onto one or more servers",
+		Long:  "Pulls an image from its registry onto each server and shows the progress.  Images from a registry with stored credentials are pulled with them.\n\nPass no hosts to pull onto the current server.",
+		Run:   pullImage,
+	}
+	AddCommand(gearCmd, pullCmd, false)
+
+	setRegistryCmd := &cobra.Command{
+		Use:   "set-registry-credentials <host>... --registry=<registry> --username=<user>",
+		Short: "Store the credentials servers use to pull from a private registry",
+		Long:  "Stores an account on each server that is used when images are pulled from the registry.  The password is read from stdin unless --password is passed.",
+		Run:   setRegistryCredentials,
+	}
+	setRegistryCmd.Flags().StringVar(&registryCredentials.Registry, "registry", "", "The registry host and optional port")
+	setRegistryCmd.Flags().StringVar(&registryCredentials.Username, "username", "", "The user to authenticate as")
+	setRegistryCmd.Flags().StringVar(&registryCredentials.Password, "password", "", "The password of the user")
+	setRegistryCmd.Flags().StringVar(&registryCredentials.Email, "email", "", "The email address of the user")
+	AddCommand(gearCmd, setRegistryCmd, false)
+
+	deleteRegistryCmd := &cobra.Command{
+		Use:   "delete-registry-credentials <host>... --registry=<registry>",
+		Short: "Remove the credentials stored for a registry",
+		Run:   deleteRegistryCredentials,
+	}
+	deleteRegistryCmd.Flags().StringVar(&registryCredentials.Registry, "registry", "", "The registry host and optional port")
+	AddCommand(gearCmd, deleteRegistryCmd, false)
+
+	createVolumeCmd := &cobra.Command{
+		Use:   "create-volume <name>...",
+		Short: "Create a named volume that containers can mount",
+		Long:  "Creates or updates a directory on the server that can be mounted into containers with 'gear install --volumes'.\n\nSpecify a location on a remote server with <host>[:<port>]/<name> instead of <name>.",
+		Run:   createVolume,
+	}
+	createVolumeCmd.Flags().StringVar((*string)(&volume.Owner), "owner", "", "The container whose user owns the volume contents")
+	createVolumeCmd.Flags().BoolVar(&volume.Shared, "shared", false, "Allow more than one container to mount the volume")
+	AddCommand(gearCmd, createVolumeCmd, false)
+
+	deleteVolumeCmd := &cobra.Command{
+		Use:   "delete-volume <name>...",
+		Short: "Delete a volume and its contents",
+		Long:  "Deletes one or more volumes that are not mounted by any installed container.",
+		Run:   deleteVolume,
+	}
+	AddCommand(gearCmd, deleteVolumeCmd, false)
+
 	deleteCmd := &cobra.Command{
 		Use:   "delete <name>...",
 		Short: "Delete an installed container",
@@ -194,6 +296,124 @@ func Execute() {
 	//startCmd.Flags().BoolVarP(&follow, "follow", "f", false, "Attach to the logs after startup")
 	AddCommand(gearCmd, restartCmd, false)
 
+	idleCmd := &cobra.Command{
+		Use:   "idle <name>...",
+		Short: "Stop running containers until traffic arrives for them",
+		Long:  "Stops each container and queues new connections to it, so the idler starts it again when traffic arrives.  The server must be built with the idler.",
+		Run:   idleContainer,
+	}
+	AddCommand(gearCmd, idleCmd, false)
+
+	unidleCmd := &cobra.Command{
+		Use:   "unidle <name>...",
+		Short: "Start idled containers",
+		Run:   unidleContainer,
+	}
+	AddCommand(gearCmd, unidleCmd, false)
+
+	setLimitsCmd := &cobra.Command{
+		Use:   "set-limits <name>...",
+		Short: "Change the resource limits of running containers",
+		Long:  "Applies the given limits to each container immediately and keeps them across restarts. Limits that are not passed are unchanged. Requires a server that runs containers with --has-foreground.",
+		Run:   setLimits,
+	}
+	addLimitFlags(setLimitsCmd)
+	AddCommand(gearCmd, setLimitsCmd, false)
+
+	backupCmd := &cobra.Command{
+		Use:   "backup <name>",
+		Short: "Write an archive of a container's data to stdout",
+		Long:  "Writes a gzipped tar archive of the home directory and data volumes of a container, along with the image, environment, ports, limits, and volumes it was installed with.\n\n  gear backup <name> > backup.tar.gz",
+		Run:   backupContainer,
+	}
+	backupCmd.Flags().BoolVar(&quiesce, "quiesce", false, "Stop the container while the archive is written")
+	AddCommand(gearCmd, backupCmd, false)
+
+	restoreCmd := &cobra.Command{
+		Use:   "restore <name>",
+		Short: "Install a container from an archive read from stdin",
+		Long:  "Installs a container from an archive written by 'gear backup' and restores its data.  The container may be restored under a new name or on another server - new external ports are assigned.\n\n  gear restore <name> < backup.tar.gz",
+		Run:   restoreContainer,
+	}
+	restoreCmd.Flags().BoolVar(&start, "start", false, "Start the container once it is restored")
+	AddCommand(gearCmd, restoreCmd, false)
+
+	moveCmd := &cobra.Command{
+		Use:   "move <host>/<name> <host>",
+		Short: "Move a container and its data to another server",
+		Long:  "Stops the container, copies its definition, environment, network links, and data to the new server, starts it there with newly assigned ports, and deletes it from the old server.  If the copy fails the container is started again where it was.\n\nPass --with <deployment> to update the location of the instance in the deployment and relink the containers that link to it.",
+		Run:   moveContainer,
+	}
+	AddCommand(gearCmd, moveCmd, false)
+
+	execCmd := &cobra.Command{
+		Use:   "exec <name> -- <command>...",
+		Short: "Run a command inside a running container",
+		Long:  "Runs a command in the namespaces of a running container and returns its output and exit code.  Pass -i to send stdin to the command and -t to run it in a terminal.\n\n  gear exec -it <name> -- /bin/bash",
+		Run:   execContainer,
+	}
+	execCmd.Flags().BoolVarP(&execTty, "tty", "t", false, "Run the command in a terminal")
+	execCmd.Flags().BoolVarP(&execInteractive, "interactive", "i", false, "Send stdin to the command")
+	execCmd.Flags().Var(StringList{Value: &execEnv}, "env", "Set an environment variable for the command in KEY=VALUE format, may be repeated")
+	AddCommand(gearCmd, execCmd, false)
+
+	grantExecCmd := &cobra.Command{
+		Use:   "grant-exec <name>... --user=<user>",
+		Short: "Allow a user to run commands in containers",
+		Long:  "Allows requests made on behalf of a user (the X-Request-User header) to run commands in the listed containers with 'gear exec' or POST /container/<name>/exec.  Requests without a user are refused once any user has access to a container.  Must be run on the server the containers are on.",
+		Run:   grantExecAccess,
+	}
+	grantExecCmd.Flags().StringVar(&execUser, "user", "", "The user to allow")
+	AddCommand(gearCmd, grantExecCmd, false)
+
+	revokeExecCmd := &cobra.Command{
+		Use:   "revoke-exec <name>... --user=<user>",
+		Short: "Stop a user from running commands in containers",
+		Run:   revokeExecAccess,
+	}
+	revokeExecCmd.Flags().StringVar(&execUser, "user", "", "The user to remove")
+	AddCommand(gearCmd, revokeExecCmd, false)
+
+	idlePolicyCmd := &cobra.Command{
+		Use:   "idle-policy <name>... --policy=<policy>",
+		Short: "Set when the idler may stop containers",
+		Long:  "Sets when the idler may stop containers that are not receiving traffic: 'never', 'after:<minutes>' without traffic, or 'schedule:<HH:MM-HH:MM>[,...]' to idle quiet containers only during those hours (server time).  Pass 'default' to use the timeout of the idler.",
+		Run:   setIdlePolicy,
+	}
+	idlePolicyCmd.Flags().StringVar(&idlePolicyIn, "policy", "", "The idle policy of the containers")
+	AddCommand(gearCmd, idlePolicyCmd, false)
+
+	historyCmd := &cobra.Command{
+		Use:   "history <name>...",
+		Short: "List the previous definitions of a container",
+		Long:  "Lists each definition a container has been installed or updated with, newest first, with the request that wrote it.  Pass the request id to 'gear rollback' to return to a definition.",
+		Run:   containerHistory,
+	}
+	AddCommand(gearCmd, historyCmd, false)
+
+	rollbackCmd := &cobra.Command{
+		Use:   "rollback <name>... --to=<request-id>",
+		Short: "Return a container to a previous definition",
+		Long:  "Reinstates a definition listed by 'gear history', along with the environment and ports it was installed with, and restarts the container.",
+		Run:   rollbackContainer,
+	}
+	rollbackCmd.Flags().StringVar(&rollbackTo, "to", "", "The request id of the definition to return to")
+	AddCommand(gearCmd, rollbackCmd, false)
+
+	logsCmd := &cobra.Command{
+		Use:   "logs <name>...",
+		Short: "Show the journal output of one or more containers",
+		Long:  "Shows the log lines written by the listed containers, merged in time order on each server.  Lines are prefixed with the server and container they came from when more than one is listed.  Pass -f to keep streaming new lines until interrupted.\n\n  gear logs -f --since=10m host1/web host2/web",
+		Run:   containerLogs,
+	}
+	logsCmd.Flags().BoolVarP(&logOptions.Follow, "follow", "f", false, "Stream new log lines as they are written")
+	logsCmd.Flags().IntVarP(&logOptions.Lines, "lines", "n", 0, "Number of the most recent lines to show, 30 unless --since is set")
+	logsCmd.Flags().StringVar(&logSince, "since", "", "Show lines written after a time in RFC3339 format, or a duration ago like '10m'")
+	logsCmd.Flags().StringVar(&logUntil, "until", "", "Show lines written before a time in RFC3339 format, or a duration ago like '10m'")
+	logsCmd.Flags().StringVarP(&logOptions.Priority, "priority", "p", "", "Only show lines at or above a priority ('err', '3') or within a range ('warning..err')")
+	logsCmd.Flags().BoolVar(&logJson, "json", false, "Print each entry as a JSON object with all of its journal fields")
+	AddCommand(gearCmd, logsCmd, false)
+
 	statusCmd := &cobra.Command{
 		Use:   "status <name>...",
 		Short: "Retrieve the systemd status of one or more containers",
@@ -210,6 +430,32 @@ func Execute() {
 	}
 	AddCommand(gearCmd, listUnitsCmd, false)
 
+	eventsCmd := &cobra.Command{
+		Use:   "events <host>...",
+		Short: "Stream container lifecycle events from one or more servers",
+		Long:  "Prints one JSON document per line for each container that is started, idled, stopped, deleted, or fails, until interrupted.",
+		Run:   containerEvents,
+	}
+	eventsCmd.Flags().StringVar(&eventIds, "ids", "", "Comma delimited list of container ids to report events for")
+	eventsCmd.Flags().StringVar(&eventTypes, "types", "", "Comma delimited list of event types to report (started, idled, stopped, deleted, error)")
+	AddCommand(gearCmd, eventsCmd, false)
+
+	jobStatusCmd := &cobra.Command{
+		Use:   "job-status <host>/<request-id>...",
+		Short: "Retrieve the state and result of a job",
+		Long:  "Shows whether a job submitted with a given request id is queued, running, succeeded, or failed, along with its result and the most recent output it wrote.",
+		Run:   jobStatus,
+	}
+	AddCommand(gearCmd, jobStatusCmd, false)
+
+	cancelCmd := &cobra.Command{
+		Use:   "cancel <host>/<request-id>...",
+		Short: "Cancel a queued or running job",
+		Long:  "Removes a job from the queue if it has not started yet, or interrupts it if it is running.  Builds and installs stop the systemd unit they started.",
+		Run:   cancelJob,
+	}
+	AddCommand(gearCmd, cancelCmd, false)
+
 	ExtendCommands(gearCmd, false)
 
 	daemonCmd := &cobra.Command{
@@ -219,6 +465,10 @@ func Execute() {
 		Run:   daemon,
 	}
 	daemonCmd.Flags().StringVarP(&listenAddr, "listen-address", "A", ":43273", "Set the address for the http endpoint to listen on")
+	daemonCmd.Flags().IntVar(&(conf.Dispatcher.UserQueueDepth), "user-queue-depth", 0, "The maximum number of jobs a single client may have waiting, 0 for no limit")
+	daemonCmd.Flags().IntVar(&(conf.Dispatcher.UserConcurrent), "user-concurrency", 0, "The maximum number of jobs a single client may have running at once, 0 for no limit")
+	daemonCmd.Flags().BoolVar(&(conf.TrustRequestUser), "trust-request-user", false, "Share the limits between the users named by the X-Request-User header instead of client addresses - only for daemons behind a front end that sets the header")
+	daemonCmd.Flags().Var(Labels(config.HostLabels), "label", "A key=value label describing this host to placement strategies (may be repeated)")
 	AddCommand(gearCmd, daemonCmd, true)
 
 	purgeCmd := &cobra.Command{
@@ -249,6 +499,16 @@ func gear(cmd *cobra.Command, args []string) {
 	cmd.Help()
 }
 
+// The name of the file a changed deployment is written to, the base name
+// of path with the current time as a suffix.
+func nextDeploymentPath(path string) string {
+	re := regexp.MustCompile("\\.\\d{8}\\-\\d{6}\\z")
+	now := time.Now().Format(".20060102-150405")
+	base := filepath.Base(path)
+	base = re.ReplaceAllString(base, "")
+	return base + now
+}
+
 func deployContainers(cmd *cobra.Command, args []string) {
 	if len(args) < 1 {
 		Fail(1, "Valid arguments: <deployment_file|URL> <host> ...")
@@ -261,6 +521,16 @@ func deployContainers(cmd *cobra.Command, args []string) {
 		Fail(1, "Argument 1 must be deployment file or URL describing how the containers are related")
 	}
 
+	switch deployStrategy {
+	case "recreate":
+	case "rolling":
+		if err := rollingUpdate.Check(); err != nil {
+			Fail(1, err.Error())
+		}
+	default:
+		Fail(1, "Unsupported deployment strategy '%s', must be 'recreate' or 'rolling'", deployStrategy)
+	}
+
 	u, err := url.Parse(path)
 	if nil != err {
 		Fail(1, "Cannot Parse Argument 1: %s", err.Error())
@@ -290,18 +560,57 @@ func deployContainers(cmd *cobra.Command, args []string) {
 		Fail(1, "You must pass zero or more valid host names (use '%s' or pass no arguments for the current server): %s", transport.Local.String(), err.Error())
 	}
 
-	re := regexp.MustCompile("\\.\\d{8}\\-\\d{6}\\z")
-	now := time.Now().Format(".20060102-150405")
-	base := filepath.Base(path)
-	base = re.ReplaceAllString(base, "")
-	newPath := base + now
+	var placement deployment.PlacementStrategy
+	switch placementName {
+	case "simple":
+		placement = deployment.SimplePlacement(servers)
+	case "resources":
+		placement = deployment.NewResourcePlacement(servers, hostInspector(t))
+	default:
+		Fail(1, "Unsupported placement '%s', must be 'simple' or 'resources'", placementName)
+	}
+
+	deploy.ReplaceChangedImages = deployStrategy == "rolling"
+	newPath := nextDeploymentPath(path)
+
+	if planDeploy {
+		plan, err := deploy.Plan(placement, t)
+		if err != nil {
+			Fail(1, "Deployment is not valid: %s", err.Error())
+		}
+		if planJson {
+			err = json.NewEncoder(os.Stdout).Encode(plan)
+		} else {
+			err = plan.WriteTextTo(os.Stdout)
+		}
+		if err != nil {
+			Fail(1, "Unable to write the plan: %s", err.Error())
+		}
+		return
+	}
 
 	fmt.Printf("==> Deploying %s\n", path)
-	changes, removed, err := deploy.Describe(deployment.SimplePlacement(servers), t)
+	changes, removed, err := deploy.Describe(placement, t)
 	if err != nil {
 		Fail(1, "Deployment is not valid: %s", err.Error())
 	}
 
+	if deployStrategy == "rolling" {
+		if err := rollingDeploy(t, changes, removed); err != nil {
+			Fail(1, "Deployment failed and was rolled back: %s", err.Error())
+		}
+		routed := updateRoutes(t, changes)
+		contents, _ := json.Marshal(changes)
+		if err := ioutil.WriteFile(newPath, contents, 0664); err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to write %s: %s\n", newPath, err.Error())
+		}
+		fmt.Printf("==> Deployed as %s\n", newPath)
+		if !routed {
+			os.Exit(1)
+		}
+		return
+	}
+
 	if len(removed) > 0 {
 		removedIds, err := LocatorsForDeploymentInstances(t, removed)
 		if err != nil {
@@ -345,6 +654,7 @@ func deployContainers(cmd *cobra.Command, args []string) {
 
 				Ports:        instance.Ports.PortPairs(),
 				NetworkLinks: &links,
+				Volumes:      instance.Volumes(),
 			}
 		},
 		OnSuccess: func(r *CliJobResponse, w io.Writer, job JobRequest) {
@@ -371,11 +681,6 @@ func deployContainers(cmd *cobra.Command, args []string) {
 		}
 	}
 
-	contents, _ := json.Marshal(changes)
-	if err := ioutil.WriteFile(newPath, contents, 0664); err != nil {
-		fmt.Fprintf(os.Stderr, "Unable to write %s: %s\n", newPath, err.Error())
-	}
-
 	linkedIds, err := LocatorsForDeploymentInstances(t, changes.Instances.Linked())
 	if err != nil {
 		Fail(1, "Unable to generate deployment info: %s", err.Error())
@@ -410,6 +715,11 @@ func deployContainers(cmd *cobra.Command, args []string) {
 		Transport: t,
 	}.Stream()
 
+	routed := updateRoutes(t, changes)
+	contents, _ := json.Marshal(changes)
+	if err := ioutil.WriteFile(newPath, contents, 0664); err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to write %s: %s\n", newPath, err.Error())
+	}
 	fmt.Printf("==> Deployed as %s\n", newPath)
 	if len(errors) > 0 {
 		for i := range errors {
@@ -417,6 +727,53 @@ func deployContainers(cmd *cobra.Command, args []string) {
 		}
 		os.Exit(1)
 	}
+	if !routed {
+		os.Exit(1)
+	}
+}
+
+// Send the routes of the deployment, with the instances that now serve
+// them, to the router host, and record what was routed so the next
+// deployment can remove routes that are no longer defined.
+func updateRoutes(t transport.Transport, changes *deployment.Deployment) bool {
+	if !changes.HasRoutes() {
+		return true
+	}
+	if routerHost == "" {
+		fmt.Fprintf(os.Stderr, "The deployment has routes but no --router host was given, the routes were not updated\n")
+		return true
+	}
+	update, err := changes.RouterUpdate()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to generate the routes of the deployment: %s\n", err.Error())
+		return false
+	}
+	routers, err := NewHostLocators(t, routerHost)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "The router host is not valid: %s\n", err.Error())
+		return false
+	}
+
+	failures := Executor{
+		On: routers,
+		Group: func(on ...Locator) JobRequest {
+			return update
+		},
+		Output: os.Stdout,
+		OnSuccess: func(r *CliJobResponse, w io.Writer, job JobRequest) {
+			routed := deployment.RoutedBy(job.(*rjobs.UpdateFrontendRequest))
+			fmt.Fprintf(w, "==> Routed %d frontends to %d backends\n", len(routed.Frontends), len(routed.Backends))
+		},
+		Transport: t,
+	}.Stream()
+	for i := range failures {
+		fmt.Fprintf(os.Stderr, "Unable to update the routes: %s\n", failures[i].Error())
+	}
+	if len(failures) > 0 {
+		return false
+	}
+	changes.Routed = deployment.RoutedBy(update)
+	return true
 }
 
 func installImage(cmd *cobra.Command, args []string) {
@@ -444,6 +801,11 @@ func installImage(cmd *cobra.Command, args []string) {
 			Fail(1, "Image name and container id must not be the same: %s", imageId)
 		}
 	}
+	if healthCheck.Type != "" {
+		if err := healthCheck.Check(); err != nil {
+			Fail(1, err.Error())
+		}
+	}
 
 	Executor{
 		On: ids,
@@ -460,6 +822,21 @@ func installImage(cmd *cobra.Command, args []string) {
 				Ports:        *portPairs.Get().(*port.PortPairs),
 				Environment:  &environment.Description,
 				NetworkLinks: networkLinks.NetworkLinks,
+
+				Pull:         pullFirst,
+				DockerSocket: conf.Docker.Socket,
+			}
+			if volumeMounts.VolumeMounts != nil {
+				r.Volumes = *volumeMounts.VolumeMounts
+			}
+			if !limits.Empty() {
+				r.Limits = &limits
+			}
+			if healthCheck.Type != "" {
+				r.HealthCheck = &healthCheck
+			}
+			if idlePolicy.Mode != "" {
+				r.IdlePolicy = &idlePolicy
 			}
 			return &r
 		},
@@ -468,6 +845,63 @@ func installImage(cmd *cobra.Command, args []string) {
 	}.StreamAndExit()
 }
 
+func createVolume(cmd *cobra.Command, args []string) {
+	if len(args) < 1 {
+		Fail(1, "Valid arguments: <name> ...")
+	}
+	if volume.Owner != "" {
+		if _, err := containers.NewIdentifier(string(volume.Owner)); err != nil {
+			Fail(1, "The owner must be a valid container name: %s", err.Error())
+		}
+	}
+
+	t := defaultTransport.Get()
+
+	ids, err := NewContainerLocators(t, args...)
+	if err != nil {
+		Fail(1, "You must pass one or more valid volume names: %s", err.Error())
+	}
+
+	Executor{
+		On: ids,
+		Serial: func(on Locator) JobRequest {
+			v := volume
+			v.Id = AsIdentifier(on)
+			return &cjobs.PutVolumeRequest{Volume: v}
+		},
+		Output: os.Stdout,
+		OnSuccess: func(r *CliJobResponse, w io.Writer, job JobRequest) {
+			fmt.Fprintf(w, "Created volume %s\n", string(job.(*cjobs.PutVolumeRequest).Id))
+		},
+		Transport: t,
+	}.StreamAndExit()
+}
+
+func deleteVolume(cmd *cobra.Command, args []string) {
+	if len(args) < 1 {
+		Fail(1, "Valid arguments: <name> ...")
+	}
+
+	t := defaultTransport.Get()
+
+	ids, err := NewContainerLocators(t, args...)
+	if err != nil {
+		Fail(1, "You must pass one or more valid volume names: %s", err.Error())
+	}
+
+	Executor{
+		On: ids,
+		Serial: func(on Locator) JobRequest {
+			return &cjobs.DeleteVolumeRequest{Id: AsIdentifier(on)}
+		},
+		Output: os.Stdout,
+		OnSuccess: func(r *CliJobResponse, w io.Writer, job JobRequest) {
+			fmt.Fprintf(w, "Deleted volume %s\n", string(job.(*cjobs.DeleteVolumeRequest).Id))
+		},
+		Transport: t,
+	}.StreamAndExit()
+}
+
 func buildImage(cmd *cobra.Command, args []string) {
 	if err := environment.ExtractVariablesFrom(&args, false); err != nil {
 		Fail(1, err.Error())
@@ -696,15 +1130,27 @@ func stopContainer(cmd *cobra.Command, args []string) {
 	}.StreamAndExit()
 }
 
-func restartContainer(cmd *cobra.Command, args []string) {
-	t := defaultTransport.Get()
+func addLimitFlags(cmd *cobra.Command) {
+	cmd.Flags().Var(ByteSize{Value: &limits.Memory}, "memory", "Limit the memory of the container, with an optional K, M, or G suffix")
+	cmd.Flags().Uint64Var(&limits.CpuShares, "cpu-shares", 0, "The relative CPU weight of the container (1024 is the default)")
+	cmd.Flags().Uint64Var(&limits.CpuQuota, "cpu-quota", 0, "The percentage of a CPU the container may use (200 for two CPUs)")
+	cmd.Flags().Uint64Var(&limits.BlockIOWeight, "blkio-weight", 0, "The relative block IO weight of the container, 10 to 1000")
+	cmd.Flags().Uint64Var(&limits.Tasks, "tasks", 0, "The maximum number of processes and threads in the container")
+}
 
-	if err := ExtractContainerLocatorsFromDeployment(t, deploymentPath, &args); err != nil {
-		Fail(1, err.Error())
-	}
+func setLimits(cmd *cobra.Command, args []string) {
 	if len(args) < 1 {
 		Fail(1, "Valid arguments: <id> ...")
 	}
+	if limits.Empty() {
+		Fail(1, "You must specify at least one limit to change")
+	}
+	if err := limits.Check(); err != nil {
+		Fail(1, err.Error())
+	}
+
+	t := defaultTransport.Get()
+
 	ids, err := NewContainerLocators(t, args...)
 	if err != nil {
 		Fail(1, "You must pass one or more valid service names: %s", err.Error())
@@ -713,80 +1159,543 @@ func restartContainer(cmd *cobra.Command, args []string) {
 	Executor{
 		On: ids,
 		Serial: func(on Locator) JobRequest {
-			return &cjobs.RestartContainerRequest{
-				Id: AsIdentifier(on),
+			return &cjobs.PatchContainerLimitsRequest{
+				RequestIdentifier: jobs.NewRequestIdentifier(),
+
+				Id:             AsIdentifier(on),
+				ResourceLimits: limits,
 			}
 		},
-		Output:    os.Stdout,
+		Output: os.Stdout,
+		OnSuccess: func(r *CliJobResponse, w io.Writer, job JobRequest) {
+			fmt.Fprintf(w, "Limits set to %s\n", limits.String())
+		},
 		Transport: t,
 	}.StreamAndExit()
 }
 
-func containerStatus(cmd *cobra.Command, args []string) {
+func backupContainer(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		Fail(1, "Valid arguments: <id>")
+	}
+
 	t := defaultTransport.Get()
 
-	if err := ExtractContainerLocatorsFromDeployment(t, deploymentPath, &args); err != nil {
+	ids, err := NewContainerLocators(t, args...)
+	if err != nil {
+		Fail(1, "You must pass a valid service name: %s", err.Error())
+	}
+
+	// the archive is binary, so it is written directly rather than through
+	// the executor which prefixes each line of output
+	job, err := t.RemoteJobFor(ids[0].TransportLocator(), &cjobs.BackupContainerRequest{
+		Id:           AsIdentifier(ids[0]),
+		Quiesce:      quiesce,
+		DockerSocket: conf.Docker.Socket,
+	})
+	if err != nil {
+		Fail(1, "Unable to back up %s: %s", ids[0].Identity(), err.Error())
+	}
+	res := &CliJobResponse{Output: os.Stdout}
+	job.Execute(res)
+	if res.Error != nil {
+		Fail(1, "Unable to back up %s: %s", ids[0].Identity(), res.Error.Error())
+	}
+}
+
+func execContainer(cmd *cobra.Command, args []string) {
+	if len(args) < 2 {
+		Fail(1, "Valid arguments: <id> -- <command>...")
+	}
+
+	t := defaultTransport.Get()
+
+	ids, err := NewContainerLocators(t, args[0])
+	if err != nil {
+		Fail(1, "You must pass a valid service name: %s", err.Error())
+	}
+
+	req := &cjobs.ExecContainerRequest{
+		Id:          AsIdentifier(ids[0]),
+		Command:     args[1:],
+		Environment: execEnv,
+		Tty:         execTty,
+	}
+	if execInteractive {
+		req.Stdin = os.Stdin
+	}
+	if execTty {
+		if rows, columns, err := TerminalSize(os.Stdout.Fd()); err == nil {
+			req.Rows, req.Columns = rows, columns
+		}
+	}
+	if err := req.Check(); err != nil {
 		Fail(1, err.Error())
 	}
-	if len(args) < 1 {
-		Fail(1, "Valid arguments: <id> ...")
+
+	// the output is split back into stdout and stderr rather than passed
+	// through the executor which prefixes each line
+	job, err := t.RemoteJobFor(ids[0].TransportLocator(), req)
+	if err != nil {
+		Fail(1, "Unable to run a command in %s: %s", ids[0].Identity(), err.Error())
+	}
+
+	restore := func() {}
+	if execTty && execInteractive && IsTerminal(os.Stdin.Fd()) {
+		if r, err := MakeTerminalRaw(os.Stdin.Fd()); err == nil {
+			restore = r
+		}
+	}
+	output := containers.NewExecStreamReader(os.Stdout, os.Stderr)
+	res := &CliJobResponse{Output: output}
+	job.Execute(res)
+	restore()
+
+	if res.Error != nil {
+		Fail(1, "Unable to run a command in %s: %s", ids[0].Identity(), res.Error.Error())
+	}
+	code, err := output.ExitCode()
+	if err != nil {
+		Fail(1, "Unable to run a command in %s: %s", ids[0].Identity(), err.Error())
+	}
+	os.Exit(code)
+}
+
+func grantExecAccess(cmd *cobra.Command, args []string) {
+	if len(args) < 1 || execUser == "" {
+		Fail(1, "Valid arguments: <id>... --user=<user>")
+	}
+	if err := containers.CheckExecUser(execUser); err != nil {
+		Fail(1, err.Error())
 	}
+
+	t := defaultTransport.Get()
+
 	ids, err := NewContainerLocators(t, args...)
 	if err != nil {
 		Fail(1, "You must pass one or more valid service names: %s", err.Error())
 	}
 
-	data, errors := Executor{
+	Executor{
 		On: ids,
 		Serial: func(on Locator) JobRequest {
-			return &cjobs.ContainerStatusRequest{
-				Id: AsIdentifier(on),
-			}
+			return &cjobs.GrantExecAccessRequest{Id: AsIdentifier(on), User: execUser}
+		},
+		Output: os.Stdout,
+		OnSuccess: func(r *CliJobResponse, w io.Writer, job JobRequest) {
+			fmt.Fprintf(w, "%s may run commands in %s\n", execUser, string(job.(*cjobs.GrantExecAccessRequest).Id))
 		},
-		Output:    os.Stdout,
 		Transport: t,
-	}.Gather()
+	}.StreamAndExit()
+}
 
-	for i := range data {
-		if buf, ok := data[i].(*bytes.Buffer); ok {
-			if i > 0 {
-				fmt.Fprintf(os.Stdout, "\n-------------\n")
-			}
-			buf.WriteTo(os.Stdout)
-		}
+func revokeExecAccess(cmd *cobra.Command, args []string) {
+	if len(args) < 1 || execUser == "" {
+		Fail(1, "Valid arguments: <id>... --user=<user>")
 	}
-	if len(errors) > 0 {
-		for i := range errors {
-			fmt.Fprintf(os.Stderr, "Error: %s\n", errors[i])
-		}
-		os.Exit(1)
+	if err := containers.CheckExecUser(execUser); err != nil {
+		Fail(1, err.Error())
 	}
-	os.Exit(0)
-}
 
-func listUnits(cmd *cobra.Command, args []string) {
-	t, servers := transportAndHosts(args...)
+	t := defaultTransport.Get()
 
-	data, errors := Executor{
-		On: servers,
-		Group: func(on ...Locator) JobRequest {
-			return &cjobs.ListContainersRequest{}
+	ids, err := NewContainerLocators(t, args...)
+	if err != nil {
+		Fail(1, "You must pass one or more valid service names: %s", err.Error())
+	}
+
+	Executor{
+		On: ids,
+		Serial: func(on Locator) JobRequest {
+			return &cjobs.RevokeExecAccessRequest{Id: AsIdentifier(on), User: execUser}
+		},
+		Output: os.Stdout,
+		OnSuccess: func(r *CliJobResponse, w io.Writer, job JobRequest) {
+			fmt.Fprintf(w, "%s may no longer run commands in %s\n", execUser, string(job.(*cjobs.RevokeExecAccessRequest).Id))
 		},
-		Output:    os.Stdout,
 		Transport: t,
-	}.Gather()
+	}.StreamAndExit()
+}
 
-	combined := cjobs.ListServerContainersResponse{}
-	for i := range data {
-		// if r, ok := data[i].(*cjobs.ListServerContainersResponse); ok {
-		// 	combined.Append(&r.ListContainersResponse)
-		// } else
-		if j, ok := data[i].(*cjobs.ListContainersResponse); ok {
-			combined.Append(j)
-		}
+func idleContainer(cmd *cobra.Command, args []string) {
+	if len(args) < 1 {
+		Fail(1, "Valid arguments: <id> ...")
 	}
-	combined.Sort()
-	combined.WriteTableTo(os.Stdout)
+
+	t := defaultTransport.Get()
+
+	ids, err := NewContainerLocators(t, args...)
+	if err != nil {
+		Fail(1, "You must pass one or more valid service names: %s", err.Error())
+	}
+
+	Executor{
+		On: ids,
+		Serial: func(on Locator) JobRequest {
+			return &cjobs.IdleContainerRequest{Id: AsIdentifier(on)}
+		},
+		Output:    os.Stdout,
+		Transport: t,
+	}.StreamAndExit()
+}
+
+func unidleContainer(cmd *cobra.Command, args []string) {
+	if len(args) < 1 {
+		Fail(1, "Valid arguments: <id> ...")
+	}
+
+	t := defaultTransport.Get()
+
+	ids, err := NewContainerLocators(t, args...)
+	if err != nil {
+		Fail(1, "You must pass one or more valid service names: %s", err.Error())
+	}
+
+	Executor{
+		On: ids,
+		Serial: func(on Locator) JobRequest {
+			return &cjobs.UnidleContainerRequest{Id: AsIdentifier(on)}
+		},
+		Output:    os.Stdout,
+		Transport: t,
+	}.StreamAndExit()
+}
+
+func setIdlePolicy(cmd *cobra.Command, args []string) {
+	if len(args) < 1 || idlePolicyIn == "" {
+		Fail(1, "Valid arguments: <id>... --policy=<policy>")
+	}
+	var policy *containers.IdlePolicy
+	if idlePolicyIn != "default" {
+		p, err := containers.NewIdlePolicyFromString(idlePolicyIn)
+		if err != nil {
+			Fail(1, err.Error())
+		}
+		policy = p
+	}
+
+	t := defaultTransport.Get()
+
+	ids, err := NewContainerLocators(t, args...)
+	if err != nil {
+		Fail(1, "You must pass one or more valid service names: %s", err.Error())
+	}
+
+	Executor{
+		On: ids,
+		Serial: func(on Locator) JobRequest {
+			return &cjobs.SetIdlePolicyRequest{Id: AsIdentifier(on), Policy: policy}
+		},
+		Output: os.Stdout,
+		OnSuccess: func(r *CliJobResponse, w io.Writer, job JobRequest) {
+			fmt.Fprintf(w, "Idle policy of %s set to %s\n", string(job.(*cjobs.SetIdlePolicyRequest).Id), idlePolicyIn)
+		},
+		Transport: t,
+	}.StreamAndExit()
+}
+
+func restoreContainer(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		Fail(1, "Valid arguments: <id>")
+	}
+
+	t := defaultTransport.Get()
+
+	ids, err := NewContainerLocators(t, args...)
+	if err != nil {
+		Fail(1, "You must pass a valid service name: %s", err.Error())
+	}
+
+	Executor{
+		On: ids,
+		Serial: func(on Locator) JobRequest {
+			return &cjobs.RestoreContainerRequest{
+				RequestIdentifier: jobs.NewRequestIdentifier(),
+
+				Id:           AsIdentifier(on),
+				Archive:      os.Stdin,
+				Started:      start,
+				DockerSocket: conf.Docker.Socket,
+			}
+		},
+		Output: os.Stdout,
+		OnSuccess: func(r *CliJobResponse, w io.Writer, job JobRequest) {
+			fmt.Fprintf(w, "Restored as %s\n", string(job.(*cjobs.RestoreContainerRequest).Id))
+		},
+		Transport: t,
+	}.StreamAndExit()
+}
+
+func restartContainer(cmd *cobra.Command, args []string) {
+	t := defaultTransport.Get()
+
+	if err := ExtractContainerLocatorsFromDeployment(t, deploymentPath, &args); err != nil {
+		Fail(1, err.Error())
+	}
+	if len(args) < 1 {
+		Fail(1, "Valid arguments: <id> ...")
+	}
+	ids, err := NewContainerLocators(t, args...)
+	if err != nil {
+		Fail(1, "You must pass one or more valid service names: %s", err.Error())
+	}
+
+	Executor{
+		On: ids,
+		Serial: func(on Locator) JobRequest {
+			return &cjobs.RestartContainerRequest{
+				Id: AsIdentifier(on),
+			}
+		},
+		Output:    os.Stdout,
+		Transport: t,
+	}.StreamAndExit()
+}
+
+func pullImage(cmd *cobra.Command, args []string) {
+	if len(args) < 1 {
+		Fail(1, "Valid arguments: <host>... <image>")
+	}
+	image := args[len(args)-1]
+
+	t, servers := transportAndHosts(args[:len(args)-1]...)
+
+	Executor{
+		On: servers,
+		Group: func(on ...Locator) JobRequest {
+			return &cjobs.PullImageRequest{Image: image, DockerSocket: conf.Docker.Socket}
+		},
+		Output:    os.Stdout,
+		Transport: t,
+	}.StreamAndExit()
+}
+
+func setRegistryCredentials(cmd *cobra.Command, args []string) {
+	if registryCredentials.Password == "" {
+		password, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && err != io.EOF {
+			Fail(1, "Unable to read the password from stdin: %s", err.Error())
+		}
+		registryCredentials.Password = strings.TrimRight(password, "\r\n")
+	}
+	if err := registryCredentials.Check(); err != nil {
+		Fail(1, err.Error())
+	}
+
+	t, servers := transportAndHosts(args...)
+
+	Executor{
+		On: servers,
+		Group: func(on ...Locator) JobRequest {
+			return &cjobs.PutRegistryCredentialsRequest{RegistryCredentials: registryCredentials}
+		},
+		Output: os.Stdout,
+		OnSuccess: func(r *CliJobResponse, w io.Writer, job JobRequest) {
+			fmt.Fprintf(w, "Credentials for %s saved\n", registryCredentials.Registry)
+		},
+		Transport: t,
+	}.StreamAndExit()
+}
+
+func deleteRegistryCredentials(cmd *cobra.Command, args []string) {
+	if err := containers.CheckRegistry(registryCredentials.Registry); err != nil {
+		Fail(1, err.Error())
+	}
+
+	t, servers := transportAndHosts(args...)
+
+	Executor{
+		On: servers,
+		Group: func(on ...Locator) JobRequest {
+			return &cjobs.DeleteRegistryCredentialsRequest{Registry: registryCredentials.Registry}
+		},
+		Output: os.Stdout,
+		OnSuccess: func(r *CliJobResponse, w io.Writer, job JobRequest) {
+			fmt.Fprintf(w, "Credentials for %s removed\n", registryCredentials.Registry)
+		},
+		Transport: t,
+	}.StreamAndExit()
+}
+
+func containerHistory(cmd *cobra.Command, args []string) {
+	if len(args) < 1 {
+		Fail(1, "Valid arguments: <id> ...")
+	}
+
+	t := defaultTransport.Get()
+
+	ids, err := NewContainerLocators(t, args...)
+	if err != nil {
+		Fail(1, "You must pass one or more valid service names: %s", err.Error())
+	}
+
+	data, errors := Executor{
+		On: ids,
+		Serial: func(on Locator) JobRequest {
+			return &cjobs.ContainerHistoryRequest{Id: AsIdentifier(on)}
+		},
+		Output:    os.Stdout,
+		Transport: t,
+	}.Gather()
+
+	for i := range data {
+		if history, ok := data[i].(*cjobs.ContainerHistoryResponse); ok {
+			if i > 0 {
+				fmt.Fprintf(os.Stdout, "\n-------------\n")
+			}
+			history.WriteTableTo(os.Stdout)
+		}
+	}
+	if len(errors) > 0 {
+		for i := range errors {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", errors[i])
+		}
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func rollbackContainer(cmd *cobra.Command, args []string) {
+	if len(args) < 1 || rollbackTo == "" {
+		Fail(1, "Valid arguments: <id>... --to=<request-id>")
+	}
+	if err := containers.CheckUnitVersion(rollbackTo); err != nil {
+		Fail(1, err.Error())
+	}
+
+	t := defaultTransport.Get()
+
+	ids, err := NewContainerLocators(t, args...)
+	if err != nil {
+		Fail(1, "You must pass one or more valid service names: %s", err.Error())
+	}
+
+	Executor{
+		On: ids,
+		Serial: func(on Locator) JobRequest {
+			return &cjobs.RollbackContainerRequest{Id: AsIdentifier(on), To: rollbackTo}
+		},
+		Output:    os.Stdout,
+		Transport: t,
+	}.StreamAndExit()
+}
+
+func containerLogs(cmd *cobra.Command, args []string) {
+	t := defaultTransport.Get()
+
+	if err := ExtractContainerLocatorsFromDeployment(t, deploymentPath, &args); err != nil {
+		Fail(1, err.Error())
+	}
+	if len(args) < 1 {
+		Fail(1, "Valid arguments: <id> ...")
+	}
+	ids, err := NewContainerLocators(t, args...)
+	if err != nil {
+		Fail(1, "You must pass one or more valid service names: %s", err.Error())
+	}
+	if logOptions.Since, err = parseLogTime(logSince); err != nil {
+		Fail(1, "--since %s", err.Error())
+	}
+	if logOptions.Until, err = parseLogTime(logUntil); err != nil {
+		Fail(1, "--until %s", err.Error())
+	}
+
+	Executor{
+		On: ids,
+		Group: func(on ...Locator) JobRequest {
+			ids := make([]containers.Identifier, len(on))
+			for i := range on {
+				ids[i] = AsIdentifier(on[i])
+			}
+			return &cjobs.ContainerLogRequest{Ids: ids, LogOptions: logOptions, Json: logJson}
+		},
+		Output:    os.Stdout,
+		Transport: t,
+		// journal entries carry the server they were written on
+		Unprefixed: logJson,
+	}.StreamAndExit()
+}
+
+// Accepts an absolute time or a duration before now.
+func parseLogTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, errors.New("must be a time in RFC3339 format or a duration like '10m'")
+	}
+	return t, nil
+}
+
+func containerStatus(cmd *cobra.Command, args []string) {
+	t := defaultTransport.Get()
+
+	if err := ExtractContainerLocatorsFromDeployment(t, deploymentPath, &args); err != nil {
+		Fail(1, err.Error())
+	}
+	if len(args) < 1 {
+		Fail(1, "Valid arguments: <id> ...")
+	}
+	ids, err := NewContainerLocators(t, args...)
+	if err != nil {
+		Fail(1, "You must pass one or more valid service names: %s", err.Error())
+	}
+
+	data, errors := Executor{
+		On: ids,
+		Serial: func(on Locator) JobRequest {
+			return &cjobs.ContainerStatusRequest{
+				Id: AsIdentifier(on),
+			}
+		},
+		Output:    os.Stdout,
+		Transport: t,
+	}.Gather()
+
+	for i := range data {
+		if buf, ok := data[i].(*bytes.Buffer); ok {
+			if i > 0 {
+				fmt.Fprintf(os.Stdout, "\n-------------\n")
+			}
+			buf.WriteTo(os.Stdout)
+		}
+	}
+	if len(errors) > 0 {
+		for i := range errors {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", errors[i])
+		}
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func listUnits(cmd *cobra.Command, args []string) {
+	t, servers := transportAndHosts(args...)
+
+	data, errors := Executor{
+		On: servers,
+		Group: func(on ...Locator) JobRequest {
+			return &cjobs.ListContainersRequest{}
+		},
+		Output:    os.Stdout,
+		Transport: t,
+	}.Gather()
+
+	combined := cjobs.ListServerContainersResponse{}
+	for i := range data {
+		// if r, ok := data[i].(*cjobs.ListServerContainersResponse); ok {
+		// 	combined.Append(&r.ListContainersResponse)
+		// } else
+		if j, ok := data[i].(*cjobs.ListContainersResponse); ok {
+			combined.Append(j)
+		}
+	}
+	combined.Sort()
+	combined.WriteTableTo(os.Stdout)
 	if len(errors) > 0 {
 		for i := range errors {
 			fmt.Fprintf(os.Stderr, "Error: %s\n", errors[i])
@@ -796,6 +1705,99 @@ func listUnits(cmd *cobra.Command, args []string) {
 	os.Exit(0)
 }
 
+func jobStatus(cmd *cobra.Command, args []string) {
+	if len(args) < 1 {
+		Fail(1, "Valid arguments: <host>/<request-id> ...")
+	}
+
+	t := defaultTransport.Get()
+
+	ids, err := NewJobLocators(t, args...)
+	if err != nil {
+		Fail(1, "You must pass one or more valid request ids: %s", err.Error())
+	}
+
+	data, errors := Executor{
+		On: ids,
+		Serial: func(on Locator) JobRequest {
+			id, _ := jobs.NewRequestIdentifierFromString(on.(*ResourceLocator).Id)
+			return &dispatcher.JobStatusRequest{Id: id}
+		},
+		Output:    os.Stdout,
+		Transport: t,
+	}.Gather()
+
+	for i := range data {
+		if entry, ok := data[i].(*dispatcher.JournalEntry); ok {
+			if i > 0 {
+				fmt.Fprintf(os.Stdout, "\n-------------\n")
+			}
+			entry.WriteTableTo(os.Stdout)
+		}
+	}
+	if len(errors) > 0 {
+		for i := range errors {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", errors[i])
+		}
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func cancelJob(cmd *cobra.Command, args []string) {
+	if len(args) < 1 {
+		Fail(1, "Valid arguments: <host>/<request-id> ...")
+	}
+
+	t := defaultTransport.Get()
+
+	ids, err := NewJobLocators(t, args...)
+	if err != nil {
+		Fail(1, "You must pass one or more valid request ids: %s", err.Error())
+	}
+
+	Executor{
+		On: ids,
+		Serial: func(on Locator) JobRequest {
+			id, _ := jobs.NewRequestIdentifierFromString(on.(*ResourceLocator).Id)
+			return &dispatcher.CancelJobRequest{Id: id}
+		},
+		Output: os.Stdout,
+		OnSuccess: func(r *CliJobResponse, w io.Writer, job JobRequest) {
+			fmt.Fprintf(w, "Canceled %s\n", job.(*dispatcher.CancelJobRequest).Id.String())
+		},
+		Transport: t,
+	}.StreamAndExit()
+}
+
+func containerEvents(cmd *cobra.Command, args []string) {
+	t, servers := transportAndHosts(args...)
+
+	ids := []containers.Identifier{}
+	types := []string{}
+	if eventIds != "" {
+		for _, value := range strings.Split(eventIds, ",") {
+			id, err := containers.NewIdentifier(value)
+			if err != nil {
+				Fail(1, "You must pass valid container ids: %s", err.Error())
+			}
+			ids = append(ids, id)
+		}
+	}
+	if eventTypes != "" {
+		types = strings.Split(eventTypes, ",")
+	}
+
+	Executor{
+		On: servers,
+		Group: func(on ...Locator) JobRequest {
+			return &cjobs.ContainerEventsRequest{Ids: ids, Types: types}
+		},
+		Output:    os.Stdout,
+		Transport: t,
+	}.StreamAndExit()
+}
+
 func purge(cmd *cobra.Command, args []string) {
 	t, servers := transportAndHosts(args...)
 
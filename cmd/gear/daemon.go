@@ -4,9 +4,13 @@ import (
 	"github.com/spf13/cobra"
 	"log"
 	nethttp "net/http"
-	// "path/filepath"
+	"path/filepath"
 
 	"github.com/openshift/geard/cmd"
+	"github.com/openshift/geard/config"
+	cjobs "github.com/openshift/geard/containers/jobs"
+	"github.com/openshift/geard/dispatcher"
+	"github.com/openshift/geard/metrics"
 	// "github.com/openshift/geard/encrypted"
 )
 
@@ -25,8 +29,17 @@ func daemon(c *cobra.Command, args []string) {
 	// 	nethttp.Handle("/token/", nethttp.StripPrefix("/token", config.Handler(api)))
 	// }
 
+	// only the daemon records jobs, so that commands run locally do not
+	// touch the journal
+	conf.Dispatcher.Journal = dispatcher.NewJournal(filepath.Join(config.ContainerBasePath(), "jobs"))
+	cjobs.ReplayDockerSocket = conf.Docker.Socket
+	metrics.AddCollector(conf.Dispatcher)
 	conf.Dispatcher.Start()
 
+	if err := cmd.StartDaemonExtensions(); err != nil {
+		cmd.Fail(1, "Unable to start server: %s", err.Error())
+	}
+
 	log.Printf("Listening (HTTP) on %s ...", listenAddr)
 	log.Fatal(nethttp.ListenAndServe(listenAddr, nil))
 }
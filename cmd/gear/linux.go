@@ -8,15 +8,22 @@ import (
 	chttp "github.com/openshift/geard/containers/http"
 	cjobs "github.com/openshift/geard/containers/jobs"
 	initcmd "github.com/openshift/geard/containers/systemd/init"
+	"github.com/openshift/geard/dispatcher"
+	dhttp "github.com/openshift/geard/dispatcher/http"
 	gitcmd "github.com/openshift/geard/git/cmd"
 	githttp "github.com/openshift/geard/git/http"
 	gitjobs "github.com/openshift/geard/git/jobs"
 	"github.com/openshift/geard/http"
 	"github.com/openshift/geard/jobs"
 	routercmd "github.com/openshift/geard/router/cmd"
+	rhttp "github.com/openshift/geard/router/http"
+	rjobs "github.com/openshift/geard/router/jobs"
 	sshcmd "github.com/openshift/geard/ssh/cmd"
 	sshhttp "github.com/openshift/geard/ssh/http"
 	sshjobs "github.com/openshift/geard/ssh/jobs"
+	"github.com/openshift/geard/webhooks"
+	whttp "github.com/openshift/geard/webhooks/http"
+	wjobs "github.com/openshift/geard/webhooks/jobs"
 )
 
 func init() {
@@ -32,11 +39,21 @@ func init() {
 	cmd.AddCommandExtension(initcmd.RegisterInit, true)
 	cmd.AddCommandExtension(routercmd.RegisterRouter, true)
 
+	jobs.AddJobExtension(dispatcher.NewJobExtension(conf.Dispatcher))
 	jobs.AddJobExtension(cjobs.NewContainerExtension())
 	jobs.AddJobExtension(gitjobs.NewGitExtension())
 	jobs.AddJobExtension(sshjobs.NewSshExtension())
+	jobs.AddJobExtension(wjobs.NewWebhookExtension())
+	jobs.AddJobExtension(rjobs.NewRouterExtension())
 
+	http.AddHttpExtension(&dhttp.HttpExtension{})
 	http.AddHttpExtension(&chttp.HttpExtension{})
 	http.AddHttpExtension(&githttp.HttpExtension{})
 	http.AddHttpExtension(&sshhttp.HttpExtension{})
+	http.AddHttpExtension(&whttp.HttpExtension{})
+	http.AddHttpExtension(&rhttp.HttpExtension{})
+
+	cmd.AddDaemonExtension(webhooks.StartNotifier)
+	cjobs.DefaultHealthMonitor.Dispatcher = conf.Dispatcher
+	cmd.AddDaemonExtension(cjobs.StartHealthMonitor)
 }
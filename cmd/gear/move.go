@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	. "github.com/openshift/geard/cmd"
+	"github.com/openshift/geard/containers"
+	cjobs "github.com/openshift/geard/containers/jobs"
+	"github.com/openshift/geard/deployment"
+	"github.com/openshift/geard/jobs"
+	"github.com/openshift/geard/port"
+	"github.com/openshift/geard/transport"
+	"github.com/spf13/cobra"
+)
+
+func moveContainer(cmd *cobra.Command, args []string) {
+	if len(args) != 2 {
+		Fail(1, "Valid arguments: <host>/<id> <host>")
+	}
+
+	t := defaultTransport.Get()
+
+	ids, err := NewContainerLocators(t, args[0])
+	if err != nil {
+		Fail(1, "You must pass a valid service name: %s", err.Error())
+	}
+	from := ids[0]
+	id := AsIdentifier(from)
+	to, err := t.LocatorFor(args[1])
+	if err != nil {
+		Fail(1, "You must pass a valid host name: %s", err.Error())
+	}
+	if to.String() == from.TransportLocator().String() {
+		Fail(1, "%s is already on %s", id, to.String())
+	}
+
+	var deploy *deployment.Deployment
+	if deploymentPath != "" {
+		if deploy, err = deployment.NewDeploymentFromFile(deploymentPath); err != nil {
+			Fail(1, "Unable to load deployment from %s: %s", deploymentPath, err.Error())
+		}
+		if _, found := deploy.Instances.Find(id); !found {
+			Fail(1, "%s is not an instance in %s", id, deploymentPath)
+		}
+	}
+
+	// the contents of named volumes stay on the current host
+	manifest, err := describeContainer(t, from)
+	if err != nil {
+		Fail(1, "Unable to describe %s: %s", from.Identity(), err.Error())
+	}
+	if len(manifest.Volumes) > 0 {
+		Fail(1, "%s mounts the named volumes %s, which are not copied when a container is moved", id, manifest.Volumes.ToCompact())
+	}
+
+	fmt.Printf("==> Stopping %s\n", from.Identity())
+	if err := firstError(Executor{
+		On: ids,
+		Serial: func(on Locator) JobRequest {
+			return &cjobs.StoppedContainerStateRequest{Id: AsIdentifier(on)}
+		},
+		Output:    os.Stdout,
+		Transport: t,
+	}.Stream()); err != nil {
+		Fail(1, "Unable to stop %s: %s", from.Identity(), err.Error())
+	}
+
+	fmt.Printf("==> Copying %s to %s\n", id, to.String())
+	ports, err := transferContainer(t, from, to)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to move %s: %s\n", from.Identity(), err.Error())
+		Executor{
+			On: ids,
+			Serial: func(on Locator) JobRequest {
+				return &cjobs.StartedContainerStateRequest{Id: AsIdentifier(on)}
+			},
+			Output:    os.Stdout,
+			Transport: t,
+		}.Stream()
+		os.Exit(1)
+	}
+	if len(ports) > 0 {
+		fmt.Printf("==> Started %s on %s with ports %s\n", id, to.String(), ports.String())
+	} else {
+		fmt.Printf("==> Started %s on %s\n", id, to.String())
+	}
+
+	failures := Executor{
+		On: ids,
+		Serial: func(on Locator) JobRequest {
+			return &cjobs.DeleteContainerRequest{Id: AsIdentifier(on)}
+		},
+		Output: os.Stdout,
+		OnSuccess: func(r *CliJobResponse, w io.Writer, job JobRequest) {
+			fmt.Fprintf(w, "==> Deleted %s", string(job.(*cjobs.DeleteContainerRequest).Id))
+		},
+		Transport: t,
+	}.Stream()
+	for i := range failures {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", failures[i].Error())
+	}
+
+	if deploy != nil {
+		if err := relinkMovedInstance(t, deploy, id, to, ports); err != nil {
+			Fail(1, "The links to %s could not be updated: %s", id, err.Error())
+		}
+	}
+	if len(failures) > 0 {
+		Fail(1, "%s was moved to %s but could not be deleted from %s", id, to.String(), from.TransportLocator().String())
+	}
+}
+
+// Read the manifest of a backup of the container from its current host.
+func describeContainer(t transport.Transport, from Locator) (*containers.BackupManifest, error) {
+	job, err := t.RemoteJobFor(from.TransportLocator(), &cjobs.BackupContainerRequest{
+		Id:           AsIdentifier(from),
+		ManifestOnly: true,
+		DockerSocket: conf.Docker.Socket,
+	})
+	if err != nil {
+		return nil, err
+	}
+	archive := &bytes.Buffer{}
+	res := &CliJobResponse{Output: archive}
+	job.Execute(res)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	backup, err := containers.NewBackupReader(archive)
+	if err != nil {
+		return nil, err
+	}
+	return backup.Manifest, nil
+}
+
+// Stream a backup of the container from its current host into a restore
+// on the destination, returning the ports assigned there.
+func transferContainer(t transport.Transport, from Locator, to transport.Locator) (port.PortPairs, error) {
+	id := AsIdentifier(from)
+
+	backup, err := t.RemoteJobFor(from.TransportLocator(), &cjobs.BackupContainerRequest{
+		Id:           id,
+		DockerSocket: conf.Docker.Socket,
+	})
+	if err != nil {
+		return nil, err
+	}
+	reader, writer := io.Pipe()
+	restoreReq := &cjobs.RestoreContainerRequest{
+		RequestIdentifier: jobs.NewRequestIdentifier(),
+
+		Id:           id,
+		Archive:      reader,
+		Started:      true,
+		DockerSocket: conf.Docker.Socket,
+	}
+	restore, err := t.RemoteJobFor(to, restoreReq)
+	if err != nil {
+		return nil, err
+	}
+
+	backedUp := make(chan error, 1)
+	go func() {
+		res := &CliJobResponse{Output: writer}
+		backup.Execute(res)
+		writer.CloseWithError(res.Error)
+		backedUp <- res.Error
+	}()
+
+	res := &CliJobResponse{Output: ioutil.Discard, Gather: true}
+	restore.Execute(res)
+	// let the backup finish if the restore stopped reading early
+	io.Copy(ioutil.Discard, reader)
+	if err := <-backedUp; err != nil {
+		return nil, err
+	}
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	ports, _ := restoreReq.PortMappingsFrom(res.Pending)
+	return ports, nil
+}
+
+// Record the new location and ports of a moved instance in the deployment
+// and point the links of its peers at it.
+func relinkMovedInstance(t transport.Transport, deploy *deployment.Deployment, id containers.Identifier, to transport.Locator, ports port.PortPairs) error {
+	instance, _ := deploy.Instances.Find(id)
+	instance.Place(to)
+
+	hosts := transport.Locators{}
+	for _, instance := range deploy.Instances {
+		if instance.On == nil {
+			continue
+		}
+		locator, err := t.LocatorFor(*instance.On)
+		if err != nil {
+			return err
+		}
+		hosts = append(hosts, locator)
+	}
+
+	changes, removed, err := deploy.Describe(deployment.SimplePlacement(hosts), t)
+	if err != nil {
+		return err
+	}
+	if len(removed) > 0 || len(changes.Instances.Added()) > 0 {
+		return errors.New(fmt.Sprintf("%s does not match the deployed instances, run 'gear deploy' first", deploymentPath))
+	}
+	moved, _ := changes.Instances.Find(id)
+	moved.Ports.Update(ports)
+	changes.UpdateLinks()
+
+	newPath := nextDeploymentPath(deploymentPath)
+	contents, _ := json.Marshal(changes)
+	if err := ioutil.WriteFile(newPath, contents, 0664); err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to write %s: %s\n", newPath, err.Error())
+	} else {
+		fmt.Printf("==> Deployment updated as %s\n", newPath)
+	}
+
+	linked := changes.Instances.Linked()
+	if len(linked) == 0 {
+		return nil
+	}
+	linkedIds, err := LocatorsForDeploymentInstances(t, linked)
+	if err != nil {
+		return err
+	}
+	return firstError(Executor{
+		On: linkedIds,
+		Group: func(on ...Locator) JobRequest {
+			links := []containers.ContainerLink{}
+			for i := range on {
+				instance, _ := changes.Instances.Find(AsIdentifier(on[i]))
+				if network := instance.NetworkLinks(); len(network) > 0 {
+					links = append(links, containers.ContainerLink{Id: instance.Id, NetworkLinks: network})
+				}
+			}
+			return &cjobs.LinkContainersRequest{ContainerLinks: &containers.ContainerLinks{Links: links}}
+		},
+		Output:    os.Stdout,
+		Transport: t,
+	}.Stream())
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	. "github.com/openshift/geard/cmd"
+	cjobs "github.com/openshift/geard/containers/jobs"
+	"github.com/openshift/geard/deployment"
+	"github.com/openshift/geard/transport"
+)
+
+// Read the capacity of a host from its agent for resource aware
+// placement.
+func hostInspector(t transport.Transport) deployment.HostInspector {
+	return func(on transport.Locator) (*deployment.HostCapacity, error) {
+		data, failures := Executor{
+			On: Locators{&ResourceLocator{At: on}},
+			Group: func(on ...Locator) JobRequest {
+				return &cjobs.HostResourcesRequest{}
+			},
+			Transport: t,
+		}.Gather()
+		if len(failures) > 0 {
+			return nil, failures[0]
+		}
+		if len(data) == 0 {
+			return nil, errors.New("no resources were returned")
+		}
+		r, ok := data[0].(*cjobs.HostResourcesResponse)
+		if !ok {
+			return nil, errors.New(fmt.Sprintf("unexpected response %+v", data[0]))
+		}
+		return &deployment.HostCapacity{
+			Containers:      r.Containers,
+			MemoryAvailable: r.MemoryAvailable,
+			Cpus:            r.Cpus,
+			Load:            r.Load,
+			Labels:          r.Labels,
+		}, nil
+	}
+}
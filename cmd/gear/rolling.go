@@ -0,0 +1,282 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	. "github.com/openshift/geard/cmd"
+	"github.com/openshift/geard/containers"
+	cjobs "github.com/openshift/geard/containers/jobs"
+	"github.com/openshift/geard/deployment"
+	"github.com/openshift/geard/jobs"
+	"github.com/openshift/geard/transport"
+)
+
+// Replace changed instances in batches, waiting for each batch to become
+// active before continuing.  If a batch fails, the new instances are
+// deleted and the old instances are started and relinked.
+func rollingDeploy(t transport.Transport, changes *deployment.Deployment, removed deployment.InstanceRefs) error {
+	rollout, err := rollingUpdate.Plan(changes, removed)
+	if err != nil {
+		return err
+	}
+
+	stopped := deployment.InstanceRefs{}
+	installed := deployment.InstanceRefs{}
+	for i, batch := range rollout.Batches {
+		fmt.Printf("==> Updating batch %d of %d\n", i+1, len(rollout.Batches))
+
+		stopped = append(stopped, batch.Stopped...)
+		if err := stopInstances(t, rollout, batch.Stopped); err != nil {
+			rollbackInstances(t, rollout, installed, stopped)
+			return err
+		}
+		installed = append(installed, batch.Added...)
+		if err := startInstances(t, changes, rollout, batch.Added); err != nil {
+			rollbackInstances(t, rollout, installed, stopped)
+			return err
+		}
+		stopped = append(stopped, batch.Retired...)
+		if err := stopInstances(t, rollout, batch.Retired); err != nil {
+			rollbackInstances(t, rollout, installed, stopped)
+			return err
+		}
+	}
+
+	// instances are only deleted once the update can no longer be rolled back
+	obsolete := deployment.InstanceRefs{}
+	obsolete = append(obsolete, rollout.Replaced()...)
+	for i := range removed {
+		if !removed[i].Replaced() {
+			obsolete = append(obsolete, removed[i])
+		}
+	}
+	for _, err := range deleteInstances(t, obsolete) {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err.Error())
+	}
+	return nil
+}
+
+func stopInstances(t transport.Transport, rollout *deployment.Rollout, instances deployment.InstanceRefs) error {
+	if len(instances) == 0 {
+		return nil
+	}
+	ids, err := LocatorsForDeploymentInstances(t, instances)
+	if err != nil {
+		return err
+	}
+
+	rollout.Deactivate(instances)
+	if err := relinkInstances(t, rollout); err != nil {
+		return err
+	}
+	return firstError(Executor{
+		On: ids,
+		Serial: func(on Locator) JobRequest {
+			return &cjobs.StoppedContainerStateRequest{
+				Id: AsIdentifier(on),
+			}
+		},
+		Output:    os.Stdout,
+		Transport: t,
+	}.Stream())
+}
+
+func startInstances(t transport.Transport, changes *deployment.Deployment, rollout *deployment.Rollout, instances deployment.InstanceRefs) error {
+	if len(instances) == 0 {
+		return nil
+	}
+	ids, err := LocatorsForDeploymentInstances(t, instances)
+	if err != nil {
+		return err
+	}
+
+	if err := firstError(Executor{
+		On: ids,
+		Serial: func(on Locator) JobRequest {
+			instance, _ := changes.Instances.Find(AsIdentifier(on))
+			links := rollout.NetworkLinks(instance)
+			return &cjobs.InstallContainerRequest{
+				RequestIdentifier: jobs.NewRequestIdentifier(),
+
+				Id:      instance.Id,
+				Image:   instance.Image,
+				Isolate: isolate,
+
+				Ports:        instance.Ports.PortPairs(),
+				NetworkLinks: &links,
+				Volumes:      instance.Volumes(),
+			}
+		},
+		OnSuccess: func(r *CliJobResponse, w io.Writer, job JobRequest) {
+			installJob := job.(*cjobs.InstallContainerRequest)
+			instance, _ := changes.Instances.Find(installJob.Id)
+			if pairs, ok := installJob.PortMappingsFrom(r.Pending); ok {
+				if !instance.Ports.Update(pairs) {
+					fmt.Fprintf(os.Stderr, "Not all ports listed %+v were returned by the server %+v", instance.Ports, pairs)
+				}
+			}
+		},
+		Output:    os.Stdout,
+		Transport: t,
+	}.Stream()); err != nil {
+		return err
+	}
+
+	changes.UpdateLinks()
+	rollout.Activate(instances)
+	if err := relinkInstances(t, rollout); err != nil {
+		return err
+	}
+
+	if err := firstError(Executor{
+		On: ids,
+		Serial: func(on Locator) JobRequest {
+			return &cjobs.StartedContainerStateRequest{
+				Id: AsIdentifier(on),
+			}
+		},
+		Output:    os.Stdout,
+		Transport: t,
+	}.Stream()); err != nil {
+		return err
+	}
+	return waitForActive(t, ids, time.Duration(rolloutTimeout)*time.Second)
+}
+
+func rollbackInstances(t transport.Transport, rollout *deployment.Rollout, installed, stopped deployment.InstanceRefs) {
+	fmt.Printf("==> Rolling back\n")
+	rollout.Deactivate(installed)
+	rollout.Activate(stopped)
+
+	failures := []error{}
+	if err := relinkInstances(t, rollout); err != nil {
+		failures = append(failures, err)
+	}
+	if ids, err := LocatorsForDeploymentInstances(t, stopped); err != nil {
+		failures = append(failures, err)
+	} else if len(ids) > 0 {
+		failures = append(failures, Executor{
+			On: ids,
+			Serial: func(on Locator) JobRequest {
+				return &cjobs.StartedContainerStateRequest{
+					Id: AsIdentifier(on),
+				}
+			},
+			Output:    os.Stdout,
+			Transport: t,
+		}.Stream()...)
+	}
+	failures = append(failures, deleteInstances(t, installed)...)
+	for i := range failures {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", failures[i].Error())
+	}
+}
+
+// Link every running instance to the instances that are currently
+// running.
+func relinkInstances(t transport.Transport, rollout *deployment.Rollout) error {
+	linked := rollout.Linked()
+	if len(linked) == 0 {
+		return nil
+	}
+	ids, err := LocatorsForDeploymentInstances(t, linked)
+	if err != nil {
+		return err
+	}
+	return firstError(Executor{
+		On: ids,
+		Group: func(on ...Locator) JobRequest {
+			links := []containers.ContainerLink{}
+			for i := range on {
+				for _, instance := range linked {
+					if instance.Id == AsIdentifier(on[i]) {
+						links = append(links, containers.ContainerLink{Id: instance.Id, NetworkLinks: rollout.NetworkLinks(instance)})
+					}
+				}
+			}
+			return &cjobs.LinkContainersRequest{ContainerLinks: &containers.ContainerLinks{Links: links}}
+		},
+		Output:    os.Stdout,
+		Transport: t,
+	}.Stream())
+}
+
+func deleteInstances(t transport.Transport, instances deployment.InstanceRefs) []error {
+	if len(instances) == 0 {
+		return []error{}
+	}
+	ids, err := LocatorsForDeploymentInstances(t, instances)
+	if err != nil {
+		return []error{err}
+	}
+	return Executor{
+		On: ids,
+		Serial: func(on Locator) JobRequest {
+			return &cjobs.DeleteContainerRequest{
+				Id: AsIdentifier(on),
+			}
+		},
+		Output: os.Stdout,
+		OnSuccess: func(r *CliJobResponse, w io.Writer, job JobRequest) {
+			fmt.Fprintf(w, "==> Deleted %s", string(job.(*cjobs.DeleteContainerRequest).Id))
+		},
+		Transport: t,
+	}.Stream()
+}
+
+// Poll the servers until every container is active, one has failed, or
+// the timeout is reached.
+func waitForActive(t transport.Transport, ids Locators, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		data, failures := Executor{
+			On: ids,
+			Group: func(on ...Locator) JobRequest {
+				return &cjobs.ListContainersRequest{}
+			},
+			Transport: t,
+		}.Gather()
+		if err := firstError(failures); err != nil {
+			return err
+		}
+
+		states := make(map[string]string)
+		for i := range data {
+			if r, ok := data[i].(*cjobs.ListContainersResponse); ok {
+				for _, container := range r.Containers {
+					states[container.Id] = container.ActiveState
+				}
+			}
+		}
+
+		pending := 0
+		for i := range ids {
+			id := string(AsIdentifier(ids[i]))
+			switch states[id] {
+			case "active":
+			case "failed":
+				return errors.New(fmt.Sprintf("%s failed to start", id))
+			default:
+				pending++
+			}
+		}
+		if pending == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return errors.New(fmt.Sprintf("%d instances did not become active within %s", pending, timeout))
+		}
+		time.Sleep(2 * time.Second)
+	}
+}
+
+func firstError(failures []error) error {
+	if len(failures) > 0 {
+		return failures[0]
+	}
+	return nil
+}
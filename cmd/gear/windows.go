@@ -5,9 +5,11 @@ package main
 import (
 	"github.com/openshift/geard/cmd"
 	chttp "github.com/openshift/geard/containers/http"
+	dhttp "github.com/openshift/geard/dispatcher/http"
 	gitcmd "github.com/openshift/geard/git/cmd"
 	githttp "github.com/openshift/geard/git/http"
 	"github.com/openshift/geard/http"
+	rhttp "github.com/openshift/geard/router/http"
 	sshcmd "github.com/openshift/geard/ssh/cmd"
 	sshhttp "github.com/openshift/geard/ssh/http"
 )
@@ -20,7 +22,9 @@ func init() {
 	b := &sshcmd.Command{&defaultTransport.TransportFlag}
 	cmd.AddCommandExtension(b.RegisterAddKeys, false)
 
+	http.AddHttpExtension(&dhttp.HttpExtension{})
 	http.AddHttpExtension(&chttp.HttpExtension{})
 	http.AddHttpExtension(&githttp.HttpExtension{})
 	http.AddHttpExtension(&sshhttp.HttpExtension{})
+	http.AddHttpExtension(&rhttp.HttpExtension{})
 }
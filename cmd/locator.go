@@ -3,6 +3,7 @@ package cmd
 import (
 	"errors"
 	"github.com/openshift/geard/containers"
+	"github.com/openshift/geard/jobs"
 	"github.com/openshift/geard/transport"
 	"strings"
 )
@@ -52,6 +53,9 @@ type ResourceType string
 // A container resource
 const ResourceTypeContainer ResourceType = "ctr"
 
+// A job submitted to a server, identified by its request id
+const ResourceTypeJob ResourceType = "job"
+
 type ResourceValidator interface {
 	Type() ResourceType
 }
@@ -125,6 +129,20 @@ func NewContainerLocators(t transport.Transport, values ...string) (Locators, er
 	return locators, nil
 }
 
+func NewJobLocators(t transport.Transport, values ...string) (Locators, error) {
+	locators, err := NewResourceLocators(t, ResourceTypeJob, values...)
+	if err != nil {
+		return Locators{}, err
+	}
+	for i := range locators {
+		_, err := jobs.NewRequestIdentifierFromString(locators[i].(*ResourceLocator).Id)
+		if err != nil {
+			return Locators{}, err
+		}
+	}
+	return locators, nil
+}
+
 // Given a command line string representing a resource, break it into type, host identity, and suffix
 func SplitTypeHostSuffix(value string) (res ResourceType, host string, suffix string, err error) {
 	if value == "" {
@@ -0,0 +1,59 @@
+// +build linux
+
+package cmd
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+type winsize struct {
+	Rows    uint16
+	Columns uint16
+	X       uint16
+	Y       uint16
+}
+
+func IsTerminal(fd uintptr) bool {
+	var termios syscall.Termios
+	return ioctl(fd, syscall.TCGETS, uintptr(unsafe.Pointer(&termios))) == nil
+}
+
+// The size of the terminal on fd.
+func TerminalSize(fd uintptr) (rows, columns uint16, err error) {
+	var size winsize
+	if err := ioctl(fd, syscall.TIOCGWINSZ, uintptr(unsafe.Pointer(&size))); err != nil {
+		return 0, 0, err
+	}
+	return size.Rows, size.Columns, nil
+}
+
+// Pass input on the terminal on fd through unprocessed, returning a
+// function that restores the previous mode.
+func MakeTerminalRaw(fd uintptr) (func(), error) {
+	var previous syscall.Termios
+	if err := ioctl(fd, syscall.TCGETS, uintptr(unsafe.Pointer(&previous))); err != nil {
+		return nil, err
+	}
+	raw := previous
+	raw.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP | syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	raw.Oflag &^= syscall.OPOST
+	raw.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.Cflag &^= syscall.CSIZE | syscall.PARENB
+	raw.Cflag |= syscall.CS8
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	if err := ioctl(fd, syscall.TCSETS, uintptr(unsafe.Pointer(&raw))); err != nil {
+		return nil, err
+	}
+	return func() {
+		ioctl(fd, syscall.TCSETS, uintptr(unsafe.Pointer(&previous)))
+	}, nil
+}
+
+func ioctl(fd, request, arg uintptr) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, request, arg); errno != 0 {
+		return errno
+	}
+	return nil
+}
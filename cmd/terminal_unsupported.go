@@ -0,0 +1,21 @@
+// +build !linux
+
+package cmd
+
+import (
+	"errors"
+)
+
+var ErrTerminalUnsupported = errors.New("Terminals are not supported on this platform")
+
+func IsTerminal(fd uintptr) bool {
+	return false
+}
+
+func TerminalSize(fd uintptr) (rows, columns uint16, err error) {
+	return 0, 0, ErrTerminalUnsupported
+}
+
+func MakeTerminalRaw(fd uintptr) (func(), error) {
+	return nil, ErrTerminalUnsupported
+}
@@ -49,4 +49,7 @@ var (
 	SystemDockerFeatures = DockerFeatures{}
 	basePath             = "/var/lib/containers"
 	runPath              = "/var/run/containers"
+
+	// Labels describing this host, reported to placement strategies
+	HostLabels = map[string]string{}
 )
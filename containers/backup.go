@@ -0,0 +1,328 @@
+package containers
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/openshift/geard/port"
+)
+
+const BackupManifestName = "manifest.json"
+
+var ErrInvalidBackup = errors.New("The archive is not a container backup - the first entry must be " + BackupManifestName)
+
+// Describes the container a backup was taken from, so that restoring the
+// archive on any host can recreate it.
+type BackupManifest struct {
+	Id           Identifier
+	Image        string
+	Isolate      bool                 `json:",omitempty"`
+	Ports        port.PortPairs       `json:",omitempty"`
+	Environment  EnvironmentVariables `json:",omitempty"`
+	Limits       ResourceLimits
+	Volumes      VolumeMounts `json:",omitempty"`
+	NetworkLinks NetworkLinks `json:",omitempty"`
+	HealthCheck  *HealthCheck `json:",omitempty"`
+	IdlePolicy   *IdlePolicy  `json:",omitempty"`
+	// The paths of the volumes in the data container that are archived
+	DataVolumes []string `json:",omitempty"`
+	Created     time.Time
+}
+
+func GetBackupManifest(id Identifier) (*BackupManifest, error) {
+	unit, err := ioutil.ReadFile(id.UnitPathFor())
+	if err != nil {
+		return nil, err
+	}
+	manifest, envPath, err := readManifestFromUnitFile(unit)
+	if err != nil {
+		return nil, err
+	}
+	manifest.Id = id
+	links, err := GetExistingNetworkLinks(id)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if len(links) > 0 {
+		manifest.NetworkLinks = links
+	}
+	if check, err := GetHealthCheck(id); err == nil {
+		manifest.HealthCheck = check
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	if policy, err := GetIdlePolicy(id); err == nil {
+		manifest.IdlePolicy = policy
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	if envPath != "" {
+		file, err := os.Open(envPath)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if err == nil {
+			defer file.Close()
+			env := &EnvironmentDescription{}
+			if err := env.ReadFrom(file); err != nil {
+				return nil, err
+			}
+			manifest.Environment = env.Variables
+		}
+	}
+	return manifest, nil
+}
+
+// Read the manifest and the path of the environment file from a unit.
+func readManifestFromUnitFile(unit []byte) (*BackupManifest, string, error) {
+	manifest := &BackupManifest{Created: time.Now().UTC()}
+	envPath := ""
+	scan := bufio.NewScanner(bytes.NewReader(unit))
+	for scan.Scan() {
+		parts := strings.SplitN(strings.TrimSpace(scan.Text()), "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		switch parts[0] {
+		case "X-ContainerImage":
+			manifest.Image = parts[1]
+		case "X-ContainerType":
+			manifest.Isolate = parts[1] == "isolated"
+		case "EnvironmentFile":
+			envPath = strings.TrimPrefix(parts[1], "-")
+		}
+	}
+	if scan.Err() != nil {
+		return nil, "", scan.Err()
+	}
+	if manifest.Image == "" {
+		return nil, "", errors.New("The unit file does not describe a container image")
+	}
+
+	ports, err := readPortsFromUnitFile(bytes.NewReader(unit))
+	if err != nil {
+		return nil, "", err
+	}
+	manifest.Ports = ports
+	limits, err := readLimitsFromUnitFile(bytes.NewReader(unit))
+	if err != nil {
+		return nil, "", err
+	}
+	manifest.Limits = *limits
+	volumes, err := readVolumesFromUnitFile(bytes.NewReader(unit))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(volumes) > 0 {
+		manifest.Volumes = volumes
+	}
+	return manifest, envPath, nil
+}
+
+// Writes a gzipped tar archive that begins with a manifest.
+type BackupWriter struct {
+	gz *gzip.Writer
+	tw *tar.Writer
+}
+
+func NewBackupWriter(w io.Writer, manifest *BackupManifest) (*BackupWriter, error) {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	gz := gzip.NewWriter(w)
+	b := &BackupWriter{gz, tar.NewWriter(gz)}
+	header := &tar.Header{Name: BackupManifestName, Mode: 0600, Size: int64(len(data)), ModTime: manifest.Created, Typeflag: tar.TypeReg}
+	if err := b.tw.WriteHeader(header); err != nil {
+		return nil, err
+	}
+	if _, err := b.tw.Write(data); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Add the contents of a directory to the archive under prefix.
+func (b *BackupWriter) AddDirectory(prefix, root string) error {
+	return filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(p); err != nil {
+				return err
+			}
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			// sockets and devices are not archived
+			return nil
+		}
+		header.Name = path.Join(prefix, filepath.ToSlash(rel))
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		if err := b.tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		file, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.CopyN(b.tw, file, header.Size)
+		return err
+	})
+}
+
+func (b *BackupWriter) Close() error {
+	if err := b.tw.Close(); err != nil {
+		return err
+	}
+	return b.gz.Close()
+}
+
+// Reads an archive written by a BackupWriter.
+type BackupReader struct {
+	Manifest *BackupManifest
+
+	tr *tar.Reader
+}
+
+func NewBackupReader(r io.Reader) (*BackupReader, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(gz)
+	header, err := tr.Next()
+	if err != nil || header.Name != BackupManifestName {
+		return nil, ErrInvalidBackup
+	}
+	manifest := &BackupManifest{}
+	if err := json.NewDecoder(io.LimitReader(tr, 1024*1024)).Decode(manifest); err != nil {
+		return nil, err
+	}
+	return &BackupReader{manifest, tr}, nil
+}
+
+// Extract the remaining entries of the archive.  Each entry is written
+// under the directory in roots whose key is the longest prefix of its
+// name - entries that match no prefix are skipped.
+func (b *BackupReader) ExtractTo(roots map[string]string) error {
+	for {
+		header, err := b.tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		prefix := ""
+		for key := range roots {
+			if (header.Name == key || strings.HasPrefix(header.Name, key+"/")) && len(key) > len(prefix) {
+				prefix = key
+			}
+		}
+		if prefix == "" {
+			continue
+		}
+		root := roots[prefix]
+		target := filepath.Join(root, filepath.FromSlash(path.Clean("/"+strings.TrimPrefix(header.Name, prefix))))
+		if err := extractEntry(b.tr, header, root, target); err != nil {
+			return err
+		}
+	}
+}
+
+func extractEntry(r io.Reader, header *tar.Header, root, target string) error {
+	if target != filepath.Clean(root) && !insideRoot(root, target) {
+		return errors.New(fmt.Sprintf("The archive entry %s is outside of %s", header.Name, root))
+	}
+
+	mode := os.FileMode(header.Mode) & os.ModePerm
+	switch header.Typeflag {
+	case tar.TypeDir:
+		// an earlier entry may have placed a symlink here, which MkdirAll
+		// and Chmod would follow out of the root
+		if info, err := os.Lstat(target); err == nil && info.Mode()&os.ModeSymlink != 0 {
+			return errors.New(fmt.Sprintf("The archive entry %s is a directory in place of a symlink", header.Name))
+		}
+		if err := os.MkdirAll(target, mode); err != nil {
+			return err
+		}
+		if err := os.Chmod(target, mode); err != nil {
+			return err
+		}
+	case tar.TypeSymlink:
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		os.Remove(target)
+		if err := os.Symlink(header.Linkname, target); err != nil {
+			return err
+		}
+	case tar.TypeReg:
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		os.Remove(target)
+		file, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, mode)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(file, r); err != nil {
+			file.Close()
+			return err
+		}
+		if err := file.Close(); err != nil {
+			return err
+		}
+	default:
+		// hard links and special files are not restored
+		return nil
+	}
+	// ownership can only be restored when running as root
+	if os.Geteuid() == 0 {
+		return os.Lchown(target, header.Uid, header.Gid)
+	}
+	return nil
+}
+
+// True if the nearest existing parent of target resolves to a directory
+// within root, so extracting cannot write through a symlink.
+func insideRoot(root, target string) bool {
+	base, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return false
+	}
+	for dir := filepath.Dir(target); ; dir = filepath.Dir(dir) {
+		resolved, err := filepath.EvalSymlinks(dir)
+		if err == nil {
+			return resolved == base || strings.HasPrefix(resolved, base+string(filepath.Separator))
+		}
+		if !os.IsNotExist(err) || dir == filepath.Dir(dir) {
+			return false
+		}
+	}
+}
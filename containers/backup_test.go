@@ -0,0 +1,182 @@
+package containers
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const backupUnit = `[Unit]
+Description=Container test
+
+[Service]
+Type=simple
+EnvironmentFile=-/var/lib/containers/env/contents/te/test
+MemoryLimit=512M
+CPUShares=512
+ExecStart=/usr/bin/docker run --name "test" --rm -a stdout -a stderr --memory=536870912 --cpu-shares=512 openshift/busybox-http-app
+
+[Install]
+WantedBy=container-active.target
+
+# Container information
+X-ContainerId=test
+X-ContainerImage=openshift/busybox-http-app
+X-ContainerUserId=ctr-test
+X-ContainerRequestId=abcd
+X-ContainerType=isolated
+X-PortMapping=8080:4000
+X-Volume=data:/var/lib/data
+`
+
+func TestReadManifestFromUnitFile(t *testing.T) {
+	manifest, envPath, err := readManifestFromUnitFile([]byte(backupUnit))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Image != "openshift/busybox-http-app" || !manifest.Isolate {
+		t.Errorf("Unexpected image or type: %+v", manifest)
+	}
+	if envPath != "/var/lib/containers/env/contents/te/test" {
+		t.Errorf("Unexpected environment path: %s", envPath)
+	}
+	if len(manifest.Ports) != 1 || manifest.Ports[0].Internal != 8080 || manifest.Ports[0].External != 4000 {
+		t.Errorf("Unexpected ports: %+v", manifest.Ports)
+	}
+	if manifest.Limits.Memory != 512*1024*1024 || manifest.Limits.CpuShares != 512 {
+		t.Errorf("Unexpected limits: %+v", manifest.Limits)
+	}
+	if len(manifest.Volumes) != 1 || manifest.Volumes[0].Volume != "data" {
+		t.Errorf("Unexpected volumes: %+v", manifest.Volumes)
+	}
+
+	if _, _, err := readManifestFromUnitFile([]byte("[Unit]\n")); err == nil {
+		t.Error("Expected an error for a unit without an image")
+	}
+}
+
+func TestBackupRoundTrip(t *testing.T) {
+	source, err := ioutil.TempDir("", "backup-source")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(source)
+	target, err := ioutil.TempDir("", "backup-target")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(target)
+
+	if err := os.MkdirAll(filepath.Join(source, "app", "logs"), 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(source, "app", "config"), []byte("port=8080\n"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("config", filepath.Join(source, "app", "current")); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	manifest := &BackupManifest{Id: "test", Image: "openshift/busybox-http-app", Environment: EnvironmentVariables{{Name: "A", Value: "1"}}}
+	w, err := NewBackupWriter(buf, manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.AddDirectory("home", source); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.AddDirectory("data/var/lib/data", source); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewBackupReader(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Manifest.Image != manifest.Image || len(r.Manifest.Environment) != 1 {
+		t.Errorf("Unexpected manifest: %+v", r.Manifest)
+	}
+	// only the home directory is restored
+	if err := r.ExtractTo(map[string]string{"home": target}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(target, "app", "config"))
+	if err != nil || string(data) != "port=8080\n" {
+		t.Errorf("Unexpected restored file: %q %v", string(data), err)
+	}
+	if info, err := os.Stat(filepath.Join(target, "app", "logs")); err != nil || !info.IsDir() || info.Mode().Perm() != 0750 {
+		t.Errorf("Unexpected restored directory: %+v %v", info, err)
+	}
+	if link, err := os.Readlink(filepath.Join(target, "app", "current")); err != nil || link != "config" {
+		t.Errorf("Unexpected restored link: %s %v", link, err)
+	}
+	if _, err := os.Stat(filepath.Join(target, "var")); !os.IsNotExist(err) {
+		t.Errorf("Entries outside of the roots should be skipped: %v", err)
+	}
+
+	if _, err := NewBackupReader(bytes.NewBufferString("not an archive")); err == nil {
+		t.Error("Expected an error for an invalid archive")
+	}
+}
+
+func TestExtractDoesNotFollowArchivedSymlinks(t *testing.T) {
+	outside, err := ioutil.TempDir("", "backup-outside")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(outside)
+	if err := os.Chmod(outside, 0700); err != nil {
+		t.Fatal(err)
+	}
+	target, err := ioutil.TempDir("", "backup-target")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(target)
+
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+	manifest := []byte(`{"Id":"test"}`)
+	entries := []struct {
+		header *tar.Header
+		body   []byte
+	}{
+		{&tar.Header{Name: BackupManifestName, Mode: 0600, Size: int64(len(manifest)), Typeflag: tar.TypeReg}, manifest},
+		{&tar.Header{Name: "home/evil", Linkname: outside, Mode: 0777, Typeflag: tar.TypeSymlink}, nil},
+		{&tar.Header{Name: "home/evil/", Mode: 0777, Typeflag: tar.TypeDir}, nil},
+	}
+	for _, e := range entries {
+		if err := tw.WriteHeader(e.header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(e.body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewBackupReader(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.ExtractTo(map[string]string{"home": target}); err == nil {
+		t.Error("Expected a directory entry in place of a symlink to be refused")
+	}
+	if info, err := os.Stat(outside); err != nil || info.Mode().Perm() != 0700 {
+		t.Errorf("The directory outside the root was changed: %+v %v", info, err)
+	}
+}
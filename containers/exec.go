@@ -0,0 +1,193 @@
+package containers
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// The output of a process run in a container is returned as a series of
+// frames, each an 8 byte header holding the stream and the length of the
+// payload that follows.  The last frame carries the exit code of the
+// process.
+const (
+	ExecStdout byte = 1
+	ExecStderr byte = 2
+	ExecExit   byte = 3
+
+	execHeaderLength = 8
+)
+
+var ErrExecNoExitCode = errors.New("The process output ended before the process exited")
+
+var allowedExecUser = regexp.MustCompile("\\A[a-zA-Z0-9_][a-zA-Z0-9_@\\-]{0,63}\\z")
+
+func CheckExecUser(user string) error {
+	if !allowedExecUser.MatchString(user) {
+		return errors.New("User must match " + allowedExecUser.String())
+	}
+	return nil
+}
+
+// Environment variables passed to a process in a container must be in
+// KEY=VALUE form, as with switchns --env.
+func CheckExecEnvironment(env []string) error {
+	for i := range env {
+		parts := strings.SplitN(env[i], "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return errors.New(fmt.Sprintf("The environment variable '%s' must be in KEY=VALUE format", env[i]))
+		}
+	}
+	return nil
+}
+
+// Allow a user to run processes inside a container.
+func GrantExecAccess(id Identifier, user string) error {
+	f, err := os.OpenFile(id.ExecAccessPathFor(user), os.O_CREATE|os.O_WRONLY, 0640)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func RevokeExecAccess(id Identifier, user string) error {
+	if err := os.Remove(id.ExecAccessPathFor(user)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Requests made without a user come from the administrator of the server
+// and may run processes in any container no user has been granted access
+// to.
+func HasExecAccess(id Identifier, user string) bool {
+	if user == "" {
+		return !hasExecAccessList(id)
+	}
+	if err := CheckExecUser(user); err != nil {
+		return false
+	}
+	_, err := os.Stat(id.ExecAccessPathFor(user))
+	return err == nil
+}
+
+// True if any user has been granted access to the container, or if that
+// cannot be determined.
+func hasExecAccessList(id Identifier) bool {
+	users, err := ioutil.ReadDir(filepath.Dir(id.ExecAccessPathFor("-")))
+	if err != nil {
+		return !os.IsNotExist(err)
+	}
+	return len(users) > 0
+}
+
+// Writes the output of a process as frames on a shared stream.  Writes
+// from different streams may be made concurrently.
+type ExecStreamWriter struct {
+	lock sync.Mutex
+	w    io.Writer
+}
+
+func NewExecStreamWriter(w io.Writer) *ExecStreamWriter {
+	return &ExecStreamWriter{w: w}
+}
+
+func (s *ExecStreamWriter) writeFrame(stream byte, p []byte) error {
+	frame := make([]byte, execHeaderLength+len(p))
+	frame[0] = stream
+	binary.BigEndian.PutUint32(frame[4:execHeaderLength], uint32(len(p)))
+	copy(frame[execHeaderLength:], p)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	_, err := s.w.Write(frame)
+	return err
+}
+
+// A writer for one of the output streams of the process.
+func (s *ExecStreamWriter) Stream(stream byte) io.Writer {
+	return execStream{s, stream}
+}
+
+func (s *ExecStreamWriter) WriteExitCode(code int) error {
+	return s.writeFrame(ExecExit, []byte(strconv.Itoa(code)))
+}
+
+type execStream struct {
+	s      *ExecStreamWriter
+	stream byte
+}
+
+func (e execStream) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if err := e.s.writeFrame(e.stream, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Splits the frames written to it back into the output streams of the
+// process, recording the exit code.
+type ExecStreamReader struct {
+	Stdout io.Writer
+	Stderr io.Writer
+
+	buf    []byte
+	code   int
+	exited bool
+}
+
+func NewExecStreamReader(stdout, stderr io.Writer) *ExecStreamReader {
+	return &ExecStreamReader{Stdout: stdout, Stderr: stderr}
+}
+
+func (r *ExecStreamReader) Write(p []byte) (int, error) {
+	r.buf = append(r.buf, p...)
+	for len(r.buf) >= execHeaderLength {
+		length := int(binary.BigEndian.Uint32(r.buf[4:execHeaderLength]))
+		if len(r.buf) < execHeaderLength+length {
+			break
+		}
+		payload := r.buf[execHeaderLength : execHeaderLength+length]
+		switch r.buf[0] {
+		case ExecStdout:
+			if _, err := r.Stdout.Write(payload); err != nil {
+				return 0, err
+			}
+		case ExecStderr:
+			if _, err := r.Stderr.Write(payload); err != nil {
+				return 0, err
+			}
+		case ExecExit:
+			code, err := strconv.Atoi(string(payload))
+			if err != nil {
+				return 0, errors.New(fmt.Sprintf("The exit code '%s' is not valid", string(payload)))
+			}
+			r.code = code
+			r.exited = true
+		default:
+			return 0, errors.New(fmt.Sprintf("Unrecognized process output stream %d", r.buf[0]))
+		}
+		r.buf = r.buf[execHeaderLength+length:]
+	}
+	return len(p), nil
+}
+
+// The exit code of the process, or an error if the output ended before
+// the process exited.
+func (r *ExecStreamReader) ExitCode() (int, error) {
+	if !r.exited {
+		return -1, ErrExecNoExitCode
+	}
+	return r.code, nil
+}
@@ -0,0 +1,105 @@
+package containers
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/openshift/geard/config"
+)
+
+func TestExecStreamRoundTrip(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := NewExecStreamWriter(buf)
+	w.Stream(ExecStdout).Write([]byte("hello "))
+	w.Stream(ExecStderr).Write([]byte("warning\n"))
+	w.Stream(ExecStdout).Write([]byte("world\n"))
+	if err := w.WriteExitCode(3); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	r := NewExecStreamReader(stdout, stderr)
+	if _, err := r.ExitCode(); err != ErrExecNoExitCode {
+		t.Errorf("Expected no exit code before the output ends: %v", err)
+	}
+	// frames may be split across writes
+	data := buf.Bytes()
+	for i := 0; i < len(data); i += 5 {
+		end := i + 5
+		if end > len(data) {
+			end = len(data)
+		}
+		if _, err := r.Write(data[i:end]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if stdout.String() != "hello world\n" || stderr.String() != "warning\n" {
+		t.Errorf("Unexpected output: %q %q", stdout.String(), stderr.String())
+	}
+	if code, err := r.ExitCode(); err != nil || code != 3 {
+		t.Errorf("Unexpected exit code: %d %v", code, err)
+	}
+
+	if _, err := NewExecStreamReader(stdout, stderr).Write([]byte{9, 0, 0, 0, 0, 0, 0, 0}); err == nil {
+		t.Error("Expected an error for an unknown stream")
+	}
+}
+
+func TestCheckExecEnvironment(t *testing.T) {
+	if err := CheckExecEnvironment([]string{"A=1", "B=", "C=x=y"}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	for _, s := range []string{"A", "=1", ""} {
+		if err := CheckExecEnvironment([]string{s}); err == nil {
+			t.Errorf("Expected an error for %q", s)
+		}
+	}
+}
+
+func TestExecAccess(t *testing.T) {
+	base, err := ioutil.TempDir("", "exec-access")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(base)
+	previous := config.ContainerBasePath()
+	config.SetContainerBasePath(base)
+	defer config.SetContainerBasePath(previous)
+
+	id := Identifier("test")
+	if !HasExecAccess(id, "") {
+		t.Error("Requests without a user should be allowed")
+	}
+	if HasExecAccess(id, "alice") {
+		t.Error("Users should not be allowed until granted access")
+	}
+	if err := GrantExecAccess(id, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if !HasExecAccess(id, "alice") || HasExecAccess(Identifier("other"), "alice") {
+		t.Error("Access should only be granted to the one container")
+	}
+	if HasExecAccess(id, "") || !HasExecAccess(Identifier("other"), "") {
+		t.Error("Requests without a user should be denied once users have been granted access")
+	}
+	if err := RevokeExecAccess(id, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if HasExecAccess(id, "alice") {
+		t.Error("Access should be revoked")
+	}
+	if !HasExecAccess(id, "") {
+		t.Error("Requests without a user should be allowed once no user has access")
+	}
+	if err := RevokeExecAccess(id, "alice"); err != nil {
+		t.Errorf("Revoking missing access should succeed: %v", err)
+	}
+
+	for _, user := range []string{"", "../etc", "a/b", "a.b"} {
+		if err := CheckExecUser(user); err == nil {
+			t.Errorf("Expected an error for the user %q", user)
+		}
+	}
+}
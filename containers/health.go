@@ -0,0 +1,326 @@
+package containers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openshift/geard/config"
+	"github.com/openshift/geard/port"
+)
+
+const (
+	HealthCheckHttp = "http"
+	HealthCheckTcp  = "tcp"
+	HealthCheckExec = "exec"
+
+	HealthActionRestart = "restart"
+	HealthActionNone    = "none"
+
+	HealthStarting  = "starting"
+	HealthHealthy   = "healthy"
+	HealthUnhealthy = "unhealthy"
+)
+
+// A probe run periodically against a container by the server.  HTTP and
+// TCP checks connect to the host port mapped to Port, exec checks run
+// Command inside the container.
+type HealthCheck struct {
+	Type    string
+	Port    port.Port `json:",omitempty"`
+	Path    string    `json:",omitempty"`
+	Command []string  `json:",omitempty"`
+
+	// Seconds between checks and before a check is failed
+	Interval int `json:",omitempty"`
+	Timeout  int `json:",omitempty"`
+	// The number of consecutive failures before the container is
+	// unhealthy and Action is taken
+	FailureThreshold int    `json:",omitempty"`
+	Action           string `json:",omitempty"`
+}
+
+const (
+	defaultHealthInterval  = 30
+	defaultHealthTimeout   = 5
+	defaultHealthThreshold = 3
+)
+
+func (h *HealthCheck) Check() error {
+	switch h.Type {
+	case HealthCheckHttp, HealthCheckTcp:
+		if err := h.Port.Check(); err != nil {
+			return errors.New("A health check port must be a positive integer less than 65536")
+		}
+	case HealthCheckExec:
+		if len(h.Command) == 0 {
+			return errors.New("An exec health check must have a command")
+		}
+	default:
+		return errors.New(fmt.Sprintf("The health check type '%s' must be one of http, tcp, or exec", h.Type))
+	}
+	if h.Path != "" && (h.Type != HealthCheckHttp || !strings.HasPrefix(h.Path, "/")) {
+		return errors.New("A health check path may only be set for http checks and must begin with '/'")
+	}
+	if h.Interval < 0 || h.Timeout < 0 || h.FailureThreshold < 0 {
+		return errors.New("The health check interval, timeout, and failure threshold may not be negative")
+	}
+	if h.Timeout > 0 && h.Interval > 0 && h.Timeout > h.Interval {
+		return errors.New("The health check timeout may not be longer than the interval")
+	}
+	switch h.Action {
+	case "", HealthActionRestart, HealthActionNone:
+	default:
+		return errors.New(fmt.Sprintf("The health check action '%s' must be restart or none", h.Action))
+	}
+	return nil
+}
+
+// Return a copy of the check with defaults for any unset values.
+func (h HealthCheck) WithDefaults() HealthCheck {
+	if h.Interval == 0 {
+		h.Interval = defaultHealthInterval
+	}
+	if h.Timeout == 0 {
+		h.Timeout = defaultHealthTimeout
+		if h.Timeout > h.Interval {
+			h.Timeout = h.Interval
+		}
+	}
+	if h.FailureThreshold == 0 {
+		h.FailureThreshold = defaultHealthThreshold
+	}
+	if h.Action == "" {
+		h.Action = HealthActionRestart
+	}
+	if h.Type == HealthCheckHttp && h.Path == "" {
+		h.Path = "/"
+	}
+	return h
+}
+
+func (h HealthCheck) String() string {
+	var target string
+	switch h.Type {
+	case HealthCheckHttp:
+		target = fmt.Sprintf("http %d%s", h.Port, h.Path)
+	case HealthCheckTcp:
+		target = fmt.Sprintf("tcp %d", h.Port)
+	default:
+		target = fmt.Sprintf("exec %s", strings.Join(h.Command, " "))
+	}
+	return fmt.Sprintf("%s every %ds, timeout %ds, %s after %d failures", target, h.Interval, h.Timeout, h.Action, h.FailureThreshold)
+}
+
+// Parse a check of the form http:<port>[/<path>], tcp:<port>, or
+// exec:<command>.
+func NewHealthCheckFromString(s string) (*HealthCheck, error) {
+	parts := strings.SplitN(s, ":", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return nil, errors.New(fmt.Sprintf("The health check '%s' must be of the form http:<port>[/<path>], tcp:<port>, or exec:<command>", s))
+	}
+	h := &HealthCheck{Type: parts[0]}
+	switch h.Type {
+	case HealthCheckHttp, HealthCheckTcp:
+		value := parts[1]
+		if i := strings.Index(value, "/"); i != -1 && h.Type == HealthCheckHttp {
+			h.Path = value[i:]
+			value = value[:i]
+		}
+		p, err := port.NewPortFromString(value)
+		if err != nil {
+			return nil, err
+		}
+		h.Port = p
+	case HealthCheckExec:
+		h.Command = strings.Fields(parts[1])
+	}
+	if err := h.Check(); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// Run the check once against a container whose ports are mapped as
+// described by ports.
+func (h *HealthCheck) Probe(id Identifier, ports port.PortPairs) error {
+	timeout := time.Duration(h.Timeout) * time.Second
+	switch h.Type {
+	case HealthCheckExec:
+		return probeCommand(id, h.Command, timeout)
+	}
+
+	pair, found := ports.Find(h.Port)
+	if !found || pair.External == port.InvalidPort {
+		return errors.New(fmt.Sprintf("Port %d is not mapped to the host", h.Port))
+	}
+	address := net.JoinHostPort("127.0.0.1", strconv.Itoa(int(pair.External)))
+
+	if h.Type == HealthCheckTcp {
+		conn, err := net.DialTimeout("tcp", address, timeout)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Get("http://" + address + h.Path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return errors.New(fmt.Sprintf("%s returned %d", h.Path, resp.StatusCode))
+	}
+	return nil
+}
+
+func probeCommand(id Identifier, command []string, timeout time.Duration) error {
+	args := append([]string{"--container=" + id.ContainerFor(), "--"}, command...)
+	cmd := exec.Command(filepath.Join("/", "usr", "bin", "switchns"), args...)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(timeout):
+		cmd.Process.Kill()
+		<-done
+		return errors.New(fmt.Sprintf("The command did not finish within %s", timeout))
+	}
+}
+
+// The most recent results of the health check of a container.
+type HealthStatus struct {
+	State               string
+	ConsecutiveFailures int       `json:",omitempty"`
+	LastChecked         time.Time `json:",omitempty"`
+	LastError           string    `json:",omitempty"`
+	// The number of times the container has been restarted for failing
+	// its check
+	Restarts int `json:",omitempty"`
+}
+
+// Record the outcome of a probe, returning true if the container has
+// just reached the failure threshold of the check.
+func (s *HealthStatus) Record(h *HealthCheck, err error, at time.Time) bool {
+	s.LastChecked = at
+	if err == nil {
+		s.State = HealthHealthy
+		s.ConsecutiveFailures = 0
+		s.LastError = ""
+		return false
+	}
+	s.ConsecutiveFailures++
+	s.LastError = err.Error()
+	if s.ConsecutiveFailures < h.FailureThreshold {
+		if s.State == "" {
+			s.State = HealthStarting
+		}
+		return false
+	}
+	s.State = HealthUnhealthy
+	return s.ConsecutiveFailures == h.FailureThreshold
+}
+
+func (s *HealthStatus) String() string {
+	if s.LastChecked.IsZero() {
+		return s.State
+	}
+	if s.LastError != "" {
+		return fmt.Sprintf("%s, %d consecutive failures, last checked %s: %s", s.State, s.ConsecutiveFailures, s.LastChecked.Format(time.RFC3339), s.LastError)
+	}
+	return fmt.Sprintf("%s, last checked %s", s.State, s.LastChecked.Format(time.RFC3339))
+}
+
+func SaveHealthCheck(id Identifier, h *HealthCheck) error {
+	return writeJsonFile(id.HealthCheckPathFor(), h)
+}
+
+func GetHealthCheck(id Identifier) (*HealthCheck, error) {
+	h := &HealthCheck{}
+	if err := readJsonFile(id.HealthCheckPathFor(), h); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+func RemoveHealthCheck(id Identifier) error {
+	if err := os.Remove(id.HealthCheckPathFor()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Remove(id.HealthStatusPathFor()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// The containers that have a health check.
+func GetHealthCheckedContainers() ([]Identifier, error) {
+	paths, err := filepath.Glob(filepath.Join(config.ContainerBasePath(), "health", "checks", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]Identifier, 0, len(paths))
+	for _, path := range paths {
+		if id, err := NewIdentifier(strings.TrimSuffix(filepath.Base(path), ".json")); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func SaveHealthStatus(id Identifier, s *HealthStatus) error {
+	return writeJsonFile(id.HealthStatusPathFor(), s)
+}
+
+// The health of the container, or nil if it has no health check.
+func GetHealthStatus(id Identifier) (*HealthStatus, error) {
+	if _, err := os.Stat(id.HealthCheckPathFor()); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	s := &HealthStatus{}
+	if err := readJsonFile(id.HealthStatusPathFor(), s); err != nil {
+		if os.IsNotExist(err) {
+			return &HealthStatus{State: HealthStarting}, nil
+		}
+		return nil, err
+	}
+	return s, nil
+}
+
+func writeJsonFile(path string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0640); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func readJsonFile(path string, value interface{}) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, value)
+}
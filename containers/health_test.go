@@ -0,0 +1,113 @@
+package containers
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/openshift/geard/port"
+)
+
+func TestNewHealthCheckFromString(t *testing.T) {
+	h, err := NewHealthCheckFromString("http:8080/status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Type != HealthCheckHttp || h.Port != 8080 || h.Path != "/status" {
+		t.Errorf("Unexpected http check: %+v", h)
+	}
+	h, err = NewHealthCheckFromString("tcp:5432")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Type != HealthCheckTcp || h.Port != 5432 || h.Path != "" {
+		t.Errorf("Unexpected tcp check: %+v", h)
+	}
+	h, err = NewHealthCheckFromString("exec:/bin/check --quick")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Type != HealthCheckExec || len(h.Command) != 2 || h.Command[1] != "--quick" {
+		t.Errorf("Unexpected exec check: %+v", h)
+	}
+
+	for _, s := range []string{"", "http", "http:", "udp:53", "tcp:0", "tcp:99999", "tcp:80/status", "exec: "} {
+		if _, err := NewHealthCheckFromString(s); err == nil {
+			t.Errorf("Expected an error for %q", s)
+		}
+	}
+}
+
+func TestHealthCheckDefaults(t *testing.T) {
+	h := HealthCheck{Type: HealthCheckHttp, Port: 8080}.WithDefaults()
+	if h.Interval != 30 || h.Timeout != 5 || h.FailureThreshold != 3 || h.Action != HealthActionRestart || h.Path != "/" {
+		t.Errorf("Unexpected defaults: %+v", h)
+	}
+	h = HealthCheck{Type: HealthCheckTcp, Port: 8080, Interval: 2, Action: HealthActionNone}.WithDefaults()
+	if h.Timeout != 2 || h.Action != HealthActionNone {
+		t.Errorf("The timeout should be limited by the interval: %+v", h)
+	}
+
+	invalid := []HealthCheck{
+		{Type: HealthCheckTcp, Port: 8080, Interval: 1, Timeout: 5},
+		{Type: HealthCheckTcp, Port: 8080, FailureThreshold: -1},
+		{Type: HealthCheckTcp, Port: 8080, Action: "stop"},
+	}
+	for i := range invalid {
+		if err := invalid[i].Check(); err == nil {
+			t.Errorf("Expected an error for %+v", invalid[i])
+		}
+	}
+}
+
+func TestHealthStatusRecord(t *testing.T) {
+	h := &HealthCheck{FailureThreshold: 2}
+	s := &HealthStatus{State: HealthStarting}
+	failed := errors.New("connection refused")
+	now := time.Now()
+
+	if s.Record(h, failed, now) || s.State != HealthStarting || s.ConsecutiveFailures != 1 {
+		t.Errorf("Unexpected status after one failure: %+v", s)
+	}
+	if !s.Record(h, failed, now) || s.State != HealthUnhealthy {
+		t.Errorf("The threshold should be reached on the second failure: %+v", s)
+	}
+	if s.Record(h, failed, now) || s.ConsecutiveFailures != 3 {
+		t.Errorf("The threshold should only be reported once: %+v", s)
+	}
+	if s.Record(h, nil, now) || s.State != HealthHealthy || s.ConsecutiveFailures != 0 || s.LastError != "" {
+		t.Errorf("Unexpected status after a success: %+v", s)
+	}
+}
+
+func TestHealthCheckProbe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ok" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+	_, portString, _ := net.SplitHostPort(server.Listener.Addr().String())
+	external, _ := strconv.Atoi(portString)
+	ports := port.PortPairs{{Internal: 8080, External: port.Port(external)}}
+
+	checks := []struct {
+		check   HealthCheck
+		healthy bool
+	}{
+		{HealthCheck{Type: HealthCheckHttp, Port: 8080, Path: "/ok", Timeout: 1}, true},
+		{HealthCheck{Type: HealthCheckHttp, Port: 8080, Path: "/fail", Timeout: 1}, false},
+		{HealthCheck{Type: HealthCheckTcp, Port: 8080, Timeout: 1}, true},
+		{HealthCheck{Type: HealthCheckTcp, Port: 9090, Timeout: 1}, false},
+	}
+	for i := range checks {
+		err := checks[i].check.Probe("test", ports)
+		if (err == nil) != checks[i].healthy {
+			t.Errorf("Unexpected result for %s: %v", checks[i].check.String(), err)
+		}
+	}
+}
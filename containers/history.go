@@ -0,0 +1,176 @@
+package containers
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openshift/geard/port"
+)
+
+// A definition of a container, stored under the id of the request that
+// wrote it each time the container is installed or its limits change.
+type UnitVersion struct {
+	RequestId string
+	Image     string
+	Ports     port.PortPairs `json:",omitempty"`
+	Created   time.Time
+	// True if this is the definition the container is currently using
+	Current bool `json:",omitempty"`
+
+	// The environment file referenced by the definition
+	EnvironmentPath string `json:"-"`
+	// The install request the definition derives from, which is the
+	// request id for definitions written by an install
+	InstallRequestId string `json:"-"`
+}
+
+type UnitVersions []UnitVersion
+
+func (v UnitVersions) Len() int           { return len(v) }
+func (v UnitVersions) Swap(i, j int)      { v[i], v[j] = v[j], v[i] }
+func (v UnitVersions) Less(i, j int) bool { return v[i].Created.After(v[j].Created) }
+
+var allowedUnitVersion = regexp.MustCompile("\\A[a-zA-Z0-9_\\-]{1,64}\\z")
+
+func CheckUnitVersion(version string) error {
+	if !allowedUnitVersion.MatchString(version) {
+		return errors.New("Version must match " + allowedUnitVersion.String())
+	}
+	return nil
+}
+
+// Return the stored definitions of a container, newest first.
+func GetUnitHistory(id Identifier) (UnitVersions, error) {
+	infos, err := ioutil.ReadDir(id.VersionedUnitsPathFor())
+	if err != nil {
+		return nil, err
+	}
+	current, _ := os.Stat(id.UnitPathFor())
+
+	versions := make(UnitVersions, 0, len(infos))
+	for _, info := range infos {
+		// skips the temporary links made while replacing the definition
+		if info.IsDir() || CheckUnitVersion(info.Name()) != nil {
+			continue
+		}
+		version, err := readUnitVersionFile(id.VersionedUnitPathFor(info.Name()))
+		if err != nil {
+			return nil, err
+		}
+		version.RequestId = info.Name()
+		version.Created = info.ModTime()
+		version.Current = current != nil && os.SameFile(current, info)
+		versions = append(versions, *version)
+	}
+	sort.Sort(versions)
+	return versions, nil
+}
+
+// Return a single stored definition of a container.
+func GetUnitVersion(id Identifier, version string) (*UnitVersion, error) {
+	if err := CheckUnitVersion(version); err != nil {
+		return nil, err
+	}
+	path := id.VersionedUnitPathFor(version)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	v, err := readUnitVersionFile(path)
+	if err != nil {
+		return nil, err
+	}
+	v.RequestId = version
+	v.Created = info.ModTime()
+	if current, err := os.Stat(id.UnitPathFor()); err == nil {
+		v.Current = os.SameFile(current, info)
+	}
+	return v, nil
+}
+
+func readUnitVersionFile(path string) (*UnitVersion, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return readUnitVersion(file)
+}
+
+func readUnitVersion(r io.Reader) (*UnitVersion, error) {
+	v := &UnitVersion{}
+	scan := bufio.NewScanner(r)
+	for scan.Scan() {
+		line := strings.TrimSpace(scan.Text())
+		switch {
+		case strings.HasPrefix(line, "X-ContainerImage="):
+			v.Image = strings.TrimPrefix(line, "X-ContainerImage=")
+		case strings.HasPrefix(line, "X-ContainerRequestId="):
+			v.InstallRequestId = strings.TrimPrefix(line, "X-ContainerRequestId=")
+		case strings.HasPrefix(line, "EnvironmentFile="):
+			v.EnvironmentPath = strings.TrimPrefix(line, "EnvironmentFile=")
+		case strings.HasPrefix(line, "X-PortMapping="):
+			if found, err := port.FromPortPairHeader(strings.TrimPrefix(line, "X-PortMapping=")); err == nil {
+				v.Ports = append(v.Ports, found...)
+			}
+		}
+	}
+	if err := scan.Err(); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Keep a copy of the environment a container was installed with, so
+// that it can be restored along with the definition.
+func SaveEnvironmentVersion(id Identifier, version, environmentPath string) error {
+	source, err := os.Open(environmentPath)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	return copyToFile(id.VersionedEnvironmentPathFor(version), source)
+}
+
+// Write the environment saved with a definition back to the file the
+// definition references.  Returns false if no copy of the environment
+// was saved.
+func RestoreEnvironmentVersion(id Identifier, v *UnitVersion) (bool, error) {
+	if v.EnvironmentPath == "" || CheckUnitVersion(v.InstallRequestId) != nil {
+		return false, nil
+	}
+	saved, err := os.Open(id.VersionedEnvironmentPathFor(v.InstallRequestId))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer saved.Close()
+	return true, copyToFile(v.EnvironmentPath, saved)
+}
+
+func copyToFile(path string, r io.Reader) error {
+	tmp := path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0660)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, r); err != nil {
+		file.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,96 @@
+package containers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/openshift/geard/config"
+)
+
+func writeUnitVersion(t *testing.T, id Identifier, version, image, ports, envPath string, created time.Time) {
+	unit := "[Service]\nEnvironmentFile=" + envPath + "\n\n[Install]\nX-ContainerImage=" + image + "\nX-ContainerRequestId=" + version + "\nX-PortMapping=" + ports + "\n"
+	path := id.VersionedUnitPathFor(version)
+	if err := ioutil.WriteFile(path, []byte(unit), 0664); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, created, created); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUnitHistory(t *testing.T) {
+	base, err := ioutil.TempDir("", "unit-history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(base)
+	previous := config.ContainerBasePath()
+	config.SetContainerBasePath(base)
+	defer config.SetContainerBasePath(previous)
+
+	id := Identifier("test")
+	envPath := filepath.Join(base, "environment")
+	now := time.Now()
+	writeUnitVersion(t, id, "first", "image:1", "8080:4000", envPath, now.Add(-time.Hour))
+	writeUnitVersion(t, id, "second", "image:2", "8080:4001,22:4002", envPath, now)
+	if err := os.Link(id.VersionedUnitPathFor("first"), id.UnitPathFor()); err != nil {
+		t.Fatal(err)
+	}
+	ioutil.WriteFile(id.VersionedUnitPathFor("second.replace.tmp"), []byte{}, 0664)
+
+	versions, err := GetUnitHistory(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 {
+		t.Fatalf("Expected two versions, got %+v", versions)
+	}
+	if versions[0].RequestId != "second" || versions[0].Image != "image:2" || len(versions[0].Ports) != 2 || versions[0].Current {
+		t.Errorf("Unexpected newest version: %+v", versions[0])
+	}
+	if versions[1].RequestId != "first" || versions[1].Ports[0].External != 4000 || !versions[1].Current {
+		t.Errorf("Unexpected oldest version: %+v", versions[1])
+	}
+
+	if _, err := GetUnitVersion(id, "missing"); !os.IsNotExist(err) {
+		t.Errorf("Expected a missing version to not exist: %v", err)
+	}
+	if _, err := GetUnitVersion(id, "../first"); err == nil {
+		t.Error("Expected an invalid version to be rejected")
+	}
+}
+
+func TestEnvironmentVersion(t *testing.T) {
+	base, err := ioutil.TempDir("", "env-version")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(base)
+	previous := config.ContainerBasePath()
+	config.SetContainerBasePath(base)
+	defer config.SetContainerBasePath(previous)
+
+	id := Identifier("test")
+	envPath := filepath.Join(base, "environment")
+	ioutil.WriteFile(envPath, []byte("A=\"1\"\n"), 0660)
+	if err := SaveEnvironmentVersion(id, "first", envPath); err != nil {
+		t.Fatal(err)
+	}
+	ioutil.WriteFile(envPath, []byte("A=\"2\"\n"), 0660)
+
+	restored, err := RestoreEnvironmentVersion(id, &UnitVersion{EnvironmentPath: envPath, InstallRequestId: "first"})
+	if err != nil || !restored {
+		t.Fatalf("Expected the environment to be restored: %v", err)
+	}
+	if data, _ := ioutil.ReadFile(envPath); string(data) != "A=\"1\"\n" {
+		t.Errorf("Unexpected restored environment: %q", string(data))
+	}
+
+	restored, err = RestoreEnvironmentVersion(id, &UnitVersion{EnvironmentPath: envPath, InstallRequestId: "second"})
+	if err != nil || restored {
+		t.Errorf("Expected no environment to be restored: %v", err)
+	}
+}
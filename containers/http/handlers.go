@@ -4,11 +4,16 @@ import (
 	"encoding/json"
 	"errors"
 	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/openshift/geard/containers"
 	cjobs "github.com/openshift/geard/containers/jobs"
 	"github.com/openshift/geard/http"
 	"github.com/openshift/geard/jobs"
+	"github.com/openshift/geard/systemd"
 	"github.com/openshift/go-json-rest"
 )
 
@@ -21,18 +26,38 @@ func (h *HttpExtension) Routes() []http.HttpJobHandler {
 		&HttpInstallContainerRequest{},
 		&HttpDeleteContainerRequest{},
 		&HttpContainerLogRequest{},
+		&HttpContainerLogsRequest{},
 		&HttpContainerStatusRequest{},
 		&HttpListContainerPortsRequest{},
+		&HttpContainerEventsRequest{},
 
 		&HttpStartContainerRequest{},
 		&HttpStopContainerRequest{},
 		&HttpRestartContainerRequest{},
+		&HttpIdleContainerRequest{},
+		&HttpUnidleContainerRequest{},
+		&HttpPatchContainerLimitsRequest{},
+		&HttpContainerHistoryRequest{},
+		&HttpRollbackContainerRequest{},
+		&HttpBackupContainerRequest{},
+		&HttpRestoreContainerRequest{},
+		&HttpExecContainerRequest{},
+		&HttpGrantExecAccessRequest{},
+		&HttpRevokeExecAccessRequest{},
+		&HttpSetIdlePolicyRequest{},
 
 		&HttpLinkContainersRequest{},
 
+		&HttpPutVolumeRequest{},
+		&HttpDeleteVolumeRequest{},
+
 		&HttpListContainersRequest{},
 		&HttpListImagesRequest{},
+		&HttpPullImageRequest{},
+		&HttpPutRegistryCredentialsRequest{},
+		&HttpDeleteRegistryCredentialsRequest{},
 		&HttpListBuildsRequest{},
+		&HttpHostResourcesRequest{},
 
 		&HttpBuildImageRequest{},
 
@@ -55,20 +80,62 @@ func (h *HttpExtension) HttpJobFor(job interface{}) (exc http.RemoteExecutable,
 		exc = &HttpStopContainerRequest{StoppedContainerStateRequest: *j}
 	case *cjobs.RestartContainerRequest:
 		exc = &HttpRestartContainerRequest{RestartContainerRequest: *j}
+	case *cjobs.IdleContainerRequest:
+		exc = &HttpIdleContainerRequest{IdleContainerRequest: *j}
+	case *cjobs.UnidleContainerRequest:
+		exc = &HttpUnidleContainerRequest{UnidleContainerRequest: *j}
 	case *cjobs.PutEnvironmentRequest:
 		exc = &HttpPutEnvironmentRequest{PutEnvironmentRequest: *j}
 	case *cjobs.PatchEnvironmentRequest:
 		exc = &HttpPatchEnvironmentRequest{PatchEnvironmentRequest: *j}
+	case *cjobs.PatchContainerLimitsRequest:
+		exc = &HttpPatchContainerLimitsRequest{PatchContainerLimitsRequest: *j}
+	case *cjobs.PullImageRequest:
+		exc = &HttpPullImageRequest{PullImageRequest: *j}
+	case *cjobs.PutRegistryCredentialsRequest:
+		exc = &HttpPutRegistryCredentialsRequest{PutRegistryCredentialsRequest: *j}
+	case *cjobs.DeleteRegistryCredentialsRequest:
+		exc = &HttpDeleteRegistryCredentialsRequest{DeleteRegistryCredentialsRequest: *j}
+	case *cjobs.ContainerHistoryRequest:
+		exc = &HttpContainerHistoryRequest{ContainerHistoryRequest: *j}
+	case *cjobs.RollbackContainerRequest:
+		exc = &HttpRollbackContainerRequest{RollbackContainerRequest: *j}
 	case *cjobs.ContainerStatusRequest:
 		exc = &HttpContainerStatusRequest{ContainerStatusRequest: *j}
 	case *cjobs.ContentRequest:
 		exc = &HttpContentRequest{ContentRequest: *j}
 	case *cjobs.DeleteContainerRequest:
 		exc = &HttpDeleteContainerRequest{DeleteContainerRequest: *j}
+	case *cjobs.BackupContainerRequest:
+		exc = &HttpBackupContainerRequest{BackupContainerRequest: *j}
+	case *cjobs.RestoreContainerRequest:
+		exc = &HttpRestoreContainerRequest{RestoreContainerRequest: *j}
+	case *cjobs.PutVolumeRequest:
+		exc = &HttpPutVolumeRequest{PutVolumeRequest: *j}
+	case *cjobs.DeleteVolumeRequest:
+		exc = &HttpDeleteVolumeRequest{DeleteVolumeRequest: *j}
 	case *cjobs.LinkContainersRequest:
 		exc = &HttpLinkContainersRequest{LinkContainersRequest: *j}
 	case *cjobs.ListContainersRequest:
 		exc = &HttpListContainersRequest{ListContainersRequest: *j}
+	case *cjobs.HostResourcesRequest:
+		exc = &HttpHostResourcesRequest{HostResourcesRequest: *j}
+	case *cjobs.ContainerEventsRequest:
+		exc = &HttpContainerEventsRequest{Ids: j.Ids, Types: j.Types}
+	case *cjobs.ContainerLogRequest:
+		if len(j.Ids) == 1 {
+			exc = &HttpContainerLogRequest{Id: j.Ids[0], LogOptions: j.LogOptions, Json: j.Json}
+		} else {
+			exc = &HttpContainerLogsRequest{Ids: j.Ids, LogOptions: j.LogOptions, Json: j.Json}
+		}
+	case *cjobs.ExecContainerRequest:
+		exc = &HttpExecContainerRequest{Id: j.Id, Command: j.Command, Environment: j.Environment, Tty: j.Tty, Rows: j.Rows, Columns: j.Columns, Stdin: j.Stdin}
+	case *cjobs.GrantExecAccessRequest:
+		exc = &HttpGrantExecAccessRequest{GrantExecAccessRequest: *j}
+	case *cjobs.RevokeExecAccessRequest:
+		exc = &HttpRevokeExecAccessRequest{RevokeExecAccessRequest: *j}
+	case *cjobs.SetIdlePolicyRequest:
+		exc = &HttpSetIdlePolicyRequest{SetIdlePolicyRequest: *j}
 	default:
 		err = jobs.ErrNoJobForRequest
 	}
@@ -127,6 +194,7 @@ func (h *HttpInstallContainerRequest) Handler(conf *http.HttpConfiguration) http
 		}
 		data.Id = id
 		data.RequestIdentifier = context.Id
+		data.DockerSocket = conf.Docker.Socket
 
 		if err := data.Check(); err != nil {
 			return nil, err
@@ -154,6 +222,260 @@ func (h *HttpDeleteContainerRequest) Handler(conf *http.HttpConfiguration) http.
 	}
 }
 
+type HttpBackupContainerRequest struct {
+	cjobs.BackupContainerRequest
+	http.DefaultRequest
+}
+
+func (h *HttpBackupContainerRequest) HttpMethod() string { return "GET" }
+func (h *HttpBackupContainerRequest) Streamable() bool   { return true }
+func (h *HttpBackupContainerRequest) HttpPath() string {
+	return http.Inline("/container/:id/backup", string(h.Id))
+}
+func (h *HttpBackupContainerRequest) Handler(conf *http.HttpConfiguration) http.JobHandler {
+	return func(context *jobs.JobContext, r *rest.Request) (interface{}, error) {
+		id, errg := containers.NewIdentifier(r.PathParam("id"))
+		if errg != nil {
+			return nil, errg
+		}
+		return &cjobs.BackupContainerRequest{
+			Id:           id,
+			Quiesce:      r.URL.Query().Get("quiesce") == "true",
+			ManifestOnly: r.URL.Query().Get("manifest") == "true",
+			DockerSocket: conf.Docker.Socket,
+		}, nil
+	}
+}
+
+type HttpRestoreContainerRequest struct {
+	cjobs.RestoreContainerRequest
+	http.DefaultRequest
+}
+
+func (h *HttpRestoreContainerRequest) HttpMethod() string { return "POST" }
+func (h *HttpRestoreContainerRequest) HttpPath() string {
+	return http.Inline("/container/:id/restore", string(h.Id))
+}
+func (h *HttpRestoreContainerRequest) Handler(conf *http.HttpConfiguration) http.JobHandler {
+	return func(context *jobs.JobContext, r *rest.Request) (interface{}, error) {
+		id, errg := containers.NewIdentifier(r.PathParam("id"))
+		if errg != nil {
+			return nil, errg
+		}
+		data := &cjobs.RestoreContainerRequest{
+			RequestIdentifier: context.Id,
+			Id:                id,
+			Archive:           r.Body,
+			Started:           r.URL.Query().Get("started") == "true",
+			DockerSocket:      conf.Docker.Socket,
+		}
+		if err := data.Check(); err != nil {
+			return nil, err
+		}
+		return data, nil
+	}
+}
+
+// The process is read from the query and the request body is forwarded
+// to its stdin while its output is streamed back.
+type HttpExecContainerRequest struct {
+	Id          containers.Identifier
+	Command     []string
+	Environment []string
+	Tty         bool
+	Rows        uint16
+	Columns     uint16
+	Stdin       io.Reader
+	http.DefaultRequest
+}
+
+func (h *HttpExecContainerRequest) HttpMethod() string { return "POST" }
+func (h *HttpExecContainerRequest) Streamable() bool   { return true }
+func (h *HttpExecContainerRequest) FullDuplex() bool   { return true }
+func (h *HttpExecContainerRequest) HttpPath() string {
+	return http.Inline("/container/:id/exec", string(h.Id))
+}
+func (h *HttpExecContainerRequest) Handler(conf *http.HttpConfiguration) http.JobHandler {
+	return func(context *jobs.JobContext, r *rest.Request) (interface{}, error) {
+		id, errg := containers.NewIdentifier(r.PathParam("id"))
+		if errg != nil {
+			return nil, errg
+		}
+		query := r.URL.Query()
+		data := &cjobs.ExecContainerRequest{
+			Id:          id,
+			Command:     query["cmd"],
+			Environment: query["env"],
+			Tty:         query.Get("tty") == "true",
+			Stdin:       r.Body,
+			Remote:      isRemoteAddress(r),
+		}
+		// the header names the user only behind a front end that sets it
+		if conf.TrustRequestUser {
+			data.User = context.User
+		}
+		if s := query.Get("rows"); s != "" {
+			rows, err := strconv.ParseUint(s, 10, 16)
+			if err != nil {
+				return nil, errors.New("rows must be a positive integer")
+			}
+			data.Rows = uint16(rows)
+		}
+		if s := query.Get("columns"); s != "" {
+			columns, err := strconv.ParseUint(s, 10, 16)
+			if err != nil {
+				return nil, errors.New("columns must be a positive integer")
+			}
+			data.Columns = uint16(columns)
+		}
+		if err := data.Check(); err != nil {
+			return nil, err
+		}
+		return data, nil
+	}
+}
+
+type HttpGrantExecAccessRequest struct {
+	cjobs.GrantExecAccessRequest
+	http.DefaultRequest
+}
+
+func (h *HttpGrantExecAccessRequest) HttpMethod() string { return "PUT" }
+func (h *HttpGrantExecAccessRequest) HttpPath() string {
+	return http.Inline("/container/:id/exec/access/:user", string(h.Id), h.User)
+}
+func (h *HttpGrantExecAccessRequest) Handler(conf *http.HttpConfiguration) http.JobHandler {
+	return func(context *jobs.JobContext, r *rest.Request) (interface{}, error) {
+		id, errg := containers.NewIdentifier(r.PathParam("id"))
+		if errg != nil {
+			return nil, errg
+		}
+		data := &cjobs.GrantExecAccessRequest{Id: id, User: r.PathParam("user"), Remote: isRemoteCaller(context, r)}
+		if err := data.Check(); err != nil {
+			return nil, err
+		}
+		return data, nil
+	}
+}
+
+// True unless the request was made from the server itself without a user.
+func isRemoteCaller(context *jobs.JobContext, r *rest.Request) bool {
+	return context.User != "" || isRemoteAddress(r)
+}
+
+func isRemoteAddress(r *rest.Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip == nil || !ip.IsLoopback()
+}
+
+type HttpRevokeExecAccessRequest struct {
+	cjobs.RevokeExecAccessRequest
+	http.DefaultRequest
+}
+
+func (h *HttpRevokeExecAccessRequest) HttpMethod() string { return "DELETE" }
+func (h *HttpRevokeExecAccessRequest) HttpPath() string {
+	return http.Inline("/container/:id/exec/access/:user", string(h.Id), h.User)
+}
+func (h *HttpRevokeExecAccessRequest) Handler(conf *http.HttpConfiguration) http.JobHandler {
+	return func(context *jobs.JobContext, r *rest.Request) (interface{}, error) {
+		id, errg := containers.NewIdentifier(r.PathParam("id"))
+		if errg != nil {
+			return nil, errg
+		}
+		data := &cjobs.RevokeExecAccessRequest{Id: id, User: r.PathParam("user"), Remote: isRemoteCaller(context, r)}
+		if err := data.Check(); err != nil {
+			return nil, err
+		}
+		return data, nil
+	}
+}
+
+type HttpSetIdlePolicyRequest struct {
+	cjobs.SetIdlePolicyRequest
+	http.DefaultRequest
+}
+
+func (h *HttpSetIdlePolicyRequest) HttpMethod() string { return "PUT" }
+func (h *HttpSetIdlePolicyRequest) HttpPath() string {
+	return http.Inline("/container/:id/idle-policy", string(h.Id))
+}
+func (h *HttpSetIdlePolicyRequest) Handler(conf *http.HttpConfiguration) http.JobHandler {
+	return func(context *jobs.JobContext, r *rest.Request) (interface{}, error) {
+		id, errg := containers.NewIdentifier(r.PathParam("id"))
+		if errg != nil {
+			return nil, errg
+		}
+		data := &cjobs.SetIdlePolicyRequest{Id: id}
+		// an empty or null body returns the container to the default policy
+		if r.Body != nil {
+			dec := json.NewDecoder(limitedBodyReader(r))
+			if err := dec.Decode(&data.Policy); err != nil && err != io.EOF {
+				return nil, err
+			}
+		}
+		if err := data.Check(); err != nil {
+			return nil, err
+		}
+		return data, nil
+	}
+}
+
+type HttpPutVolumeRequest struct {
+	cjobs.PutVolumeRequest
+	http.DefaultRequest
+}
+
+func (h *HttpPutVolumeRequest) HttpMethod() string { return "PUT" }
+func (h *HttpPutVolumeRequest) HttpPath() string {
+	return http.Inline("/volume/:id", string(h.Id))
+}
+func (h *HttpPutVolumeRequest) Handler(conf *http.HttpConfiguration) http.JobHandler {
+	return func(context *jobs.JobContext, r *rest.Request) (interface{}, error) {
+		id, errg := containers.NewIdentifier(r.PathParam("id"))
+		if errg != nil {
+			return nil, errg
+		}
+
+		data := containers.Volume{}
+		if r.Body != nil {
+			dec := json.NewDecoder(limitedBodyReader(r))
+			if err := dec.Decode(&data); err != nil && err != io.EOF {
+				return nil, err
+			}
+		}
+		data.Id = id
+		if err := data.Check(); err != nil {
+			return nil, err
+		}
+
+		return &cjobs.PutVolumeRequest{Volume: data}, nil
+	}
+}
+
+type HttpDeleteVolumeRequest struct {
+	cjobs.DeleteVolumeRequest
+	http.DefaultRequest
+}
+
+func (h *HttpDeleteVolumeRequest) HttpMethod() string { return "DELETE" }
+func (h *HttpDeleteVolumeRequest) HttpPath() string {
+	return http.Inline("/volume/:id", string(h.Id))
+}
+func (h *HttpDeleteVolumeRequest) Handler(conf *http.HttpConfiguration) http.JobHandler {
+	return func(context *jobs.JobContext, r *rest.Request) (interface{}, error) {
+		id, errg := containers.NewIdentifier(r.PathParam("id"))
+		if errg != nil {
+			return nil, errg
+		}
+		return &cjobs.DeleteVolumeRequest{Id: id}, nil
+	}
+}
+
 type HttpListContainersRequest struct {
 	cjobs.ListContainersRequest
 	http.DefaultRequest
@@ -167,6 +489,19 @@ func (h *HttpListContainersRequest) Handler(conf *http.HttpConfiguration) http.J
 	}
 }
 
+type HttpHostResourcesRequest struct {
+	cjobs.HostResourcesRequest
+	http.DefaultRequest
+}
+
+func (h *HttpHostResourcesRequest) HttpMethod() string { return "GET" }
+func (h *HttpHostResourcesRequest) HttpPath() string   { return "/resources" }
+func (h *HttpHostResourcesRequest) Handler(conf *http.HttpConfiguration) http.JobHandler {
+	return func(context *jobs.JobContext, r *rest.Request) (interface{}, error) {
+		return &cjobs.HostResourcesRequest{}, nil
+	}
+}
+
 type HttpListBuildsRequest cjobs.ListBuildsRequest
 
 func (h *HttpListBuildsRequest) HttpMethod() string { return "GET" }
@@ -187,9 +522,80 @@ func (h *HttpListImagesRequest) Handler(conf *http.HttpConfiguration) http.JobHa
 	}
 }
 
-type HttpContainerLogRequest cjobs.ContainerLogRequest
+type HttpPullImageRequest struct {
+	cjobs.PullImageRequest
+	http.DefaultRequest
+}
+
+func (h *HttpPullImageRequest) HttpMethod() string { return "POST" }
+func (h *HttpPullImageRequest) HttpPath() string   { return "/images/pull" }
+func (h *HttpPullImageRequest) Streamable() bool   { return true }
+func (h *HttpPullImageRequest) Handler(conf *http.HttpConfiguration) http.JobHandler {
+	return func(context *jobs.JobContext, r *rest.Request) (interface{}, error) {
+		data := cjobs.PullImageRequest{}
+		if r.Body != nil {
+			dec := json.NewDecoder(limitedBodyReader(r))
+			if err := dec.Decode(&data); err != nil && err != io.EOF {
+				return nil, err
+			}
+		}
+		data.DockerSocket = conf.Docker.Socket
+		if err := data.Check(); err != nil {
+			return nil, err
+		}
+		return &data, nil
+	}
+}
+
+type HttpPutRegistryCredentialsRequest struct {
+	cjobs.PutRegistryCredentialsRequest
+	http.DefaultRequest
+}
+
+func (h *HttpPutRegistryCredentialsRequest) HttpMethod() string { return "PUT" }
+func (h *HttpPutRegistryCredentialsRequest) HttpPath() string   { return "/registries" }
+func (h *HttpPutRegistryCredentialsRequest) Handler(conf *http.HttpConfiguration) http.JobHandler {
+	return func(context *jobs.JobContext, r *rest.Request) (interface{}, error) {
+		data := containers.RegistryCredentials{}
+		if r.Body != nil {
+			dec := json.NewDecoder(limitedBodyReader(r))
+			if err := dec.Decode(&data); err != nil && err != io.EOF {
+				return nil, err
+			}
+		}
+		if err := data.Check(); err != nil {
+			return nil, err
+		}
+		return &cjobs.PutRegistryCredentialsRequest{RegistryCredentials: data}, nil
+	}
+}
+
+type HttpDeleteRegistryCredentialsRequest struct {
+	cjobs.DeleteRegistryCredentialsRequest
+	http.DefaultRequest
+}
+
+func (h *HttpDeleteRegistryCredentialsRequest) HttpMethod() string { return "DELETE" }
+func (h *HttpDeleteRegistryCredentialsRequest) HttpPath() string   { return "/registries" }
+func (h *HttpDeleteRegistryCredentialsRequest) Handler(conf *http.HttpConfiguration) http.JobHandler {
+	return func(context *jobs.JobContext, r *rest.Request) (interface{}, error) {
+		req := &cjobs.DeleteRegistryCredentialsRequest{Registry: r.URL.Query().Get("registry")}
+		if err := req.Check(); err != nil {
+			return nil, err
+		}
+		return req, nil
+	}
+}
+
+type HttpContainerLogRequest struct {
+	Id containers.Identifier
+	systemd.LogOptions
+	Json bool
+	http.DefaultRequest
+}
 
 func (h *HttpContainerLogRequest) HttpMethod() string { return "GET" }
+func (h *HttpContainerLogRequest) Streamable() bool   { return true }
 func (h *HttpContainerLogRequest) HttpPath() string {
 	return http.Inline("/container/:id/log", string(h.Id))
 }
@@ -199,10 +605,64 @@ func (h *HttpContainerLogRequest) Handler(conf *http.HttpConfiguration) http.Job
 		if errg != nil {
 			return nil, errg
 		}
-		return &cjobs.ContainerLogRequest{
-			id,
-		}, nil
+		req := &cjobs.ContainerLogRequest{Ids: []containers.Identifier{id}}
+		if err := logRequestFromQuery(r.URL.Query(), req); err != nil {
+			return nil, err
+		}
+		return req, nil
+	}
+}
+
+// The merged logs of several containers on the server.
+type HttpContainerLogsRequest struct {
+	Ids []containers.Identifier
+	systemd.LogOptions
+	Json bool
+	http.DefaultRequest
+}
+
+func (h *HttpContainerLogsRequest) HttpMethod() string { return "GET" }
+func (h *HttpContainerLogsRequest) HttpPath() string   { return "/logs" }
+func (h *HttpContainerLogsRequest) Streamable() bool   { return true }
+func (h *HttpContainerLogsRequest) Handler(conf *http.HttpConfiguration) http.JobHandler {
+	return func(context *jobs.JobContext, r *rest.Request) (interface{}, error) {
+		query := r.URL.Query()
+		req := &cjobs.ContainerLogRequest{}
+		for _, value := range query["id"] {
+			id, err := containers.NewIdentifier(value)
+			if err != nil {
+				return nil, err
+			}
+			req.Ids = append(req.Ids, id)
+		}
+		if err := logRequestFromQuery(query, req); err != nil {
+			return nil, err
+		}
+		return req, nil
+	}
+}
+
+func logRequestFromQuery(query url.Values, req *cjobs.ContainerLogRequest) error {
+	if s := query.Get("lines"); s != "" {
+		lines, err := strconv.Atoi(s)
+		if err != nil {
+			return errors.New("lines must be an integer")
+		}
+		req.Lines = lines
 	}
+	for name, t := range map[string]*time.Time{"since": &req.Since, "until": &req.Until} {
+		if s := query.Get(name); s != "" {
+			value, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				return errors.New(name + " must be a time in RFC3339 format")
+			}
+			*t = value
+		}
+	}
+	req.Priority = query.Get("priority")
+	req.Follow = query.Get("follow") == "true"
+	req.Json = query.Get("json") == "true"
+	return req.Check()
 }
 
 type HttpContainerStatusRequest struct {
@@ -225,6 +685,36 @@ func (h *HttpContainerStatusRequest) Handler(conf *http.HttpConfiguration) http.
 	}
 }
 
+// The request carries the state of the running stream, so only the
+// filters are copied.
+type HttpContainerEventsRequest struct {
+	Ids   []containers.Identifier
+	Types []string
+	http.DefaultRequest
+}
+
+func (h *HttpContainerEventsRequest) HttpMethod() string          { return "GET" }
+func (h *HttpContainerEventsRequest) HttpPath() string            { return "/events" }
+func (h *HttpContainerEventsRequest) Streamable() bool            { return true }
+func (h *HttpContainerEventsRequest) PassthroughJsonStream() bool { return true }
+func (h *HttpContainerEventsRequest) Handler(conf *http.HttpConfiguration) http.JobHandler {
+	return func(context *jobs.JobContext, r *rest.Request) (interface{}, error) {
+		query := r.URL.Query()
+		req := &cjobs.ContainerEventsRequest{Types: query["type"]}
+		for _, value := range query["id"] {
+			id, err := containers.NewIdentifier(value)
+			if err != nil {
+				return nil, err
+			}
+			req.Ids = append(req.Ids, id)
+		}
+		if err := req.Check(); err != nil {
+			return nil, err
+		}
+		return req, nil
+	}
+}
+
 type HttpListContainerPortsRequest cjobs.ContainerPortsRequest
 
 func (h *HttpListContainerPortsRequest) HttpMethod() string { return "GET" }
@@ -300,6 +790,119 @@ func (h *HttpRestartContainerRequest) Handler(conf *http.HttpConfiguration) http
 	}
 }
 
+type HttpIdleContainerRequest struct {
+	cjobs.IdleContainerRequest
+	http.DefaultRequest
+}
+
+func (h *HttpIdleContainerRequest) HttpMethod() string { return "PUT" }
+func (h *HttpIdleContainerRequest) HttpPath() string {
+	return http.Inline("/container/:id/idle", string(h.Id))
+}
+func (h *HttpIdleContainerRequest) Handler(conf *http.HttpConfiguration) http.JobHandler {
+	return func(context *jobs.JobContext, r *rest.Request) (interface{}, error) {
+		id, errg := containers.NewIdentifier(r.PathParam("id"))
+		if errg != nil {
+			return nil, errg
+		}
+		return &cjobs.IdleContainerRequest{Id: id}, nil
+	}
+}
+
+type HttpUnidleContainerRequest struct {
+	cjobs.UnidleContainerRequest
+	http.DefaultRequest
+}
+
+func (h *HttpUnidleContainerRequest) HttpMethod() string { return "PUT" }
+func (h *HttpUnidleContainerRequest) HttpPath() string {
+	return http.Inline("/container/:id/unidle", string(h.Id))
+}
+func (h *HttpUnidleContainerRequest) Handler(conf *http.HttpConfiguration) http.JobHandler {
+	return func(context *jobs.JobContext, r *rest.Request) (interface{}, error) {
+		id, errg := containers.NewIdentifier(r.PathParam("id"))
+		if errg != nil {
+			return nil, errg
+		}
+		return &cjobs.UnidleContainerRequest{Id: id}, nil
+	}
+}
+
+type HttpPatchContainerLimitsRequest struct {
+	cjobs.PatchContainerLimitsRequest
+	http.DefaultRequest
+}
+
+func (h *HttpPatchContainerLimitsRequest) HttpMethod() string { return "PATCH" }
+func (h *HttpPatchContainerLimitsRequest) HttpPath() string {
+	return http.Inline("/container/:id/resources", string(h.Id))
+}
+func (h *HttpPatchContainerLimitsRequest) Handler(conf *http.HttpConfiguration) http.JobHandler {
+	return func(context *jobs.JobContext, r *rest.Request) (interface{}, error) {
+		id, errg := containers.NewIdentifier(r.PathParam("id"))
+		if errg != nil {
+			return nil, errg
+		}
+
+		data := cjobs.PatchContainerLimitsRequest{}
+		if r.Body != nil {
+			dec := json.NewDecoder(limitedBodyReader(r))
+			if err := dec.Decode(&data.ResourceLimits); err != nil && err != io.EOF {
+				return nil, err
+			}
+		}
+		data.Id = id
+		data.RequestIdentifier = context.Id
+
+		if err := data.Check(); err != nil {
+			return nil, err
+		}
+		return &data, nil
+	}
+}
+
+type HttpContainerHistoryRequest struct {
+	cjobs.ContainerHistoryRequest
+	http.DefaultRequest
+}
+
+func (h *HttpContainerHistoryRequest) HttpMethod() string { return "GET" }
+func (h *HttpContainerHistoryRequest) HttpPath() string {
+	return http.Inline("/container/:id/history", string(h.Id))
+}
+func (h *HttpContainerHistoryRequest) Handler(conf *http.HttpConfiguration) http.JobHandler {
+	return func(context *jobs.JobContext, r *rest.Request) (interface{}, error) {
+		id, errg := containers.NewIdentifier(r.PathParam("id"))
+		if errg != nil {
+			return nil, errg
+		}
+		return &cjobs.ContainerHistoryRequest{id}, nil
+	}
+}
+
+type HttpRollbackContainerRequest struct {
+	cjobs.RollbackContainerRequest
+	http.DefaultRequest
+}
+
+func (h *HttpRollbackContainerRequest) HttpMethod() string { return "POST" }
+func (h *HttpRollbackContainerRequest) HttpPath() string {
+	return http.Inline("/container/:id/rollback", string(h.Id))
+}
+func (h *HttpRollbackContainerRequest) Handler(conf *http.HttpConfiguration) http.JobHandler {
+	return func(context *jobs.JobContext, r *rest.Request) (interface{}, error) {
+		id, errg := containers.NewIdentifier(r.PathParam("id"))
+		if errg != nil {
+			return nil, errg
+		}
+		req := &cjobs.RollbackContainerRequest{Id: id, To: r.URL.Query().Get("to")}
+		if err := req.Check(); err != nil {
+			return nil, err
+		}
+		return req, nil
+	}
+}
+
 type HttpBuildImageRequest cjobs.BuildImageRequest
 
 func (h *HttpBuildImageRequest) HttpMethod() string { return "POST" }
@@ -0,0 +1,94 @@
+// +build linux
+
+package http
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	nethttp "net/http"
+	"os"
+	"testing"
+
+	"github.com/openshift/geard/config"
+	"github.com/openshift/geard/containers"
+	cjobs "github.com/openshift/geard/containers/jobs"
+	"github.com/openshift/geard/http"
+	"github.com/openshift/geard/jobs"
+	"github.com/openshift/go-json-rest"
+)
+
+type streamResponse struct {
+	failure error
+	output  bytes.Buffer
+}
+
+func (r *streamResponse) StreamResult() bool                                       { return true }
+func (r *streamResponse) Success(t jobs.ResponseSuccess)                           {}
+func (r *streamResponse) SuccessWithData(t jobs.ResponseSuccess, data interface{}) {}
+func (r *streamResponse) SuccessWithWrite(t jobs.ResponseSuccess, flush, structured bool) io.Writer {
+	return &r.output
+}
+func (r *streamResponse) Failure(reason error)                               { r.failure = reason }
+func (r *streamResponse) WritePendingSuccess(name string, value interface{}) {}
+
+func execRequest(t *testing.T, conf *http.HttpConfiguration, remoteAddr, user string) *cjobs.ExecContainerRequest {
+	req, err := nethttp.NewRequest("POST", "/container/test/exec?cmd=id", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.RemoteAddr = remoteAddr
+	r := &rest.Request{Request: req, PathParams: map[string]string{"id": "test"}}
+	job, err := (&HttpExecContainerRequest{}).Handler(conf)(&jobs.JobContext{User: user}, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return job.(*cjobs.ExecContainerRequest)
+}
+
+func TestExecHandlerAuthorization(t *testing.T) {
+	base, err := ioutil.TempDir("", "exec-handler")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(base)
+	previous := config.ContainerBasePath()
+	config.SetContainerBasePath(base)
+	defer config.SetContainerBasePath(previous)
+
+	if err := containers.GrantExecAccess(containers.Identifier("test"), "alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	// a header naming a granted user is ignored unless it is trusted
+	spoofed := execRequest(t, &http.HttpConfiguration{}, "10.0.0.5:4000", "alice")
+	if spoofed.User != "" || !spoofed.Remote {
+		t.Fatalf("The request user should not be trusted: %+v", spoofed)
+	}
+	resp := &streamResponse{}
+	spoofed.Execute(resp)
+	if resp.failure != cjobs.ErrExecNotAuthorized {
+		t.Errorf("Expected the spoofed user to be refused: %v", resp.failure)
+	}
+
+	trusted := execRequest(t, &http.HttpConfiguration{TrustRequestUser: true}, "10.0.0.5:4000", "alice")
+	if trusted.User != "alice" {
+		t.Errorf("The request user should be trusted: %+v", trusted)
+	}
+
+	// a remote caller without a user may not run processes in a container
+	// that has no access list
+	if err := containers.RevokeExecAccess(containers.Identifier("test"), "alice"); err != nil {
+		t.Fatal(err)
+	}
+	anonymous := execRequest(t, &http.HttpConfiguration{}, "10.0.0.5:4000", "")
+	resp = &streamResponse{}
+	anonymous.Execute(resp)
+	if resp.failure != cjobs.ErrExecNotAuthorized {
+		t.Errorf("Expected the remote anonymous caller to be refused: %v", resp.failure)
+	}
+
+	if local := execRequest(t, &http.HttpConfiguration{}, "127.0.0.1:4000", ""); local.Remote {
+		t.Errorf("A caller on the server itself should not be remote: %+v", local)
+	}
+}
@@ -5,15 +5,19 @@ import (
 	"errors"
 	"io"
 	nethttp "net/http"
+	"net/url"
+	"strconv"
+	"time"
 
 	cjobs "github.com/openshift/geard/containers/jobs"
 	"github.com/openshift/geard/http"
 	"github.com/openshift/geard/port"
+	"github.com/openshift/geard/systemd"
 )
 
 func (h *HttpRunContainerRequest) MarshalHttpRequestBody(w io.Writer) error {
 	encoder := json.NewEncoder(w)
-	return encoder.Encode(h.RunContainerRequest)
+	return encoder.Encode(&h.RunContainerRequest)
 }
 
 func (h *HttpInstallContainerRequest) MarshalHttpRequestBody(w io.Writer) error {
@@ -22,19 +26,79 @@ func (h *HttpInstallContainerRequest) MarshalHttpRequestBody(w io.Writer) error
 }
 func (h *HttpInstallContainerRequest) UnmarshalHttpResponse(headers nethttp.Header, r io.Reader, mode http.ResponseContentMode) (interface{}, error) {
 	if r == nil {
-		pending := make(map[string]interface{})
-		if s := headers.Get("X-" + cjobs.PendingPortMappingName); s != "" {
-			ports, err := port.FromPortPairHeader(s)
-			if err != nil {
-				return nil, err
-			}
-			pending[cjobs.PendingPortMappingName] = ports
-		}
-		return pending, nil
+		return pendingPortMapping(headers)
 	}
 	return nil, errors.New("Unexpected response body to HttpInstallContainerRequest")
 }
 
+func (h *HttpBackupContainerRequest) MarshalUrlQuery(query *url.Values) {
+	if h.Quiesce {
+		query.Set("quiesce", "true")
+	}
+	if h.ManifestOnly {
+		query.Set("manifest", "true")
+	}
+}
+
+func (h *HttpRestoreContainerRequest) MarshalUrlQuery(query *url.Values) {
+	if h.Started {
+		query.Set("started", "true")
+	}
+}
+func (h *HttpRestoreContainerRequest) MarshalHttpRequestBody(w io.Writer) error {
+	_, err := io.Copy(w, h.Archive)
+	return err
+}
+func (h *HttpRestoreContainerRequest) UnmarshalHttpResponse(headers nethttp.Header, r io.Reader, mode http.ResponseContentMode) (interface{}, error) {
+	if r == nil {
+		return pendingPortMapping(headers)
+	}
+	return nil, errors.New("Unexpected response body to HttpRestoreContainerRequest")
+}
+
+func (h *HttpExecContainerRequest) MarshalUrlQuery(query *url.Values) {
+	for i := range h.Command {
+		query.Add("cmd", h.Command[i])
+	}
+	for i := range h.Environment {
+		query.Add("env", h.Environment[i])
+	}
+	if h.Tty {
+		query.Set("tty", "true")
+	}
+	if h.Rows != 0 {
+		query.Set("rows", strconv.Itoa(int(h.Rows)))
+	}
+	if h.Columns != 0 {
+		query.Set("columns", strconv.Itoa(int(h.Columns)))
+	}
+}
+func (h *HttpExecContainerRequest) MarshalHttpRequestBody(w io.Writer) error {
+	if h.Stdin == nil {
+		return nil
+	}
+	_, err := io.Copy(w, h.Stdin)
+	return err
+}
+
+// The ports assigned to a newly installed container.
+func pendingPortMapping(headers nethttp.Header) (map[string]interface{}, error) {
+	pending := make(map[string]interface{})
+	if s := headers.Get("X-" + cjobs.PendingPortMappingName); s != "" {
+		ports, err := port.FromPortPairHeader(s)
+		if err != nil {
+			return nil, err
+		}
+		pending[cjobs.PendingPortMappingName] = ports
+	}
+	return pending, nil
+}
+
+func (h *HttpPatchContainerLimitsRequest) MarshalHttpRequestBody(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	return encoder.Encode(h.ResourceLimits)
+}
+
 func (h *HttpPutEnvironmentRequest) MarshalHttpRequestBody(w io.Writer) error {
 	encoder := json.NewEncoder(w)
 	return encoder.Encode(h.EnvironmentDescription)
@@ -45,11 +109,62 @@ func (h *HttpPatchEnvironmentRequest) MarshalHttpRequestBody(w io.Writer) error
 	return encoder.Encode(h.EnvironmentDescription)
 }
 
+func (h *HttpSetIdlePolicyRequest) MarshalHttpRequestBody(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	return encoder.Encode(h.Policy)
+}
+
+func (h *HttpPutVolumeRequest) MarshalHttpRequestBody(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	return encoder.Encode(h.Volume)
+}
+
 func (h *HttpLinkContainersRequest) MarshalHttpRequestBody(w io.Writer) error {
 	encoder := json.NewEncoder(w)
 	return encoder.Encode(h.LinkContainersRequest)
 }
 
+func (h *HttpContainerLogRequest) MarshalUrlQuery(query *url.Values) {
+	marshalLogQuery(query, &h.LogOptions, h.Json)
+}
+
+func (h *HttpContainerLogsRequest) MarshalUrlQuery(query *url.Values) {
+	for i := range h.Ids {
+		query.Add("id", string(h.Ids[i]))
+	}
+	marshalLogQuery(query, &h.LogOptions, h.Json)
+}
+
+func marshalLogQuery(query *url.Values, opts *systemd.LogOptions, json bool) {
+	if opts.Lines != 0 {
+		query.Set("lines", strconv.Itoa(opts.Lines))
+	}
+	if !opts.Since.IsZero() {
+		query.Set("since", opts.Since.Format(time.RFC3339))
+	}
+	if !opts.Until.IsZero() {
+		query.Set("until", opts.Until.Format(time.RFC3339))
+	}
+	if opts.Priority != "" {
+		query.Set("priority", opts.Priority)
+	}
+	if opts.Follow {
+		query.Set("follow", "true")
+	}
+	if json {
+		query.Set("json", "true")
+	}
+}
+
+func (h *HttpContainerEventsRequest) MarshalUrlQuery(query *url.Values) {
+	for i := range h.Ids {
+		query.Add("id", string(h.Ids[i]))
+	}
+	for i := range h.Types {
+		query.Add("type", h.Types[i])
+	}
+}
+
 // Apply the "label" from the job to the response
 func (h *HttpListContainersRequest) UnmarshalHttpResponse(headers nethttp.Header, r io.Reader, mode http.ResponseContentMode) (interface{}, error) {
 	if r == nil {
@@ -66,3 +181,43 @@ func (h *HttpListContainersRequest) UnmarshalHttpResponse(headers nethttp.Header
 	}
 	return list, nil
 }
+
+func (h *HttpContainerHistoryRequest) UnmarshalHttpResponse(headers nethttp.Header, r io.Reader, mode http.ResponseContentMode) (interface{}, error) {
+	if r == nil {
+		return nil, errors.New("Unexpected empty response body to HttpContainerHistoryRequest")
+	}
+	history := &cjobs.ContainerHistoryResponse{}
+	if err := json.NewDecoder(r).Decode(history); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+func (h *HttpPullImageRequest) MarshalHttpRequestBody(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	return encoder.Encode(h.PullImageRequest)
+}
+
+func (h *HttpPutRegistryCredentialsRequest) MarshalHttpRequestBody(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	return encoder.Encode(h.RegistryCredentials)
+}
+
+func (h *HttpDeleteRegistryCredentialsRequest) MarshalUrlQuery(query *url.Values) {
+	query.Set("registry", h.Registry)
+}
+
+func (h *HttpRollbackContainerRequest) MarshalUrlQuery(query *url.Values) {
+	query.Set("to", h.To)
+}
+
+func (h *HttpHostResourcesRequest) UnmarshalHttpResponse(headers nethttp.Header, r io.Reader, mode http.ResponseContentMode) (interface{}, error) {
+	if r == nil {
+		return nil, errors.New("Unexpected empty response body to HttpHostResourcesRequest")
+	}
+	resources := &cjobs.HostResourcesResponse{}
+	if err := json.NewDecoder(r).Decode(resources); err != nil {
+		return nil, err
+	}
+	return resources, nil
+}
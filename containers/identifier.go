@@ -57,6 +57,12 @@ func (i Identifier) IdleUnitPathFor() string {
 	return filepath.Join(filepath.Dir(base), i.UnitIdleFlagNameFor())
 }
 
+// The idle policy of the container, stored beside its unit.
+func (i Identifier) IdlePolicyPathFor() string {
+	base := utils.IsolateContentPathWithPerm(filepath.Join(config.ContainerBasePath(), "units"), string(i), "", 0775)
+	return filepath.Join(filepath.Dir(base), fmt.Sprintf("%s%s.idle-policy", IdentifierPrefix, i))
+}
+
 func (i Identifier) VersionedUnitsPathFor() string {
 	return i.VersionedUnitPathFor("")
 }
@@ -94,6 +100,15 @@ func (i Identifier) EnvironmentPathFor() string {
 	return utils.IsolateContentPath(filepath.Join(config.ContainerBasePath(), "env", "contents"), string(i), "")
 }
 
+// The environments a container was installed with, by install request.
+func (i Identifier) VersionedEnvironmentsPathFor() string {
+	return i.VersionedEnvironmentPathFor("")
+}
+
+func (i Identifier) VersionedEnvironmentPathFor(suffix string) string {
+	return utils.IsolateContentPath(filepath.Join(config.ContainerBasePath(), "env", "versions"), string(i), suffix)
+}
+
 func (i Identifier) NetworkLinksPathFor() string {
 	return utils.IsolateContentPath(filepath.Join(config.ContainerBasePath(), "ports", "links"), string(i), "")
 }
@@ -118,10 +133,40 @@ func (i Identifier) PortDescriptionPathFor() string {
 	return utils.IsolateContentPath(filepath.Join(config.ContainerBasePath(), "ports", "descriptions"), string(i), "")
 }
 
+func (i Identifier) VolumePathFor() string {
+	return filepath.Join(config.ContainerBasePath(), "volumes", "data", string(i))
+}
+
+func (i Identifier) VolumeDescriptionPathFor() string {
+	return filepath.Join(config.ContainerBasePath(), "volumes", "descriptions", string(i)+".json")
+}
+
+func (i Identifier) HealthCheckPathFor() string {
+	return filepath.Join(config.ContainerBasePath(), "health", "checks", string(i)+".json")
+}
+
+func (i Identifier) HealthStatusPathFor() string {
+	return filepath.Join(config.ContainerBasePath(), "health", "status", string(i)+".json")
+}
+
+// The users allowed to run processes inside the container.
+func (i Identifier) ExecAccessBasePath() string {
+	return utils.IsolateContentPathWithPerm(filepath.Join(config.ContainerBasePath(), "access", "containers", "exec"), string(i), "", 0750)
+}
+
+func (i Identifier) ExecAccessPathFor(user string) string {
+	return utils.IsolateContentPathWithPerm(filepath.Join(config.ContainerBasePath(), "access", "containers", "exec"), string(i), user, 0750)
+}
+
 func (i Identifier) ContainerFor() string {
 	return fmt.Sprintf("%s", i)
 }
 
+// The docker container that holds the volumes of the container.
+func (i Identifier) DataContainerFor() string {
+	return fmt.Sprintf("%s-data", i)
+}
+
 type JobIdentifier []byte
 
 // An identifier for an individual request
@@ -0,0 +1,178 @@
+package containers
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	IdleNever    = "never"
+	IdleAfter    = "after"
+	IdleSchedule = "schedule"
+)
+
+// When the idler may stop a container that is not receiving traffic.
+// Containers without a policy are idled after the timeout of the idler.
+type IdlePolicy struct {
+	Mode string
+	// Minutes without traffic before an "after" container is idled
+	Minutes int `json:",omitempty"`
+	// The HH:MM-HH:MM windows, in the local time of the server, during
+	// which a "schedule" container is idled as soon as it is quiet.  A
+	// window may cross midnight.
+	Schedule []string `json:",omitempty"`
+}
+
+func (p *IdlePolicy) Check() error {
+	switch p.Mode {
+	case IdleNever:
+		if p.Minutes != 0 || len(p.Schedule) != 0 {
+			return errors.New("A container that is never idled may not have a timeout or schedule")
+		}
+	case IdleAfter:
+		if p.Minutes < 1 {
+			return errors.New("The idle timeout must be a positive number of minutes")
+		}
+		if len(p.Schedule) != 0 {
+			return errors.New("A container idled after a timeout may not have a schedule")
+		}
+	case IdleSchedule:
+		if len(p.Schedule) == 0 {
+			return errors.New("An idle schedule must have at least one window")
+		}
+		if p.Minutes != 0 {
+			return errors.New("A container idled on a schedule may not have a timeout")
+		}
+		for _, window := range p.Schedule {
+			if _, _, err := parseIdleWindow(window); err != nil {
+				return err
+			}
+		}
+	default:
+		return errors.New(fmt.Sprintf("The idle policy '%s' must be one of never, after, or schedule", p.Mode))
+	}
+	return nil
+}
+
+// True if a container that has received no traffic for quiet should be
+// idled at now.
+func (p *IdlePolicy) ShouldIdle(quiet time.Duration, now time.Time) bool {
+	switch p.Mode {
+	case IdleAfter:
+		return quiet >= time.Duration(p.Minutes)*time.Minute
+	case IdleSchedule:
+		return quiet > 0 && p.InSchedule(now)
+	}
+	return false
+}
+
+// True if now falls within one of the windows of the schedule.
+func (p *IdlePolicy) InSchedule(now time.Time) bool {
+	minute := now.Hour()*60 + now.Minute()
+	for _, window := range p.Schedule {
+		start, end, err := parseIdleWindow(window)
+		if err != nil {
+			continue
+		}
+		if start <= end {
+			if minute >= start && minute < end {
+				return true
+			}
+		} else if minute >= start || minute < end {
+			return true
+		}
+	}
+	return false
+}
+
+func (p IdlePolicy) String() string {
+	switch p.Mode {
+	case IdleAfter:
+		return fmt.Sprintf("%s:%d", p.Mode, p.Minutes)
+	case IdleSchedule:
+		return p.Mode + ":" + strings.Join(p.Schedule, ",")
+	}
+	return p.Mode
+}
+
+// Parse a policy of the form never, after:<minutes>, or
+// schedule:<HH:MM-HH:MM>[,<HH:MM-HH:MM>...].
+func NewIdlePolicyFromString(s string) (*IdlePolicy, error) {
+	parts := strings.SplitN(s, ":", 2)
+	p := &IdlePolicy{Mode: parts[0]}
+	switch p.Mode {
+	case IdleNever:
+		if len(parts) != 1 {
+			return nil, errors.New("The idle policy 'never' does not take a value")
+		}
+	case IdleAfter:
+		if len(parts) != 2 {
+			return nil, errors.New("The idle policy must be of the form after:<minutes>")
+		}
+		minutes, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("The idle timeout '%s' must be a number of minutes", parts[1]))
+		}
+		p.Minutes = minutes
+	case IdleSchedule:
+		if len(parts) != 2 {
+			return nil, errors.New("The idle policy must be of the form schedule:<HH:MM-HH:MM>[,...]")
+		}
+		p.Schedule = strings.Split(parts[1], ",")
+	}
+	if err := p.Check(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Return the start and end of a window as minutes after midnight.
+func parseIdleWindow(window string) (int, int, error) {
+	times := strings.Split(window, "-")
+	if len(times) != 2 {
+		return 0, 0, errors.New(fmt.Sprintf("The idle window '%s' must be of the form HH:MM-HH:MM", window))
+	}
+	start, err := parseMinuteOfDay(times[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := parseMinuteOfDay(times[1])
+	if err != nil {
+		return 0, 0, err
+	}
+	if start == end {
+		return 0, 0, errors.New(fmt.Sprintf("The idle window '%s' must not start and end at the same time", window))
+	}
+	return start, end, nil
+}
+
+func parseMinuteOfDay(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, errors.New(fmt.Sprintf("The time '%s' must be of the form HH:MM", s))
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func SaveIdlePolicy(id Identifier, p *IdlePolicy) error {
+	return writeJsonFile(id.IdlePolicyPathFor(), p)
+}
+
+func GetIdlePolicy(id Identifier) (*IdlePolicy, error) {
+	p := &IdlePolicy{}
+	if err := readJsonFile(id.IdlePolicyPathFor(), p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func RemoveIdlePolicy(id Identifier) error {
+	if err := os.Remove(id.IdlePolicyPathFor()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
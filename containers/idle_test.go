@@ -0,0 +1,70 @@
+package containers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewIdlePolicyFromString(t *testing.T) {
+	p, err := NewIdlePolicyFromString("never")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Mode != IdleNever {
+		t.Errorf("Unexpected policy: %+v", p)
+	}
+	p, err = NewIdlePolicyFromString("after:30")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Mode != IdleAfter || p.Minutes != 30 || p.String() != "after:30" {
+		t.Errorf("Unexpected policy: %+v", p)
+	}
+	p, err = NewIdlePolicyFromString("schedule:22:00-06:00,12:00-13:30")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Mode != IdleSchedule || len(p.Schedule) != 2 || p.Schedule[1] != "12:00-13:30" {
+		t.Errorf("Unexpected policy: %+v", p)
+	}
+
+	for _, s := range []string{"", "sometimes", "never:5", "after", "after:0", "after:ten", "schedule", "schedule:", "schedule:22:00", "schedule:25:00-01:00", "schedule:10:00-10:00"} {
+		if _, err := NewIdlePolicyFromString(s); err == nil {
+			t.Errorf("Expected an error for %q", s)
+		}
+	}
+}
+
+func TestIdlePolicyShouldIdle(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2014, 5, 1, hour, minute, 0, 0, time.Local)
+	}
+
+	never := &IdlePolicy{Mode: IdleNever}
+	if never.ShouldIdle(24*time.Hour, at(3, 0)) {
+		t.Error("A container that is never idled should not be idled")
+	}
+
+	after := &IdlePolicy{Mode: IdleAfter, Minutes: 30}
+	if after.ShouldIdle(29*time.Minute, at(3, 0)) {
+		t.Error("The container should not be idled before the timeout")
+	}
+	if !after.ShouldIdle(30*time.Minute, at(3, 0)) {
+		t.Error("The container should be idled after the timeout")
+	}
+
+	schedule := &IdlePolicy{Mode: IdleSchedule, Schedule: []string{"22:00-06:00", "12:00-13:00"}}
+	for _, now := range []time.Time{at(23, 0), at(0, 0), at(5, 59), at(12, 30)} {
+		if !schedule.ShouldIdle(time.Minute, now) {
+			t.Errorf("The container should be idled at %s", now.Format("15:04"))
+		}
+	}
+	for _, now := range []time.Time{at(6, 0), at(11, 59), at(13, 0), at(21, 59)} {
+		if schedule.ShouldIdle(time.Hour, now) {
+			t.Errorf("The container should not be idled at %s", now.Format("15:04"))
+		}
+	}
+	if schedule.ShouldIdle(0, at(23, 0)) {
+		t.Error("A container that has received traffic should not be idled")
+	}
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/openshift/geard/containers"
 	csystemd "github.com/openshift/geard/containers/systemd"
+	"github.com/openshift/geard/dispatcher"
 	"github.com/openshift/geard/jobs"
 	"github.com/openshift/geard/systemd"
 )
@@ -93,6 +94,12 @@ func inStateOrTooSoon(id containers.Identifier, unit string, active, transition
 	return
 }
 
+// Changes to container state are cheap and time sensitive, so they are
+// scheduled ahead of builds and installs.
+func (j *StartedContainerStateRequest) Priority() dispatcher.Priority {
+	return dispatcher.PriorityHigh
+}
+
 func (j *StartedContainerStateRequest) Execute(resp jobs.Response) {
 	unitName := j.Id.UnitNameFor()
 	unitPath := j.Id.UnitPathFor()
@@ -134,6 +141,10 @@ func (j *StartedContainerStateRequest) Execute(resp jobs.Response) {
 	fmt.Fprintf(w, "Container %s starting\n", j.Id)
 }
 
+func (j *StoppedContainerStateRequest) Priority() dispatcher.Priority {
+	return dispatcher.PriorityHigh
+}
+
 func (j *StoppedContainerStateRequest) Execute(resp jobs.Response) {
 	unitName := j.Id.UnitNameFor()
 
@@ -201,6 +212,10 @@ func (j *StoppedContainerStateRequest) Execute(resp jobs.Response) {
 	}
 }
 
+func (j *RestartContainerRequest) Priority() dispatcher.Priority {
+	return dispatcher.PriorityHigh
+}
+
 func (j *RestartContainerRequest) Execute(resp jobs.Response) {
 	unitName := j.Id.UnitNameFor()
 	unitPath := j.Id.UnitPathFor()
@@ -0,0 +1,239 @@
+// +build linux
+
+package jobs
+
+import (
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/user"
+	"path"
+	"sort"
+	"strconv"
+
+	"github.com/openshift/geard/containers"
+	csystemd "github.com/openshift/geard/containers/systemd"
+	"github.com/openshift/geard/docker"
+	"github.com/openshift/geard/jobs"
+	"github.com/openshift/geard/port"
+	"github.com/openshift/geard/systemd"
+	"github.com/openshift/geard/utils"
+)
+
+func (j *BackupContainerRequest) Execute(resp jobs.Response) {
+	manifest, err := containers.GetBackupManifest(j.Id)
+	if err != nil {
+		if os.IsNotExist(err) {
+			resp.Failure(ErrContainerNotFound)
+			return
+		}
+		log.Printf("backup: Unable to describe container %s: %v", j.Id, err)
+		resp.Failure(ErrBackupFailed)
+		return
+	}
+
+	// the data container is created the first time the unit starts
+	d, err := docker.GetConnection(j.DockerSocket)
+	if err != nil {
+		log.Printf("backup: Unable to connect to docker: %v", err)
+		resp.Failure(ErrBackupFailed)
+		return
+	}
+	volumes := map[string]string{}
+	if data, err := d.InspectContainer(j.Id.DataContainerFor()); err == nil {
+		volumes = data.Volumes
+	} else if err != docker.ErrNoSuchContainer {
+		log.Printf("backup: Unable to inspect the data container of %s: %v", j.Id, err)
+		resp.Failure(ErrBackupFailed)
+		return
+	}
+	for volume := range volumes {
+		manifest.DataVolumes = append(manifest.DataVolumes, volume)
+	}
+	sort.Strings(manifest.DataVolumes)
+
+	if j.Quiesce && !j.ManifestOnly {
+		unitName := j.Id.UnitNameFor()
+		if props, err := systemd.Connection().GetUnitProperties(unitName); err == nil && props["ActiveState"] == "active" {
+			if _, err := systemd.Connection().StopUnit(unitName, "replace"); err != nil {
+				log.Printf("backup: Unable to stop %s: %v", unitName, err)
+				resp.Failure(ErrContainerStopFailed)
+				return
+			}
+			defer func() {
+				if err := systemd.Connection().StartUnitJob(unitName, "replace"); err != nil {
+					log.Printf("backup: Unable to start %s after the backup: %v", unitName, err)
+				}
+			}()
+		}
+	}
+
+	w := resp.SuccessWithWrite(jobs.ResponseOk, false, false)
+	archive, err := containers.NewBackupWriter(w, manifest)
+	if err != nil {
+		log.Printf("backup: Unable to write the manifest: %v", err)
+		return
+	}
+	if j.ManifestOnly {
+		if err := archive.Close(); err != nil {
+			log.Printf("backup: Unable to finish the archive: %v", err)
+		}
+		return
+	}
+	if err := archive.AddDirectory("home", j.Id.HomePath()); err != nil && !os.IsNotExist(err) {
+		log.Printf("backup: Unable to archive the home directory of %s: %v", j.Id, err)
+		return
+	}
+	for _, volume := range manifest.DataVolumes {
+		if err := archive.AddDirectory(path.Join("data", volume), volumes[volume]); err != nil {
+			log.Printf("backup: Unable to archive the volume %s of %s: %v", volume, j.Id, err)
+			return
+		}
+	}
+	if err := archive.Close(); err != nil {
+		log.Printf("backup: Unable to finish the archive: %v", err)
+	}
+}
+
+func (j *RestoreContainerRequest) Execute(resp jobs.Response) {
+	if _, err := os.Stat(j.Id.UnitPathFor()); err == nil {
+		resp.Failure(ErrContainerAlreadyExists)
+		return
+	}
+
+	archive, err := containers.NewBackupReader(j.Archive)
+	if err != nil {
+		log.Printf("restore: Unable to read the manifest: %v", err)
+		resp.Failure(ErrRestoreInvalidArchive)
+		return
+	}
+	manifest := archive.Manifest
+
+	// external ports are assigned by this host
+	ports := make(port.PortPairs, len(manifest.Ports))
+	for i := range manifest.Ports {
+		ports[i].Internal = manifest.Ports[i].Internal
+	}
+	install := &InstallContainerRequest{
+		RequestIdentifier: j.RequestIdentifier,
+
+		Id:      j.Id,
+		Image:   manifest.Image,
+		Isolate: manifest.Isolate,
+
+		Ports:       ports,
+		Volumes:     manifest.Volumes,
+		HealthCheck: manifest.HealthCheck,
+		IdlePolicy:  manifest.IdlePolicy,
+	}
+	if len(manifest.Environment) > 0 {
+		install.Environment = &containers.EnvironmentDescription{Id: j.Id, Variables: manifest.Environment}
+	}
+	if !manifest.Limits.Empty() {
+		install.Limits = &manifest.Limits
+	}
+	if len(manifest.NetworkLinks) > 0 {
+		install.NetworkLinks = &manifest.NetworkLinks
+	}
+	if err := install.Check(); err != nil {
+		log.Printf("restore: The manifest does not describe a valid container: %v", err)
+		resp.Failure(ErrRestoreInvalidArchive)
+		return
+	}
+	installed := &stepResponse{Response: resp}
+	install.Execute(installed)
+	if installed.err != nil {
+		resp.Failure(installed.err)
+		return
+	}
+
+	homePath := j.Id.HomePath()
+	if err := os.MkdirAll(homePath, 0700); err != nil {
+		log.Printf("restore: Unable to create the home directory of %s: %v", j.Id, err)
+		j.abort(resp, ErrRestoreFailed)
+		return
+	}
+	roots := map[string]string{"home": homePath}
+
+	if len(manifest.DataVolumes) > 0 {
+		d, err := docker.GetConnection(j.DockerSocket)
+		if err != nil {
+			log.Printf("restore: Unable to connect to docker: %v", err)
+			j.abort(resp, ErrRestoreFailed)
+			return
+		}
+		data, err := d.InspectContainer(j.Id.DataContainerFor())
+		if err == docker.ErrNoSuchContainer {
+			data, err = d.CreateDataContainer(j.Id.DataContainerFor(), manifest.Image)
+		}
+		if err != nil {
+			log.Printf("restore: Unable to create the data container of %s: %v", j.Id, err)
+			j.abort(resp, ErrRestoreFailed)
+			return
+		}
+		for _, volume := range manifest.DataVolumes {
+			if hostPath, ok := data.Volumes[volume]; ok {
+				roots[path.Join("data", volume)] = hostPath
+			} else {
+				log.Printf("restore: The image %s no longer has the volume %s", manifest.Image, volume)
+			}
+		}
+	}
+
+	if err := archive.ExtractTo(roots); err != nil {
+		log.Printf("restore: Unable to extract the archive for %s: %v", j.Id, err)
+		j.abort(resp, ErrRestoreFailed)
+		return
+	}
+
+	// the user of an isolated container is created when it first starts
+	if u, err := user.Lookup(j.Id.LoginFor()); err == nil {
+		uid, _ := strconv.Atoi(u.Uid)
+		gid, _ := strconv.Atoi(u.Gid)
+		if err := utils.ChownTree(homePath, uid, gid); err != nil {
+			log.Printf("restore: Unable to change the owner of %s: %v", homePath, err)
+		}
+	}
+
+	if j.Started {
+		if err := csystemd.SetUnitStartOnBoot(j.Id, true); err != nil {
+			log.Printf("restore: Unable to write container boot link: %v", err)
+		}
+		if err := systemd.Connection().StartUnitJob(j.Id.UnitNameFor(), "replace"); err != nil {
+			log.Printf("restore: Could not start container %s: %v", j.Id, err)
+			j.abort(resp, ErrContainerStartFailed)
+			return
+		}
+	}
+
+	resp.Success(jobs.ResponseOk)
+}
+
+// Remove a partially restored container so the restore can be retried.
+func (j *RestoreContainerRequest) abort(resp jobs.Response, reason error) {
+	deleted := &stepResponse{Response: resp}
+	(&DeleteContainerRequest{Id: j.Id}).Execute(deleted)
+	if deleted.err != nil {
+		log.Printf("restore: Unable to remove %s after a failed restore: %v", j.Id, deleted.err)
+	}
+	resp.Failure(reason)
+}
+
+// Records the outcome of a job run as one step of another job, passing
+// side channel data through to the response of the outer job.
+type stepResponse struct {
+	jobs.Response
+	err error
+}
+
+func (r *stepResponse) Success(t jobs.ResponseSuccess)                           {}
+func (r *stepResponse) SuccessWithData(t jobs.ResponseSuccess, data interface{}) {}
+func (r *stepResponse) SuccessWithWrite(t jobs.ResponseSuccess, flush, structured bool) io.Writer {
+	return ioutil.Discard
+}
+func (r *stepResponse) Failure(reason error) {
+	if r.err == nil {
+		r.err = reason
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/openshift/geard/containers"
+	"github.com/openshift/geard/dispatcher"
 	"github.com/openshift/geard/jobs"
 	"github.com/openshift/geard/systemd"
 	"github.com/openshift/geard/utils"
@@ -22,6 +23,11 @@ const (
 	gearBinaryPath = "/usr/bin/gear"
 )
 
+// Builds are long running and should not delay other work.
+func (j *BuildImageRequest) Priority() dispatcher.Priority {
+	return dispatcher.PriorityLow
+}
+
 func (j *BuildImageRequest) Execute(resp jobs.Response) {
 	w := resp.SuccessWithWrite(jobs.ResponseAccepted, true, false)
 
@@ -106,16 +112,23 @@ func (j *BuildImageRequest) Execute(resp jobs.Response) {
 	}
 
 	log.Printf("build_image: Will execute %v", startCmd)
-	status, err := systemd.Connection().StartTransientUnit(
-		unitName,
-		"fail",
-		dbus.PropExecStart(startCmd, true),
-		dbus.PropDescription(unitDescription),
-		dbus.PropRemainAfterExit(true),
-		dbus.PropSlice("container-small.slice"),
-	)
+	var status string
+	started, err := j.cancel.start(func() (err error) {
+		status, err = systemd.Connection().StartTransientUnit(
+			unitName,
+			"fail",
+			dbus.PropExecStart(startCmd, true),
+			dbus.PropDescription(unitDescription),
+			dbus.PropRemainAfterExit(true),
+			dbus.PropSlice("container-small.slice"),
+		)
+		return
+	})
 
-	if err != nil {
+	if !started {
+		fmt.Fprintf(w, "Build canceled\n")
+		return
+	} else if err != nil {
 		errType := reflect.TypeOf(err)
 		fmt.Fprintf(w, "Unable to start build container for this image due to (%s): %s\n", errType, err.Error())
 		return
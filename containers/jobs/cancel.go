@@ -0,0 +1,83 @@
+// +build linux
+
+package jobs
+
+import (
+	"log"
+
+	"github.com/openshift/geard/containers"
+	"github.com/openshift/geard/dispatcher"
+	"github.com/openshift/geard/systemd"
+)
+
+// Mark the job canceled, and stop the named unit if the job has
+// already started it.
+func (c *cancelState) cancelUnit(unitName string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.canceled = true
+	if !c.started {
+		return nil
+	}
+	log.Printf("job: Stopping %s on cancellation", unitName)
+	return systemd.Connection().StopUnitJob(unitName, "replace")
+}
+
+// Start the unit of the job with f, unless the job has been canceled in
+// which case false is returned.  A cancellation waits until f returns,
+// and the unit is only stopped on cancellation if f succeeded.
+func (c *cancelState) start(f func() error) (bool, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.canceled {
+		return false, nil
+	}
+	if err := f(); err != nil {
+		return true, err
+	}
+	c.started = true
+	return true, nil
+}
+
+func (j *BuildImageRequest) Cancel() error {
+	return j.cancel.cancelUnit(containers.JobIdentifier(j.Name).UnitNameForBuild())
+}
+
+func (j *RunContainerRequest) Cancel() error {
+	return j.cancel.cancelUnit(containers.JobIdentifier(j.Name).UnitNameFor())
+}
+
+// An install is only interrupted if the container was requested to be
+// started - the unit definition is left in place.  Other installs run to
+// completion.
+func (req *InstallContainerRequest) Cancel() error {
+	if !req.Started {
+		return dispatcher.ErrJobNotCancelable
+	}
+	return req.cancel.cancelUnit(req.Id.UnitNameFor())
+}
+
+// A channel that is closed once the job is signaled to stop.
+func (s *stopSignal) done() <-chan struct{} {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.ch == nil {
+		s.ch = make(chan struct{})
+		if s.stopped {
+			close(s.ch)
+		}
+	}
+	return s.ch
+}
+
+func (s *stopSignal) signal() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.stopped {
+		return
+	}
+	s.stopped = true
+	if s.ch != nil {
+		close(s.ch)
+	}
+}
@@ -0,0 +1,70 @@
+// +build linux
+
+package jobs
+
+import (
+	"encoding/json"
+	"log"
+
+	"github.com/openshift/geard/containers"
+	csystemd "github.com/openshift/geard/containers/systemd"
+	"github.com/openshift/geard/jobs"
+)
+
+// Event streams run until the client goes away, so they do not occupy
+// a dispatcher worker.
+func (j *ContainerEventsRequest) Continuous() bool {
+	return true
+}
+
+func (j *ContainerEventsRequest) Cancel() error {
+	j.stop.signal()
+	return nil
+}
+
+func (j *ContainerEventsRequest) Execute(resp jobs.Response) {
+	if !resp.StreamResult() {
+		resp.Failure(ErrEventsMustStream)
+		return
+	}
+	if err := j.Check(); err != nil {
+		resp.Failure(jobs.SimpleError{jobs.ResponseInvalidRequest, err.Error()})
+		return
+	}
+	types := make(map[csystemd.EventType]bool)
+	for i := range j.Types {
+		t, _ := csystemd.NewEventType(j.Types[i])
+		types[t] = true
+	}
+	ids := make(map[containers.Identifier]bool)
+	for i := range j.Ids {
+		ids[j.Ids[i]] = true
+	}
+
+	subscription, err := csystemd.SubscribeToEvents()
+	if err != nil {
+		log.Printf("job_container_events: Unable to subscribe to events: %v", err)
+		resp.Failure(ErrEventsUnavailable)
+		return
+	}
+	defer subscription.Close()
+
+	w := resp.SuccessWithWrite(jobs.ResponseOk, true, true)
+	encoder := json.NewEncoder(w)
+	for {
+		select {
+		case event := <-subscription.Events():
+			if len(ids) > 0 && !ids[event.Id] {
+				continue
+			}
+			if len(types) > 0 && !types[event.Type] {
+				continue
+			}
+			if err := encoder.Encode(event); err != nil {
+				return
+			}
+		case <-j.stop.done():
+			return
+		}
+	}
+}
@@ -0,0 +1,140 @@
+//go:build linux
+// +build linux
+
+package jobs
+
+import (
+	"log"
+	"math"
+	"os"
+
+	db "github.com/godbus/dbus"
+	"github.com/openshift/geard/config"
+	"github.com/openshift/geard/containers"
+	"github.com/openshift/geard/jobs"
+	"github.com/openshift/geard/systemd"
+	"github.com/openshift/geard/utils"
+	"github.com/openshift/go-systemd/dbus"
+)
+
+func (j *PatchContainerLimitsRequest) Execute(resp jobs.Response) {
+	unitPath := j.Id.UnitPathFor()
+	unitName := j.Id.UnitNameFor()
+	unitVersionPath := j.Id.VersionedUnitPathFor(j.RequestIdentifier.String())
+
+	// without --foreground the unit runs the docker client, and the container
+	// processes are outside of the cgroup the limits would be set on
+	if !config.SystemDockerFeatures.ForegroundRun {
+		resp.Failure(ErrLimitsRequireForeground)
+		return
+	}
+
+	if _, err := os.Stat(unitPath); err != nil {
+		resp.Failure(ErrContainerNotFound)
+		return
+	}
+
+	// lock the unit to prevent simultaneous updates
+	state, _, err := utils.OpenFileExclusive(unitPath, 0664)
+	if err != nil {
+		log.Print("container_limits: Unable to lock unit file: ", err)
+		resp.Failure(ErrLimitsUpdateFailed)
+		return
+	}
+	defer state.Close()
+
+	existing, err := containers.GetExistingLimits(j.Id)
+	if err != nil {
+		if os.IsNotExist(err) {
+			resp.Failure(ErrContainerNotFound)
+			return
+		}
+		log.Printf("container_limits: Unable to read existing limits: %v", err)
+		resp.Failure(ErrLimitsUpdateFailed)
+		return
+	}
+	limits := existing.Merge(j.ResourceLimits)
+
+	if err := writeLimitsUnit(unitPath, unitVersionPath, limits); err != nil {
+		log.Printf("container_limits: Unable to write unit: %v", err)
+		resp.Failure(ErrLimitsUpdateFailed)
+		os.Remove(unitVersionPath)
+		return
+	}
+
+	// apply the limits until the next restart, when the unit file takes over
+	// and moves the container to the slice for its new limits
+	if err := systemd.Connection().SetUnitProperties(unitName, true, limitProperties(j.ResourceLimits, limits)...); err != nil {
+		log.Printf("container_limits: Systemd rejected the limits for %s: %v", unitName, err)
+		restoreLimits(unitName, j.ResourceLimits, *existing)
+		resp.Failure(ErrLimitsUpdateFailed)
+		os.Remove(unitVersionPath)
+		return
+	}
+
+	if err := utils.AtomicReplaceLink(unitVersionPath, unitPath); err != nil {
+		log.Printf("container_limits: Failed to activate new unit: %v", err)
+		restoreLimits(unitName, j.ResourceLimits, *existing)
+		resp.Failure(ErrLimitsUpdateFailed)
+		os.Remove(unitVersionPath)
+		return
+	}
+	if err := systemd.Connection().Reload(); err != nil {
+		log.Printf("container_limits: Unable to reload systemd: %v", err)
+	}
+
+	resp.Success(jobs.ResponseOk)
+}
+
+func writeLimitsUnit(unitPath, unitVersionPath string, limits containers.ResourceLimits) error {
+	existing, err := os.Open(unitPath)
+	if err != nil {
+		return err
+	}
+	defer existing.Close()
+
+	unit, err := utils.CreateFileExclusive(unitVersionPath, 0664)
+	if err != nil {
+		return err
+	}
+	if err := containers.WriteLimitsToUnitFile(existing, unit, limits); err != nil {
+		unit.Close()
+		return err
+	}
+	return unit.Close()
+}
+
+func restoreLimits(unitName string, changed, previous containers.ResourceLimits) {
+	if err := systemd.Connection().SetUnitProperties(unitName, true, limitProperties(changed, previous)...); err != nil {
+		log.Printf("container_limits: Unable to restore the previous limits for %s: %v", unitName, err)
+	}
+}
+
+// The systemd properties that set each limit in changed to its value in
+// limits.  Limits with no value are set to infinity.
+func limitProperties(changed, limits containers.ResourceLimits) []dbus.Property {
+	props := []dbus.Property{}
+	value := func(v uint64) db.Variant {
+		if v == 0 {
+			return db.MakeVariant(uint64(math.MaxUint64))
+		}
+		return db.MakeVariant(v)
+	}
+	if changed.Memory != 0 {
+		props = append(props, dbus.Property{Name: "MemoryLimit", Value: value(limits.Memory)})
+	}
+	if changed.CpuShares != 0 {
+		props = append(props, dbus.Property{Name: "CPUShares", Value: value(limits.CpuShares)})
+	}
+	if changed.CpuQuota != 0 {
+		// systemd expects microseconds of CPU time per second
+		props = append(props, dbus.Property{Name: "CPUQuotaPerSecUSec", Value: value(limits.CpuQuota * 10000)})
+	}
+	if changed.BlockIOWeight != 0 {
+		props = append(props, dbus.Property{Name: "BlockIOWeight", Value: value(limits.BlockIOWeight)})
+	}
+	if changed.Tasks != 0 {
+		props = append(props, dbus.Property{Name: "TasksMax", Value: value(limits.Tasks)})
+	}
+	return props
+}
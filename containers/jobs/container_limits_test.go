@@ -0,0 +1,22 @@
+// +build linux
+
+package jobs
+
+import (
+	"testing"
+
+	"github.com/openshift/geard/config"
+	"github.com/openshift/geard/containers"
+)
+
+func TestPatchLimitsRequiresForegroundRun(t *testing.T) {
+	previous := config.SystemDockerFeatures.ForegroundRun
+	config.SystemDockerFeatures.ForegroundRun = false
+	defer func() { config.SystemDockerFeatures.ForegroundRun = previous }()
+
+	resp := &testResponse{}
+	(&PatchContainerLimitsRequest{Id: containers.Identifier("test"), ResourceLimits: containers.ResourceLimits{Memory: 1024}}).Execute(resp)
+	if resp.failure != ErrLimitsRequireForeground {
+		t.Errorf("Expected the limits to be refused without foreground run: %v", resp.failure)
+	}
+}
@@ -3,23 +3,117 @@
 package jobs
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
 	"log"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/openshift/geard/containers"
 	"github.com/openshift/geard/jobs"
 	"github.com/openshift/geard/systemd"
 )
 
+func (j *ContainerLogRequest) ReadOnly() bool {
+	return true
+}
+
+// Followed logs run until the client goes away, so they do not occupy a
+// dispatcher worker.
+func (j *ContainerLogRequest) Continuous() bool {
+	return j.Follow
+}
+
+func (j *ContainerLogRequest) Cancel() error {
+	j.stop.signal()
+	return nil
+}
+
 func (j *ContainerLogRequest) Execute(resp jobs.Response) {
-	if _, err := os.Stat(j.Id.UnitPathFor()); err != nil {
-		resp.Failure(ErrContainerNotFound)
+	if err := j.Check(); err != nil {
+		resp.Failure(jobs.SimpleError{jobs.ResponseInvalidRequest, err.Error()})
 		return
 	}
+	units := make([]string, len(j.Ids))
+	for i := range j.Ids {
+		if _, err := os.Stat(j.Ids[i].UnitPathFor()); err != nil {
+			resp.Failure(ErrContainerNotFound)
+			return
+		}
+		units[i] = j.Ids[i].UnitNameFor()
+	}
 
-	w := resp.SuccessWithWrite(jobs.ResponseOk, true, false)
-	err := systemd.WriteLogsTo(w, j.Id.UnitNameFor(), 30, time.After(30*time.Second))
+	journal, err := systemd.OpenJournal(units, &j.LogOptions)
 	if err != nil {
-		log.Printf("job_container_log: Unable to fetch journal logs: %s\n", err.Error())
+		log.Printf("job_container_log: Unable to read the journal: %v", err)
+		resp.Failure(ErrLogsUnavailable)
+		return
+	}
+	defer journal.Close()
+
+	go func() {
+		<-j.stop.done()
+		journal.Close()
+	}()
+	defer j.stop.signal()
+
+	w := resp.SuccessWithWrite(jobs.ResponseOk, true, j.Json)
+	decoder := json.NewDecoder(journal)
+	encoder := json.NewEncoder(w)
+	for {
+		entry := make(map[string]interface{})
+		if err := decoder.Decode(&entry); err != nil {
+			if err != io.EOF {
+				log.Printf("job_container_log: Unable to read a journal entry: %v", err)
+			}
+			return
+		}
+		if j.Json {
+			err = encoder.Encode(entry)
+		} else {
+			err = writeLogEntry(w, entry, len(j.Ids) > 1)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Write the time and message of a journal entry on one line.
+func writeLogEntry(w io.Writer, entry map[string]interface{}, withContainer bool) error {
+	var prefix string
+	if s, ok := entry["__REALTIME_TIMESTAMP"].(string); ok {
+		if usec, err := strconv.ParseInt(s, 10, 64); err == nil {
+			prefix = time.Unix(0, usec*int64(time.Microsecond)).Format(time.RFC3339) + " "
+		}
+	}
+	if withContainer {
+		if unit, ok := entry["_SYSTEMD_UNIT"].(string); ok {
+			prefix = strings.TrimSuffix(strings.TrimPrefix(unit, containers.IdentifierPrefix), ".service") + " " + prefix
+		}
+	}
+	message := journalMessage(entry["MESSAGE"])
+	_, err := fmt.Fprintf(w, "%s%s\n", prefix, strings.TrimRight(message, "\n"))
+	return err
+}
+
+// Messages that are not valid UTF-8 are returned by the journal as an
+// array of bytes.
+func journalMessage(value interface{}) string {
+	switch m := value.(type) {
+	case string:
+		return m
+	case []interface{}:
+		b := make([]byte, 0, len(m))
+		for i := range m {
+			if n, ok := m[i].(float64); ok {
+				b = append(b, byte(n))
+			}
+		}
+		return string(b)
 	}
+	return ""
 }
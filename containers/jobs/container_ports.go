@@ -9,6 +9,10 @@ import (
 	"github.com/openshift/geard/jobs"
 )
 
+func (j *ContainerPortsRequest) ReadOnly() bool {
+	return true
+}
+
 func (j *ContainerPortsRequest) Execute(resp jobs.Response) {
 	portPairs, err := containers.GetExistingPorts(j.Id)
 	if err != nil {
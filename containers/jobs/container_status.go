@@ -3,13 +3,19 @@
 package jobs
 
 import (
+	"fmt"
 	"log"
 	"os"
 
+	"github.com/openshift/geard/containers"
 	"github.com/openshift/geard/jobs"
 	"github.com/openshift/geard/systemd"
 )
 
+func (j *ContainerStatusRequest) ReadOnly() bool {
+	return true
+}
+
 func (j *ContainerStatusRequest) Execute(resp jobs.Response) {
 	if _, err := os.Stat(j.Id.UnitPathFor()); err != nil {
 		//log.Printf("container_status: Can't stat unit: %v", err)
@@ -17,8 +23,34 @@ func (j *ContainerStatusRequest) Execute(resp jobs.Response) {
 		return
 	}
 
+	limits, err := containers.GetExistingLimits(j.Id)
+	if err != nil {
+		log.Printf("container_status: Unable to read resource limits: %v", err)
+		limits = &containers.ResourceLimits{}
+	}
+
+	health, err := containers.GetHealthStatus(j.Id)
+	if err != nil {
+		log.Printf("container_status: Unable to read health: %v", err)
+	}
+
 	w := resp.SuccessWithWrite(jobs.ResponseOk, true, false)
-	err := systemd.WriteStatusTo(w, j.Id.UnitNameFor())
+	fmt.Fprintf(w, "Resource limits: %s\n", limits.String())
+	if policy, err := containers.GetIdlePolicy(j.Id); err == nil {
+		fmt.Fprintf(w, "Idle policy: %s\n", policy.String())
+	}
+	if health != nil {
+		if check, err := containers.GetHealthCheck(j.Id); err == nil {
+			fmt.Fprintf(w, "Health check: %s\n", check.String())
+		}
+		fmt.Fprintf(w, "Health: %s", health.String())
+		if health.Restarts > 0 {
+			fmt.Fprintf(w, " (restarted %d times)", health.Restarts)
+		}
+		fmt.Fprintf(w, "\n")
+	}
+	fmt.Fprintf(w, "\n")
+	err = systemd.WriteStatusTo(w, j.Id.UnitNameFor())
 	if err != nil {
 		log.Printf("container_status: Unable to fetch container status logs: %s\n", err.Error())
 	}
@@ -15,6 +15,10 @@ func (j *ContentRequest) Fast() bool {
 	return true
 }
 
+func (j *ContentRequest) ReadOnly() bool {
+	return true
+}
+
 func (j *ContentRequest) Execute(resp jobs.Response) {
 	switch j.Type {
 	case ContentTypeEnvironment:
@@ -72,10 +72,26 @@ func (j *DeleteContainerRequest) Execute(resp jobs.Response) {
 		log.Printf("delete_container: Unable to remove network links file: %v", err)
 	}
 
+	if err := containers.RemoveHealthCheck(j.Id); err != nil {
+		log.Printf("delete_container: Unable to remove health check: %v", err)
+	}
+
+	if err := containers.RemoveIdlePolicy(j.Id); err != nil {
+		log.Printf("delete_container: Unable to remove idle policy: %v", err)
+	}
+
+	if err := os.RemoveAll(j.Id.ExecAccessBasePath()); err != nil {
+		log.Printf("delete_container: Unable to remove exec access: %v", err)
+	}
+
 	if err := os.RemoveAll(unitDefinitionsPath); err != nil {
 		log.Printf("delete_container: Unable to remove definitions for container: %v", err)
 	}
 
+	if err := os.RemoveAll(j.Id.VersionedEnvironmentsPathFor()); err != nil {
+		log.Printf("delete_container: Unable to remove saved environments for container: %v", err)
+	}
+
 	if err := os.RemoveAll(filepath.Dir(runDirPath)); err != nil {
 		log.Printf("delete_container: Unable to remove run directory: %v", err)
 	}
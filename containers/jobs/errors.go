@@ -19,6 +19,33 @@ var (
 	ErrRestartRequestThrottled = jobs.SimpleError{jobs.ResponseRateLimit, "It has been too soon since the last request to restart or the state is currently changing."}
 	ErrLinkContainersFailed    = jobs.SimpleError{jobs.ResponseError, "Not all links could be set."}
 	ErrDeleteContainerFailed   = jobs.SimpleError{jobs.ResponseError, "Unable to delete the container."}
+	ErrEventsUnavailable       = jobs.SimpleError{jobs.ResponseError, "Unable to listen for container events."}
+	ErrLogsUnavailable         = jobs.SimpleError{jobs.ResponseError, "Unable to read the logs of the container."}
+	ErrEventsMustStream        = jobs.SimpleError{jobs.ResponseNotAcceptable, "Events can only be returned as a stream."}
+	ErrLimitsUpdateFailed      = jobs.SimpleError{jobs.ResponseError, "Unable to change the resource limits of this container."}
+	ErrLimitsRequireForeground = jobs.SimpleError{Failure: jobs.ResponseNotAcceptable, Reason: "The limits of a running container can only be changed when docker runs containers in the foreground."}
+	ErrVolumeNotFound          = jobs.SimpleError{jobs.ResponseNotFound, "The specified volume does not exist."}
+	ErrVolumeInUse             = jobs.SimpleError{jobs.ResponseInvalidRequest, "The volume is mounted by another container."}
+	ErrVolumeUpdateFailed      = jobs.SimpleError{jobs.ResponseError, "Unable to update the volume."}
+	ErrBackupFailed            = jobs.SimpleError{jobs.ResponseError, "Unable to back up the container."}
+	ErrRestoreFailed           = jobs.SimpleError{jobs.ResponseError, "Unable to restore the container."}
+	ErrRestoreInvalidArchive   = jobs.SimpleError{jobs.ResponseInvalidRequest, "The archive is not a valid container backup."}
+	ErrHostResourcesFailed     = jobs.SimpleError{jobs.ResponseError, "Unable to read the resources of this host."}
+	ErrContainerNotRunning     = jobs.SimpleError{jobs.ResponseInvalidRequest, "The container is not running."}
+	ErrExecNotAuthorized       = jobs.SimpleError{jobs.ResponseForbidden, "You are not allowed to run processes in this container."}
+	ErrExecMustStream          = jobs.SimpleError{jobs.ResponseNotAcceptable, "The output of a process can only be returned as a stream."}
+	ErrExecFailed              = jobs.SimpleError{jobs.ResponseError, "Unable to run the process in the container."}
+	ErrExecAccessUpdateFailed  = jobs.SimpleError{jobs.ResponseError, "Unable to change who may run processes in the container."}
+	ErrExecAccessForbidden     = jobs.SimpleError{Failure: jobs.ResponseForbidden, Reason: "Who may run processes in a container can only be changed from the server itself."}
+	ErrHistoryUnavailable      = jobs.SimpleError{jobs.ResponseError, "Unable to read the history of the container."}
+	ErrUnitVersionNotFound     = jobs.SimpleError{jobs.ResponseNotFound, "The container has no definition with that request id."}
+	ErrRollbackFailed          = jobs.SimpleError{jobs.ResponseError, "Unable to roll back the container."}
+	ErrRollbackPortsReserved   = jobs.SimpleError{jobs.ResponseError, "Unable to roll back the container: some ports could not be reserved."}
+	ErrRegistryUpdateFailed    = jobs.SimpleError{jobs.ResponseError, "Unable to change the registry credentials."}
+	ErrIdlePolicyUpdateFailed  = jobs.SimpleError{jobs.ResponseError, "Unable to change the idle policy of the container."}
+	ErrIdleUnsupported         = jobs.SimpleError{jobs.ResponseNotAcceptable, "This server is not able to idle containers."}
+	ErrContainerIdleFailed     = jobs.SimpleError{jobs.ResponseError, "Unable to idle this container."}
+	ErrContainerUnidleFailed   = jobs.SimpleError{jobs.ResponseError, "Unable to unidle this container."}
 
 	ErrContainerCreateFailed              = jobs.SimpleError{jobs.ResponseError, "Unable to create container."}
 	ErrContainerCreateFailedPortsReserved = jobs.SimpleError{jobs.ResponseError, "Unable to create container: some ports could not be reserved."}
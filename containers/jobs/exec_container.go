@@ -0,0 +1,250 @@
+// +build linux
+
+package jobs
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"github.com/openshift/geard/containers"
+	"github.com/openshift/geard/jobs"
+	"github.com/openshift/geard/systemd"
+)
+
+// Processes run until they exit or the client goes away, so they do not
+// occupy a dispatcher worker.
+func (j *ExecContainerRequest) Continuous() bool {
+	return true
+}
+
+func (j *ExecContainerRequest) Cancel() error {
+	j.stop.signal()
+	return nil
+}
+
+func (j *ExecContainerRequest) Execute(resp jobs.Response) {
+	if !resp.StreamResult() {
+		resp.Failure(ErrExecMustStream)
+		return
+	}
+	if err := j.Check(); err != nil {
+		resp.Failure(jobs.SimpleError{jobs.ResponseInvalidRequest, err.Error()})
+		return
+	}
+	if j.User == "" && j.Remote {
+		log.Printf("exec: Refusing to run a process in %s for a remote caller without a user", j.Id)
+		resp.Failure(ErrExecNotAuthorized)
+		return
+	}
+	if !containers.HasExecAccess(j.Id, j.User) {
+		log.Printf("exec: %s is not allowed to run processes in %s", j.User, j.Id)
+		resp.Failure(ErrExecNotAuthorized)
+		return
+	}
+
+	props, err := systemd.Connection().GetUnitProperties(j.Id.UnitNameFor())
+	switch {
+	case systemd.IsNoSuchUnit(err):
+		resp.Failure(ErrContainerNotFound)
+		return
+	case err != nil:
+		log.Printf("exec: Unable to read the state of %s: %v", j.Id, err)
+		resp.Failure(ErrExecFailed)
+		return
+	case props["ActiveState"] != "active":
+		resp.Failure(ErrContainerNotRunning)
+		return
+	}
+
+	args := []string{"--container=" + j.Id.ContainerFor()}
+	for i := range j.Environment {
+		args = append(args, "--env="+j.Environment[i])
+	}
+	args = append(args, "--")
+	args = append(args, j.Command...)
+	cmd := exec.Command(filepath.Join("/", "usr", "bin", "switchns"), args...)
+
+	var stdin io.WriteCloser
+	var terminal *os.File
+	outputs := map[byte]io.Reader{}
+	if j.Tty {
+		master, slave, err := openPty(j.Rows, j.Columns)
+		if err != nil {
+			log.Printf("exec: Unable to allocate a terminal: %v", err)
+			resp.Failure(ErrExecFailed)
+			return
+		}
+		defer master.Close()
+		terminal = slave
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = slave, slave, slave
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true}
+		stdin = master
+		outputs[containers.ExecStdout] = master
+	} else {
+		if stdin, err = cmd.StdinPipe(); err != nil {
+			resp.Failure(ErrExecFailed)
+			return
+		}
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			resp.Failure(ErrExecFailed)
+			return
+		}
+		stderr, err := cmd.StderrPipe()
+		if err != nil {
+			resp.Failure(ErrExecFailed)
+			return
+		}
+		// the process and its children are killed together when canceled
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+		outputs[containers.ExecStdout] = stdout
+		outputs[containers.ExecStderr] = stderr
+	}
+
+	err = cmd.Start()
+	if terminal != nil {
+		// reads from the master end fail once the process no longer holds
+		// the terminal
+		terminal.Close()
+	}
+	if err != nil {
+		log.Printf("exec: Unable to start %v in %s: %v", j.Command, j.Id, err)
+		resp.Failure(ErrExecFailed)
+		return
+	}
+
+	exited := make(chan struct{})
+	defer close(exited)
+	go func() {
+		select {
+		case <-j.stop.done():
+			syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		case <-exited:
+		}
+	}()
+
+	w := containers.NewExecStreamWriter(resp.SuccessWithWrite(jobs.ResponseOk, true, false))
+
+	if j.Stdin != nil {
+		go func() {
+			io.Copy(stdin, j.Stdin)
+			if !j.Tty {
+				stdin.Close()
+			}
+		}()
+	} else if !j.Tty {
+		stdin.Close()
+	}
+
+	wg := sync.WaitGroup{}
+	for stream, r := range outputs {
+		wg.Add(1)
+		go func(stream byte, r io.Reader) {
+			defer wg.Done()
+			io.Copy(w.Stream(stream), r)
+		}(stream, r)
+	}
+	wg.Wait()
+
+	code := exitCodeOf(cmd.Wait())
+	if err := w.WriteExitCode(code); err != nil {
+		log.Printf("exec: Unable to return the exit code of %v in %s: %v", j.Command, j.Id, err)
+	}
+}
+
+func exitCodeOf(err error) int {
+	if err == nil {
+		return 0
+	}
+	if exit, ok := err.(*exec.ExitError); ok {
+		if status, ok := exit.Sys().(syscall.WaitStatus); ok {
+			if status.Signaled() {
+				return 128 + int(status.Signal())
+			}
+			return status.ExitStatus()
+		}
+	}
+	return -1
+}
+
+type winsize struct {
+	Rows    uint16
+	Columns uint16
+	X       uint16
+	Y       uint16
+}
+
+// Open a new pseudo terminal, returning the master and slave ends.
+func openPty(rows, columns uint16) (*os.File, *os.File, error) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	var n uint32
+	if err := ioctl(master.Fd(), syscall.TIOCGPTN, uintptr(unsafe.Pointer(&n))); err != nil {
+		master.Close()
+		return nil, nil, err
+	}
+	var unlock int32
+	if err := ioctl(master.Fd(), syscall.TIOCSPTLCK, uintptr(unsafe.Pointer(&unlock))); err != nil {
+		master.Close()
+		return nil, nil, err
+	}
+	if rows != 0 && columns != 0 {
+		size := winsize{Rows: rows, Columns: columns}
+		if err := ioctl(master.Fd(), syscall.TIOCSWINSZ, uintptr(unsafe.Pointer(&size))); err != nil {
+			master.Close()
+			return nil, nil, err
+		}
+	}
+	slave, err := os.OpenFile(fmt.Sprintf("/dev/pts/%d", n), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		master.Close()
+		return nil, nil, err
+	}
+	return master, slave, nil
+}
+
+func ioctl(fd, request, arg uintptr) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, request, arg); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+func (j *GrantExecAccessRequest) Execute(resp jobs.Response) {
+	if j.Remote {
+		resp.Failure(ErrExecAccessForbidden)
+		return
+	}
+	if _, err := os.Stat(j.Id.UnitPathFor()); err != nil {
+		resp.Failure(ErrContainerNotFound)
+		return
+	}
+	if err := containers.GrantExecAccess(j.Id, j.User); err != nil {
+		log.Printf("exec_access: Unable to allow %s to run processes in %s: %v", j.User, j.Id, err)
+		resp.Failure(ErrExecAccessUpdateFailed)
+		return
+	}
+	resp.Success(jobs.ResponseOk)
+}
+
+func (j *RevokeExecAccessRequest) Execute(resp jobs.Response) {
+	if j.Remote {
+		resp.Failure(ErrExecAccessForbidden)
+		return
+	}
+	if err := containers.RevokeExecAccess(j.Id, j.User); err != nil {
+		log.Printf("exec_access: Unable to remove the access of %s to %s: %v", j.User, j.Id, err)
+		resp.Failure(ErrExecAccessUpdateFailed)
+		return
+	}
+	resp.Success(jobs.ResponseOk)
+}
@@ -47,7 +47,20 @@ func init() {
 	config.AddRequiredDirectory(
 		0750,
 		filepath.Join(config.ContainerBasePath(), "env", "contents"),
+		filepath.Join(config.ContainerBasePath(), "env", "versions"),
 		filepath.Join(config.ContainerBasePath(), "ports", "descriptions"),
 		filepath.Join(config.ContainerBasePath(), "ports", "interfaces"),
+		filepath.Join(config.ContainerBasePath(), "volumes", "descriptions"),
+		filepath.Join(config.ContainerBasePath(), "health", "checks"),
+		filepath.Join(config.ContainerBasePath(), "health", "status"),
+		filepath.Join(config.ContainerBasePath(), "access", "containers", "exec"),
+	)
+	config.AddRequiredDirectory(
+		0700,
+		filepath.Join(config.ContainerBasePath(), "registries"),
+	)
+	config.AddRequiredDirectory(
+		0755,
+		filepath.Join(config.ContainerBasePath(), "volumes", "data"),
 	)
 }
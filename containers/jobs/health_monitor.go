@@ -0,0 +1,149 @@
+// +build linux
+
+package jobs
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/openshift/geard/containers"
+	"github.com/openshift/geard/dispatcher"
+	"github.com/openshift/geard/jobs"
+	"github.com/openshift/geard/systemd"
+)
+
+// The client the health monitor queues restarts as
+const healthMonitorClient = "health-monitor"
+
+// Runs the health checks of the containers on this server, restarting
+// containers that fail their check when the check asks for it.
+type HealthMonitor struct {
+	// How often the installed checks are looked for
+	Resolution time.Duration
+	// Queues restarts so they run in turn with other jobs on the container
+	Dispatcher *dispatcher.Dispatcher
+
+	lock    sync.Mutex
+	running map[containers.Identifier]bool
+	next    map[containers.Identifier]time.Time
+}
+
+var DefaultHealthMonitor = &HealthMonitor{Resolution: time.Second}
+
+// Check the health of containers on this server until the process exits.
+func StartHealthMonitor() error {
+	return DefaultHealthMonitor.Start()
+}
+
+func (m *HealthMonitor) Start() error {
+	if m.Dispatcher == nil {
+		return errors.New("health: A dispatcher is required to restart containers")
+	}
+	m.running = make(map[containers.Identifier]bool)
+	m.next = make(map[containers.Identifier]time.Time)
+	go func() {
+		for now := range time.Tick(m.Resolution) {
+			m.checkDue(now)
+		}
+	}()
+	return nil
+}
+
+func (m *HealthMonitor) checkDue(now time.Time) {
+	ids, err := containers.GetHealthCheckedContainers()
+	if err != nil {
+		log.Printf("health: Unable to list health checks: %v", err)
+		return
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	found := make(map[containers.Identifier]bool)
+	for _, id := range ids {
+		found[id] = true
+		if m.running[id] || now.Before(m.next[id]) {
+			continue
+		}
+		check, err := containers.GetHealthCheck(id)
+		if err != nil {
+			log.Printf("health: Unable to read the health check of %s: %v", id, err)
+			continue
+		}
+		m.running[id] = true
+		m.next[id] = now.Add(time.Duration(check.Interval) * time.Second)
+		go m.check(id, check)
+	}
+	for id := range m.next {
+		if !found[id] {
+			delete(m.next, id)
+		}
+	}
+}
+
+func (m *HealthMonitor) check(id containers.Identifier, check *containers.HealthCheck) {
+	defer func() {
+		m.lock.Lock()
+		delete(m.running, id)
+		m.lock.Unlock()
+	}()
+
+	status, err := containers.GetHealthStatus(id)
+	if err != nil || status == nil {
+		status = &containers.HealthStatus{State: containers.HealthStarting}
+	}
+
+	// stopped containers are checked again once they start
+	props, err := systemd.Connection().GetUnitProperties(id.UnitNameFor())
+	if err != nil || props["ActiveState"] != "active" {
+		if status.State != containers.HealthStarting || status.ConsecutiveFailures != 0 {
+			status.State = containers.HealthStarting
+			status.ConsecutiveFailures = 0
+			m.save(id, status)
+		}
+		return
+	}
+
+	ports, err := containers.GetExistingPorts(id)
+	if err != nil {
+		log.Printf("health: Unable to read the ports of %s: %v", id, err)
+		return
+	}
+	probeErr := check.Probe(id, ports)
+	if status.Record(check, probeErr, time.Now()) {
+		log.Printf("health: %s failed its health check %d times: %v", id, check.FailureThreshold, probeErr)
+		if check.Action == containers.HealthActionRestart {
+			if err := m.restart(id); err != nil {
+				log.Printf("health: Unable to restart %s: %v", id, err)
+			} else {
+				status.Restarts++
+				status.State = containers.HealthStarting
+				status.ConsecutiveFailures = 0
+			}
+		}
+	}
+	m.save(id, status)
+}
+
+// Queue a restart of the container and wait for it to finish.
+func (m *HealthMonitor) restart(id containers.Identifier) error {
+	restarted := &stepResponse{}
+	context := &jobs.JobContext{Id: jobs.NewRequestIdentifier(), Client: healthMonitorClient}
+	done, err := m.Dispatcher.Dispatch(context, &RestartContainerRequest{Id: id}, restarted)
+	if err != nil {
+		return err
+	}
+	<-done
+	return restarted.err
+}
+
+func (m *HealthMonitor) save(id containers.Identifier, status *containers.HealthStatus) {
+	// the check may have been removed while it was running
+	if _, err := containers.GetHealthCheck(id); err != nil {
+		return
+	}
+	if err := containers.SaveHealthStatus(id, status); err != nil {
+		log.Printf("health: Unable to save the health of %s: %v", id, err)
+	}
+}
@@ -0,0 +1,144 @@
+// +build linux
+
+package jobs
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/openshift/geard/containers"
+	"github.com/openshift/geard/jobs"
+	"github.com/openshift/geard/port"
+	"github.com/openshift/geard/systemd"
+	"github.com/openshift/geard/utils"
+)
+
+func (j *ContainerHistoryRequest) ReadOnly() bool {
+	return true
+}
+
+func (j *ContainerHistoryRequest) Execute(resp jobs.Response) {
+	if _, err := os.Stat(j.Id.UnitPathFor()); err != nil {
+		resp.Failure(ErrContainerNotFound)
+		return
+	}
+	versions, err := containers.GetUnitHistory(j.Id)
+	if err != nil {
+		log.Printf("container_history: Unable to read the definitions of %s: %v", j.Id, err)
+		resp.Failure(ErrHistoryUnavailable)
+		return
+	}
+	resp.SuccessWithData(jobs.ResponseOk, &ContainerHistoryResponse{versions})
+}
+
+func (j *RollbackContainerRequest) Execute(resp jobs.Response) {
+	if err := j.Check(); err != nil {
+		resp.Failure(jobs.SimpleError{jobs.ResponseInvalidRequest, err.Error()})
+		return
+	}
+	unitName := j.Id.UnitNameFor()
+	unitPath := j.Id.UnitPathFor()
+	unitVersionPath := j.Id.VersionedUnitPathFor(j.To)
+
+	if _, err := os.Stat(unitPath); err != nil {
+		resp.Failure(ErrContainerNotFound)
+		return
+	}
+
+	// lock the unit to prevent simultaneous updates
+	state, _, err := utils.OpenFileExclusive(unitPath, 0664)
+	if err != nil {
+		log.Print("rollback_container: Unable to lock unit file: ", err)
+		resp.Failure(ErrRollbackFailed)
+		return
+	}
+	defer state.Close()
+
+	version, err := containers.GetUnitVersion(j.Id, j.To)
+	if err != nil {
+		if os.IsNotExist(err) {
+			resp.Failure(ErrUnitVersionNotFound)
+			return
+		}
+		log.Printf("rollback_container: Unable to read definition %s of %s: %v", j.To, j.Id, err)
+		resp.Failure(ErrRollbackFailed)
+		return
+	}
+
+	existing, err := containers.GetExistingPorts(j.Id)
+	if err != nil {
+		log.Printf("rollback_container: Unable to read existing ports: %v", err)
+		resp.Failure(ErrRollbackFailed)
+		return
+	}
+	// the current ports stay reserved until the definition is replaced
+	reserved, unreserve, err := port.ReserveExternalPorts(unitVersionPath, version.Ports, existing)
+	if err != nil {
+		log.Printf("rollback_container: Unable to reserve external ports: %+v", err)
+		resp.Failure(ErrRollbackPortsReserved)
+		return
+	}
+	// the definition names its ports, so none may be reassigned
+	for i := range reserved {
+		if reserved[i].External != version.Ports[i].External {
+			log.Printf("rollback_container: Port %d was reassigned to %d", version.Ports[i].External, reserved[i].External)
+			releaseAddedPorts(reserved, existing)
+			resp.Failure(ErrRollbackPortsReserved)
+			return
+		}
+	}
+
+	restored, err := containers.RestoreEnvironmentVersion(j.Id, version)
+	if err != nil {
+		log.Printf("rollback_container: Unable to restore the environment of %s: %v", j.Id, err)
+		releaseAddedPorts(reserved, existing)
+		resp.Failure(ErrRollbackFailed)
+		return
+	}
+	if !restored && version.EnvironmentPath != "" {
+		log.Printf("rollback_container: No saved environment for %s, using %s as is", j.To, version.EnvironmentPath)
+	}
+
+	if err := utils.AtomicReplaceLink(unitVersionPath, unitPath); err != nil {
+		log.Printf("rollback_container: Failed to activate definition %s: %v", j.To, err)
+		releaseAddedPorts(reserved, existing)
+		resp.Failure(ErrRollbackFailed)
+		return
+	}
+	state.Close()
+	port.ReleaseExternalPorts(unreserve) // Ignore errors
+
+	if err := systemd.EnableAndReloadUnit(systemd.Connection(), unitName, unitPath); err != nil {
+		log.Printf("rollback_container: Could not enable container %s: %v", unitName, err)
+		resp.Failure(ErrRollbackFailed)
+		return
+	}
+	if err := systemd.Connection().RestartUnitJob(unitName, "replace"); err != nil {
+		log.Printf("rollback_container: Could not restart container %s: %v", unitName, err)
+		resp.Failure(ErrContainerRestartFailed)
+		return
+	}
+
+	w := resp.SuccessWithWrite(jobs.ResponseAccepted, true, false)
+	fmt.Fprintf(w, "Container %s rolled back to %s and is restarting\n", j.Id, j.To)
+}
+
+// Release the ports in reserved that were not already held by the
+// container.
+func releaseAddedPorts(reserved, existing port.PortPairs) {
+	added := port.PortPairs{}
+	for i := range reserved {
+		held := false
+		for j := range existing {
+			if existing[j].External == reserved[i].External {
+				held = true
+				break
+			}
+		}
+		if !held {
+			added = append(added, reserved[i])
+		}
+	}
+	port.ReleaseExternalPorts(added)
+}
@@ -0,0 +1,91 @@
+// +build linux
+
+package jobs
+
+import (
+	"bufio"
+	"io/ioutil"
+	"log"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/openshift/geard/config"
+	"github.com/openshift/geard/jobs"
+	"github.com/openshift/go-systemd/dbus"
+)
+
+func (j *HostResourcesRequest) ReadOnly() bool {
+	return true
+}
+
+func (j *HostResourcesRequest) Execute(resp jobs.Response) {
+	r := &HostResourcesResponse{Cpus: runtime.NumCPU(), Labels: config.HostLabels}
+
+	if err := unitsMatching(reContainerUnits, func(name string, unit *dbus.UnitStatus) {
+		if unit.LoadState == "not-found" || unit.LoadState == "masked" {
+			return
+		}
+		r.Containers++
+		if unit.ActiveState == "active" {
+			r.Running++
+		}
+	}); err != nil {
+		log.Printf("host_resources: Unable to list units from systemd: %v", err)
+		resp.Failure(ErrHostResourcesFailed)
+		return
+	}
+
+	total, available, err := readMemoryInfo("/proc/meminfo")
+	if err != nil {
+		log.Printf("host_resources: Unable to read memory usage: %v", err)
+		resp.Failure(ErrHostResourcesFailed)
+		return
+	}
+	r.MemoryTotal, r.MemoryAvailable = total, available
+
+	if load, err := ioutil.ReadFile("/proc/loadavg"); err == nil {
+		if fields := strings.Fields(string(load)); len(fields) > 0 {
+			r.Load, _ = strconv.ParseFloat(fields[0], 64)
+		}
+	}
+
+	resp.SuccessWithData(jobs.ResponseOk, r)
+}
+
+// Return the total and available memory in bytes.  Older kernels do not
+// report MemAvailable, in which case free memory and the page cache are
+// counted as available.
+func readMemoryInfo(path string) (total, available uint64, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	values := make(map[string]uint64)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		value, errp := strconv.ParseUint(fields[1], 10, 64)
+		if errp != nil {
+			continue
+		}
+		values[strings.TrimSuffix(fields[0], ":")] = value * 1024
+	}
+	if err = scanner.Err(); err != nil {
+		return
+	}
+
+	total = values["MemTotal"]
+	if value, ok := values["MemAvailable"]; ok {
+		available = value
+	} else {
+		available = values["MemFree"] + values["Buffers"] + values["Cached"]
+	}
+	return
+}
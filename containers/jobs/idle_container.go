@@ -0,0 +1,127 @@
+// +build linux
+
+package jobs
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	csystemd "github.com/openshift/geard/containers/systemd"
+	"github.com/openshift/geard/dispatcher"
+	"github.com/openshift/geard/jobs"
+	"github.com/openshift/geard/systemd"
+)
+
+func (j *IdleContainerRequest) Priority() dispatcher.Priority {
+	return dispatcher.PriorityHigh
+}
+
+func (j *IdleContainerRequest) Execute(resp jobs.Response) {
+	if !idlerAvailable {
+		resp.Failure(ErrIdleUnsupported)
+		return
+	}
+	unitName := j.Id.UnitNameFor()
+	idlePath := j.Id.IdleUnitPathFor()
+
+	if _, err := os.Stat(j.Id.UnitPathFor()); err != nil {
+		resp.Failure(ErrContainerNotFound)
+		return
+	}
+	if _, err := os.Stat(idlePath); err == nil {
+		w := resp.SuccessWithWrite(jobs.ResponseOk, true, false)
+		fmt.Fprintf(w, "Container %s is idle\n", j.Id)
+		return
+	}
+	// only containers that are meant to be running are woken by traffic
+	started, err := csystemd.UnitStartOnBoot(j.Id)
+	if err != nil {
+		log.Printf("idle_container: Unable to read whether %s is started: %v", j.Id, err)
+		resp.Failure(ErrContainerIdleFailed)
+		return
+	}
+	if !started {
+		resp.Failure(ErrContainerNotRunning)
+		return
+	}
+
+	err = idleUnit(idlePath, func() error {
+		return systemd.Connection().StopUnitJob(unitName, "fail")
+	})
+	if err != nil {
+		log.Printf("idle_container: Could not idle container %s: %v", unitName, err)
+		resp.Failure(ErrContainerIdleFailed)
+		return
+	}
+	queueTrafficFor(j.Id)
+
+	w := resp.SuccessWithWrite(jobs.ResponseAccepted, true, false)
+	fmt.Fprintf(w, "Container %s idled\n", j.Id)
+}
+
+func (j *UnidleContainerRequest) Priority() dispatcher.Priority {
+	return dispatcher.PriorityHigh
+}
+
+func (j *UnidleContainerRequest) Execute(resp jobs.Response) {
+	unitName := j.Id.UnitNameFor()
+	idlePath := j.Id.IdleUnitPathFor()
+
+	if _, err := os.Stat(j.Id.UnitPathFor()); err != nil {
+		resp.Failure(ErrContainerNotFound)
+		return
+	}
+	if _, err := os.Stat(idlePath); os.IsNotExist(err) {
+		w := resp.SuccessWithWrite(jobs.ResponseOk, true, false)
+		fmt.Fprintf(w, "Container %s is not idle\n", j.Id)
+		return
+	}
+
+	err := unidleUnit(idlePath, func() error {
+		return systemd.Connection().StartUnitJob(unitName, "fail")
+	})
+	if err != nil {
+		log.Printf("unidle_container: Could not start container %s: %v", unitName, err)
+		resp.Failure(ErrContainerUnidleFailed)
+		return
+	}
+	acceptTrafficFor(j.Id)
+
+	w := resp.SuccessWithWrite(jobs.ResponseAccepted, true, false)
+	fmt.Fprintf(w, "Container %s starting\n", j.Id)
+}
+
+// Stop a unit with the idle flag in place, since the event listener
+// reports a unit that stops while the flag exists as idled rather than
+// stopped.  The flag is removed if the unit could not be stopped.
+func idleUnit(idlePath string, stop func() error) error {
+	f, err := os.Create(idlePath)
+	if err != nil {
+		return err
+	}
+	f.Close()
+	if err := stop(); err != nil {
+		os.Remove(idlePath)
+		return err
+	}
+	return nil
+}
+
+// Remove the idle flag and start the unit.  If the unit does not start
+// the flag is restored, so the container is still idle and new
+// connections continue to be queued for the idler.
+func unidleUnit(idlePath string, start func() error) error {
+	if err := os.Remove(idlePath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := start(); err != nil {
+		if f, errc := os.Create(idlePath); errc == nil {
+			f.Close()
+		} else {
+			log.Printf("unidle_container: Unable to restore the idle flag %s: %v", idlePath, errc)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+// +build linux
+
+package jobs
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openshift/geard/config"
+	"github.com/openshift/geard/containers"
+	"github.com/openshift/geard/jobs"
+)
+
+type testResponse struct {
+	failure error
+	output  bytes.Buffer
+}
+
+func (r *testResponse) StreamResult() bool                                       { return false }
+func (r *testResponse) Success(t jobs.ResponseSuccess)                           {}
+func (r *testResponse) SuccessWithData(t jobs.ResponseSuccess, data interface{}) {}
+func (r *testResponse) SuccessWithWrite(t jobs.ResponseSuccess, flush, structured bool) io.Writer {
+	return &r.output
+}
+func (r *testResponse) Failure(reason error)                               { r.failure = reason }
+func (r *testResponse) WritePendingSuccess(name string, value interface{}) {}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func TestIdleUnitRemovesFlagWhenStopFails(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	flag := filepath.Join(dir, "ctr-test.idle")
+
+	if err := idleUnit(flag, func() error { return errors.New("stop failed") }); err == nil {
+		t.Fatal("Expected the stop to fail")
+	}
+	if exists(flag) {
+		t.Error("The idle flag should be removed when the unit does not stop")
+	}
+
+	stopped := false
+	if err := idleUnit(flag, func() error {
+		stopped = exists(flag)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if !stopped || !exists(flag) {
+		t.Error("The idle flag should exist while the unit is stopped")
+	}
+}
+
+func TestUnidleUnitRestoresFlagWhenStartFails(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	flag := filepath.Join(dir, "ctr-test.idle")
+	if err := ioutil.WriteFile(flag, []byte{}, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := unidleUnit(flag, func() error { return errors.New("start failed") }); err == nil {
+		t.Fatal("Expected the start to fail")
+	}
+	if !exists(flag) {
+		t.Error("The idle flag should be restored when the unit does not start")
+	}
+
+	if err := unidleUnit(flag, func() error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if exists(flag) {
+		t.Error("The idle flag should be removed when the unit starts")
+	}
+}
+
+func TestIdleJobsWithoutContainer(t *testing.T) {
+	base, err := ioutil.TempDir("", "idle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(base)
+	previous := config.ContainerBasePath()
+	config.SetContainerBasePath(base)
+	defer config.SetContainerBasePath(previous)
+
+	id := containers.Identifier("test")
+	resp := &testResponse{}
+	(&UnidleContainerRequest{Id: id}).Execute(resp)
+	if resp.failure != ErrContainerNotFound {
+		t.Errorf("Expected the container to not be found: %v", resp.failure)
+	}
+
+	// a container that is not idle is left alone
+	if err := ioutil.WriteFile(id.UnitPathFor(), []byte{}, 0644); err != nil {
+		t.Fatal(err)
+	}
+	resp = &testResponse{}
+	(&UnidleContainerRequest{Id: id}).Execute(resp)
+	if resp.failure != nil || resp.output.String() != "Container test is not idle\n" {
+		t.Errorf("Unexpected response: %v %q", resp.failure, resp.output.String())
+	}
+
+	if idlerAvailable {
+		return
+	}
+	resp = &testResponse{}
+	(&IdleContainerRequest{Id: id}).Execute(resp)
+	if resp.failure != ErrIdleUnsupported {
+		t.Errorf("Expected idling to be unsupported without the idler: %v", resp.failure)
+	}
+}
@@ -0,0 +1,30 @@
+// +build linux
+
+package jobs
+
+import (
+	"log"
+	"os"
+
+	"github.com/openshift/geard/containers"
+	"github.com/openshift/geard/jobs"
+)
+
+func (j *SetIdlePolicyRequest) Execute(resp jobs.Response) {
+	if _, err := os.Stat(j.Id.UnitPathFor()); err != nil {
+		resp.Failure(ErrContainerNotFound)
+		return
+	}
+	var err error
+	if j.Policy == nil {
+		err = containers.RemoveIdlePolicy(j.Id)
+	} else {
+		err = containers.SaveIdlePolicy(j.Id, j.Policy)
+	}
+	if err != nil {
+		log.Printf("idle_policy: Unable to change the idle policy of %s: %v", j.Id, err)
+		resp.Failure(ErrIdlePolicyUpdateFailed)
+		return
+	}
+	resp.Success(jobs.ResponseOk)
+}
@@ -0,0 +1,20 @@
+// +build linux,idler
+
+package jobs
+
+import (
+	"github.com/openshift/geard/containers"
+	"github.com/openshift/geard/idler/iptables"
+)
+
+const idlerAvailable = true
+
+// Send new connections to the container to the idler, which starts the
+// container when they arrive.
+func queueTrafficFor(id containers.Identifier) {
+	iptables.IdleContainer(id, iptables.HostIp())
+}
+
+func acceptTrafficFor(id containers.Identifier) {
+	iptables.UnidleContainer(id, iptables.HostIp())
+}
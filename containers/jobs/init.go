@@ -3,13 +3,16 @@
 package jobs
 
 import (
+	"bytes"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
 	"syscall"
 
 	"github.com/openshift/geard/config"
+	"github.com/openshift/geard/containers"
 	csystemd "github.com/openshift/geard/containers/systemd"
 	"github.com/openshift/geard/systemd"
 )
@@ -46,23 +49,67 @@ func initializeTargets() error {
 	return nil
 }
 
+// The limits applied to each container slice.  Containers with a memory
+// limit are placed in the limited slice, which has none of its own.
+var defaultSliceLimits = map[string]containers.ResourceLimits{
+	"container":             {},
+	containers.SmallSlice:   {Memory: 512 * 1024 * 1024},
+	containers.LimitedSlice: {},
+}
+
+// Slices are rewritten when their limits change, since hosts that were
+// installed with older limits would otherwise keep them.  The memory
+// limit of a rewritten slice is also applied to the running slice.
 func initializeSlices() error {
 	for _, name := range []string{
 		"container",
-		"container-small",
+		containers.SmallSlice,
+		containers.LimitedSlice,
 	} {
 		parent := "container"
 		if name == "container" {
 			parent = ""
 		}
 
-		if err := systemd.InitializeSystemdFile(systemd.SliceType, name, csystemd.SliceUnitTemplate, csystemd.SliceUnit{name, parent}, false); err != nil {
+		limits := defaultSliceLimits[name]
+		path := filepath.Join(config.ContainerBasePath(), "slices", name+".slice")
+		changed, err := writeSliceFile(path, csystemd.SliceUnit{name, parent, limits})
+		if err != nil {
+			return err
+		}
+		if !changed {
+			continue
+		}
+		if err := systemd.EnableAndReloadUnit(systemd.Connection(), name+".slice", path); err != nil {
 			return err
 		}
+		if err := systemd.Connection().SetUnitProperties(name+".slice", true, limitProperties(containers.ResourceLimits{Memory: 1}, limits)...); err != nil {
+			log.Printf("init: Unable to apply the memory limit of %s: %v", name, err)
+		}
 	}
 	return nil
 }
 
+// Write the slice unit if its contents differ from those at path, and
+// return whether it was written.
+func writeSliceFile(path string, slice csystemd.SliceUnit) (bool, error) {
+	var buf bytes.Buffer
+	if err := csystemd.SliceUnitTemplate.Execute(&buf, slice); err != nil {
+		return false, err
+	}
+	if existing, err := ioutil.ReadFile(path); err == nil && bytes.Equal(existing, buf.Bytes()) {
+		return false, nil
+	}
+	if err := ioutil.WriteFile(path+".tmp", buf.Bytes(), 0666); err != nil {
+		return false, err
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		os.Remove(path + ".tmp")
+		return false, err
+	}
+	return true, nil
+}
+
 func isSystemdFile(filePath string) bool {
 	extention := filepath.Ext(filePath)
 	systemdExts := []string{".slice", ".service", ".socket", ".target"}
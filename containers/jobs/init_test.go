@@ -0,0 +1,63 @@
+// +build linux
+
+package jobs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/openshift/geard/containers"
+	csystemd "github.com/openshift/geard/containers/systemd"
+)
+
+// The container slice written before slices had their own limits.
+const upgradedContainerSlice = `
+[Unit]
+Description=Container slice container
+
+[Slice]
+CPUAccounting=yes
+MemoryAccounting=yes
+MemoryLimit=512M
+
+
+[Install]
+WantedBy=container.target container-active.target
+`
+
+func TestWriteSliceFileReplacesOldLimits(t *testing.T) {
+	dir, err := ioutil.TempDir("", "slices")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "container.slice")
+	if err := ioutil.WriteFile(path, []byte(upgradedContainerSlice), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	slice := csystemd.SliceUnit{Name: "container", Limits: defaultSliceLimits["container"]}
+	changed, err := writeSliceFile(path, slice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := ioutil.ReadFile(path)
+	if !changed || strings.Contains(string(data), "MemoryLimit") {
+		t.Fatalf("Expected the memory limit of the container slice to be removed: %s", data)
+	}
+
+	if changed, err := writeSliceFile(path, slice); err != nil || changed {
+		t.Errorf("An unchanged slice should not be written again: %v", err)
+	}
+
+	small := filepath.Join(dir, containers.SmallSlice+".slice")
+	if changed, err := writeSliceFile(small, csystemd.SliceUnit{Name: containers.SmallSlice, Parent: "container", Limits: defaultSliceLimits[containers.SmallSlice]}); err != nil || !changed {
+		t.Fatalf("Expected a missing slice to be written: %v", err)
+	}
+	if data, _ := ioutil.ReadFile(small); !strings.Contains(string(data), "MemoryLimit=") || !strings.Contains(string(data), "Slice=container") {
+		t.Errorf("Unexpected small slice: %s", data)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
@@ -55,6 +56,22 @@ func (req *InstallContainerRequest) Execute(resp jobs.Response) {
 		}
 	}
 
+	// fail before anything is written if the image cannot be pulled
+	if req.Pull {
+		if err := pullImage(req.DockerSocket, req.Image, ioutil.Discard); err != nil {
+			log.Printf("install_container: Unable to pull %s: %v", req.Image, err)
+			resp.Failure(imagePullError(req.Image, err))
+			return
+		}
+	}
+
+	// check that the volumes exist and may be mounted by this container
+	volumeSpec, errv := dockerVolumeSpec(id, req.Volumes)
+	if errv != nil {
+		resp.Failure(errv)
+		return
+	}
+
 	// open and lock the base path (to prevent simultaneous updates)
 	state, exists, err := utils.OpenFileExclusive(unitPath, 0664)
 	if err != nil {
@@ -111,6 +128,9 @@ func (req *InstallContainerRequest) Execute(resp jobs.Response) {
 			return
 		}
 		environmentPath = env.Id.EnvironmentPathFor()
+		if errs := containers.SaveEnvironmentVersion(id, req.RequestIdentifier.String(), environmentPath); errs != nil {
+			log.Printf("install_container: Unable to save a copy of the environment: %v", errs)
+		}
 	}
 
 	// write the network links (if any) to disk
@@ -121,7 +141,23 @@ func (req *InstallContainerRequest) Execute(resp jobs.Response) {
 		}
 	}
 
-	slice := "container-small"
+	// a new definition resets the health of the container
+	if err := containers.RemoveHealthCheck(id); err != nil {
+		log.Printf("install_container: Unable to remove the existing health check: %v", err)
+	}
+	if req.HealthCheck != nil {
+		check := req.HealthCheck.WithDefaults()
+		if errw := containers.SaveHealthCheck(id, &check); errw != nil {
+			log.Printf("install_container: Unable to write the health check: %v", errw)
+			resp.Failure(ErrContainerCreateFailed)
+			return
+		}
+	}
+
+	slice := containers.SmallSlice
+	if req.Limits != nil {
+		slice = req.Limits.Slice()
+	}
 
 	// write the definition unit file
 	args := csystemd.ContainerUnit{
@@ -144,8 +180,14 @@ func (req *InstallContainerRequest) Execute(resp jobs.Response) {
 		SocketUnitName:       socketUnitName,
 		SocketActivationType: socketActivationType,
 
+		Volumes:    req.Volumes,
+		VolumeSpec: volumeSpec,
+
 		DockerFeatures: config.SystemDockerFeatures,
 	}
+	if req.Limits != nil {
+		args.Limits = *req.Limits
+	}
 
 	var templateName string
 	switch {
@@ -178,6 +220,17 @@ func (req *InstallContainerRequest) Execute(resp jobs.Response) {
 	}
 	state.Close()
 
+	// the policy applies to the new definition, so it is only written once
+	// that is in place - an install without a policy keeps the one set
+	// with 'gear idle-policy'
+	if req.IdlePolicy != nil {
+		if errw := containers.SaveIdlePolicy(id, req.IdlePolicy); errw != nil {
+			log.Printf("install_container: Unable to write the idle policy: %v", errw)
+			resp.Failure(ErrContainerCreateFailed)
+			return
+		}
+	}
+
 	// write whether this container should be started on next boot
 	if req.Started {
 		if errs := csystemd.SetUnitStartOnBoot(id, true); errs != nil {
@@ -202,20 +255,22 @@ func (req *InstallContainerRequest) Execute(resp jobs.Response) {
 	}
 
 	if req.Started {
+		// Start the socket file, not the service, when socket activated
+		startName := unitName
 		if req.SocketActivation {
-			// Start the socket file, not the service and ignore failures
-			if err := systemd.Connection().StartUnitJob(socketUnitName, "replace"); err != nil {
-				log.Printf("install_container: Could not start container socket %s: %v", socketUnitName, err)
-				resp.Failure(ErrContainerCreateFailed)
-				return
-			}
-		} else {
-			if err := systemd.Connection().StartUnitJob(unitName, "replace"); err != nil {
-				log.Printf("install_container: Could not start container %s: %v", unitName, err)
-				resp.Failure(ErrContainerCreateFailed)
-				return
-			}
-
+			startName = socketUnitName
+		}
+		started, err := req.cancel.start(func() error {
+			return systemd.Connection().StartUnitJob(startName, "replace")
+		})
+		if !started {
+			resp.Failure(jobs.ErrJobCanceled)
+			return
+		}
+		if err != nil {
+			log.Printf("install_container: Could not start container %s: %v", startName, err)
+			resp.Failure(ErrContainerCreateFailed)
+			return
 		}
 	}
 
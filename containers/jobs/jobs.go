@@ -5,14 +5,40 @@
 package jobs
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"net/url"
+	"strings"
+	"sync"
 
 	"github.com/openshift/geard/containers"
+	csystemd "github.com/openshift/geard/containers/systemd"
 	"github.com/openshift/geard/jobs"
 	"github.com/openshift/geard/port"
+	"github.com/openshift/geard/systemd"
 )
 
+// The docker socket of the server, which is not recorded with requests and
+// is set by the daemon before unfinished requests are replayed.
+var ReplayDockerSocket string
+
+// Signals a job that runs until it is canceled to stop.
+type stopSignal struct {
+	lock    sync.Mutex
+	stopped bool
+	ch      chan struct{}
+}
+
+// Records whether a job has been canceled and whether it has started a
+// systemd unit that must be stopped on cancellation.
+type cancelState struct {
+	lock     sync.Mutex
+	canceled bool
+	started  bool
+}
+
 // Installing a Container
 //
 // This job will install a given container definition as a systemd service unit,
@@ -73,9 +99,20 @@ type InstallContainerRequest struct {
 	Ports        port.PortPairs
 	Environment  *containers.EnvironmentDescription
 	NetworkLinks *containers.NetworkLinks
+	Limits       *containers.ResourceLimits `json:"Limits,omitempty"`
+	Volumes      containers.VolumeMounts    `json:"Volumes,omitempty"`
+	HealthCheck  *containers.HealthCheck    `json:"HealthCheck,omitempty"`
+	IdlePolicy   *containers.IdlePolicy     `json:"IdlePolicy,omitempty"`
 
 	// Should the container be started by default
 	Started bool
+	// Pull the image before the container is installed, failing the
+	// install if it cannot be pulled
+	Pull bool `json:",omitempty"`
+
+	DockerSocket string `json:"-"`
+
+	cancel cancelState
 }
 
 func (req *InstallContainerRequest) Check() error {
@@ -101,6 +138,29 @@ func (req *InstallContainerRequest) Check() error {
 			return err
 		}
 	}
+	if req.Limits != nil {
+		if err := req.Limits.Check(); err != nil {
+			return err
+		}
+	}
+	if err := req.Volumes.Check(); err != nil {
+		return err
+	}
+	if req.HealthCheck != nil {
+		if err := req.HealthCheck.Check(); err != nil {
+			return err
+		}
+		if req.HealthCheck.Type != containers.HealthCheckExec {
+			if _, found := req.Ports.Find(req.HealthCheck.Port); !found {
+				return errors.New(fmt.Sprintf("The health check port %d must be one of the container ports", req.HealthCheck.Port))
+			}
+		}
+	}
+	if req.IdlePolicy != nil {
+		if err := req.IdlePolicy.Check(); err != nil {
+			return err
+		}
+	}
 	if req.Ports == nil {
 		req.Ports = make([]port.PortPair, 0)
 	}
@@ -126,6 +186,17 @@ type RestartContainerRequest struct {
 	Id containers.Identifier
 }
 
+// Stop a running container until traffic arrives for it.  Requires a
+// server built with the idler.
+type IdleContainerRequest struct {
+	Id containers.Identifier
+}
+
+// Start an idled container and stop queueing its traffic.
+type UnidleContainerRequest struct {
+	Id containers.Identifier
+}
+
 type BuildImageRequest struct {
 	Name         string
 	Source       string
@@ -135,6 +206,8 @@ type BuildImageRequest struct {
 	Clean        bool
 	Verbose      bool
 	CallbackUrl  string
+
+	cancel cancelState
 }
 
 func (e *BuildImageRequest) Check() error {
@@ -156,8 +229,41 @@ func (e *BuildImageRequest) Check() error {
 	return nil
 }
 
+// Stream container lifecycle events until the request is canceled.  If
+// Ids or Types are set only matching events are returned.
+type ContainerEventsRequest struct {
+	Ids   []containers.Identifier
+	Types []string
+
+	stop stopSignal
+}
+
+func (req *ContainerEventsRequest) Check() error {
+	for i := range req.Types {
+		if _, err := csystemd.NewEventType(req.Types[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Return the journal entries of one or more containers on a server,
+// merged in the order they were written.  Entries are prefixed with the
+// container they came from when more than one is requested.
 type ContainerLogRequest struct {
-	Id containers.Identifier
+	Ids []containers.Identifier
+	systemd.LogOptions
+	// Return each entry as a JSON document of its journal fields
+	Json bool
+
+	stop stopSignal
+}
+
+func (req *ContainerLogRequest) Check() error {
+	if len(req.Ids) == 0 {
+		return errors.New("At least one container must be specified.")
+	}
+	return req.LogOptions.Check()
 }
 
 type ContainerPortsRequest struct {
@@ -192,10 +298,224 @@ type PatchEnvironmentRequest struct {
 	containers.EnvironmentDescription
 }
 
+// Change the resource limits of an installed container.  Only the
+// non-zero limits are changed - they are applied to the running unit
+// and written to the unit file.
+type PatchContainerLimitsRequest struct {
+	jobs.RequestIdentifier `json:"-"`
+
+	Id containers.Identifier `json:"-"`
+	containers.ResourceLimits
+}
+
+func (req *PatchContainerLimitsRequest) Check() error {
+	if len(req.RequestIdentifier) == 0 {
+		return errors.New("A request identifier is required to change the limits of a container.")
+	}
+	if req.ResourceLimits.Empty() {
+		return errors.New("At least one resource limit must be specified.")
+	}
+	return req.ResourceLimits.Check()
+}
+
+// List the definitions a container has been installed or updated with.
+type ContainerHistoryRequest struct {
+	Id containers.Identifier
+}
+
+type ContainerHistoryResponse struct {
+	Versions containers.UnitVersions
+}
+
+// Reinstate a previous definition of a container, along with the
+// environment and ports it was installed with, and restart it.
+type RollbackContainerRequest struct {
+	Id containers.Identifier
+	// The request id of the definition to return to
+	To string
+}
+
+func (req *RollbackContainerRequest) Check() error {
+	return containers.CheckUnitVersion(req.To)
+}
+
+type PutVolumeRequest struct {
+	containers.Volume
+}
+
+type DeleteVolumeRequest struct {
+	Id containers.Identifier
+}
+
+// Stream a gzipped tar archive of the data volumes and home directory of
+// a container, preceded by a manifest that describes how to install it.
+type BackupContainerRequest struct {
+	Id containers.Identifier
+	// Stop the container while the archive is written and start it again
+	// afterwards
+	Quiesce bool
+	// Only write the manifest that describes the container, without its
+	// data
+	ManifestOnly bool
+
+	DockerSocket string `json:"-"`
+}
+
+// Install a container from an archive written by a backup and restore
+// its data.  The container is started once restored if Started is set,
+// and is otherwise left stopped.
+type RestoreContainerRequest struct {
+	jobs.RequestIdentifier `json:"-"`
+
+	Id      containers.Identifier
+	Archive io.Reader `json:"-"`
+	Started bool
+
+	DockerSocket string `json:"-"`
+}
+
+func (req *RestoreContainerRequest) Check() error {
+	if len(req.RequestIdentifier) == 0 {
+		return errors.New("A request identifier is required to restore a container.")
+	}
+	if req.Archive == nil {
+		return errors.New("An archive must be provided to restore a container.")
+	}
+	return nil
+}
+
+func (j *RestoreContainerRequest) PortMappingsFrom(pending map[string]interface{}) (port.PortPairs, bool) {
+	p, ok := pending[PendingPortMappingName].(port.PortPairs)
+	return p, ok
+}
+
+// Run a process inside a running container, forwarding Stdin to it and
+// streaming its output and exit code back as frames (see
+// containers.ExecStreamWriter).
+type ExecContainerRequest struct {
+	Id      containers.Identifier
+	Command []string
+	// Variables in KEY=VALUE form set for the process
+	Environment []string
+	// Allocate a terminal for the process, combining its output
+	Tty     bool
+	Rows    uint16
+	Columns uint16
+
+	Stdin io.Reader `json:"-"`
+	// The user the request is made on behalf of, which must have been
+	// granted access to the container
+	User string `json:"-"`
+	// Made from another host - only the administrator of the server may
+	// run processes without a user
+	Remote bool `json:"-"`
+
+	stop stopSignal
+}
+
+func (req *ExecContainerRequest) Check() error {
+	if len(req.Command) == 0 {
+		return errors.New("A command must be specified.")
+	}
+	if err := containers.CheckExecEnvironment(req.Environment); err != nil {
+		return err
+	}
+	if !req.Tty && (req.Rows != 0 || req.Columns != 0) {
+		return errors.New("A terminal size may only be set when a terminal is allocated.")
+	}
+	return nil
+}
+
+// Allow a user to run processes in a container.  Access may only be
+// changed from the server itself.
+type GrantExecAccessRequest struct {
+	Id   containers.Identifier
+	User string
+	// Set when the request came from another host or on behalf of a user
+	Remote bool `json:"-"`
+}
+
+func (req *GrantExecAccessRequest) Check() error {
+	return containers.CheckExecUser(req.User)
+}
+
+type RevokeExecAccessRequest struct {
+	Id     containers.Identifier
+	User   string
+	Remote bool `json:"-"`
+}
+
+func (req *RevokeExecAccessRequest) Check() error {
+	return containers.CheckExecUser(req.User)
+}
+
+// Change when the idler may stop a container.  A nil policy returns the
+// container to the default timeout of the idler.
+type SetIdlePolicyRequest struct {
+	Id     containers.Identifier
+	Policy *containers.IdlePolicy
+}
+
+func (req *SetIdlePolicyRequest) Check() error {
+	if req.Policy != nil {
+		return req.Policy.Check()
+	}
+	return nil
+}
+
 type LinkContainersRequest struct {
 	*containers.ContainerLinks
 }
 
+// Pull an image from its registry, using the credentials stored for the
+// registry if there are any.
+type PullImageRequest struct {
+	Image string
+
+	DockerSocket string `json:"-"`
+}
+
+func (req *PullImageRequest) Check() error {
+	if req.Image == "" || strings.ContainsAny(req.Image, " \t\n") {
+		return errors.New("An image name without whitespace is required.")
+	}
+	return nil
+}
+
+// Returned with a failure to pull an image.
+type ImagePullFailure struct {
+	Image  string
+	Reason string
+}
+
+type PutRegistryCredentialsRequest struct {
+	containers.RegistryCredentials
+}
+
+// Only the registry is recorded when the request is serialized, so the
+// password never reaches the job journal.
+func (req *PutRegistryCredentialsRequest) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct{ Registry string }{req.Registry})
+}
+
+// The dispatcher logs each job it starts, which must not include the
+// password.
+func (req *PutRegistryCredentialsRequest) String() string {
+	return fmt.Sprintf("{Registry:%s Username:%s}", req.Registry, req.Username)
+}
+
+func (req *PutRegistryCredentialsRequest) GoString() string {
+	return req.String()
+}
+
+type DeleteRegistryCredentialsRequest struct {
+	Registry string
+}
+
+func (req *DeleteRegistryCredentialsRequest) Check() error {
+	return containers.CheckRegistry(req.Registry)
+}
+
 type ListImagesRequest struct {
 	DockerSocket string
 }
@@ -214,6 +534,8 @@ type ContainerUnitResponse struct {
 	UnitResponse
 	LoadState string
 	JobType   string `json:"JobType,omitempty"`
+	// The result of the health check, if the container has one
+	Health string `json:"Health,omitempty"`
 	// Used by consumers
 	Server string `json:"Server,omitempty"`
 }
@@ -223,6 +545,24 @@ type ListContainersResponse struct {
 	Containers ContainerUnitResponses
 }
 
+// Report the capacity and usage of the host, used to decide where
+// containers are placed.
+type HostResourcesRequest struct{}
+
+type HostResourcesResponse struct {
+	// The number of containers installed and running
+	Containers int
+	Running    int
+	// Memory in bytes
+	MemoryTotal     uint64
+	MemoryAvailable uint64
+	// The number of CPUs and the one minute load average
+	Cpus int
+	Load float64
+	// The labels the server was started with
+	Labels map[string]string `json:"Labels,omitempty"`
+}
+
 type ListBuildsRequest struct{}
 type ListBuildsResponse struct {
 	Builds UnitResponses
@@ -235,6 +575,8 @@ type RunContainerRequest struct {
 	Image     string
 	Command   string
 	Arguments []string
+
+	cancel cancelState
 }
 
 func (e *RunContainerRequest) Check() error {
@@ -0,0 +1,19 @@
+package jobs
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/openshift/geard/containers"
+)
+
+func TestPutRegistryCredentialsPasswordIsNotLogged(t *testing.T) {
+	req := &PutRegistryCredentialsRequest{containers.RegistryCredentials{Registry: "registry.example.com", Username: "alice", Password: "topsecret"}}
+	for _, format := range []string{"%v", "%+v", "%#v", "%s"} {
+		s := fmt.Sprintf(format, req)
+		if !strings.Contains(s, "registry.example.com") || strings.Contains(s, "topsecret") {
+			t.Errorf("Unexpected output for %s: %s", format, s)
+		}
+	}
+}
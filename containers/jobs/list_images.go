@@ -10,6 +10,10 @@ import (
 	"github.com/openshift/geard/jobs"
 )
 
+func (j *ListImagesRequest) ReadOnly() bool {
+	return true
+}
+
 func (j *ListImagesRequest) Execute(resp jobs.Response) {
 	// TODO: config item for docker port
 	dockerClient, err := docker.NewClient(j.DockerSocket)
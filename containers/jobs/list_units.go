@@ -31,6 +31,10 @@ func unitsMatching(re *regexp.Regexp, found func(name string, unit *dbus.UnitSta
 
 var reContainerUnits = regexp.MustCompile("\\A" + regexp.QuoteMeta(containers.IdentifierPrefix) + "([^\\.]+)\\.service\\z")
 
+func (j *ListContainersRequest) ReadOnly() bool {
+	return true
+}
+
 func (j *ListContainersRequest) Execute(resp jobs.Response) {
 	r := &ListContainersResponse{make(ContainerUnitResponses, 0)}
 
@@ -38,6 +42,10 @@ func (j *ListContainersRequest) Execute(resp jobs.Response) {
 		if unit.LoadState == "not-found" || unit.LoadState == "masked" {
 			return
 		}
+		health := ""
+		if status, err := containers.GetHealthStatus(containers.Identifier(name)); err == nil && status != nil {
+			health = status.State
+		}
 		r.Containers = append(r.Containers, ContainerUnitResponse{
 			UnitResponse{
 				name,
@@ -46,6 +54,7 @@ func (j *ListContainersRequest) Execute(resp jobs.Response) {
 			},
 			unit.LoadState,
 			unit.JobType,
+			health,
 			"",
 		})
 	}); err != nil {
@@ -60,6 +69,10 @@ func (j *ListContainersRequest) Execute(resp jobs.Response) {
 
 var reBuildUnits = regexp.MustCompile("\\Abuild-([^\\.]+)\\.service\\z")
 
+func (j *ListBuildsRequest) ReadOnly() bool {
+	return true
+}
+
 func (j *ListBuildsRequest) Execute(resp jobs.Response) {
 	r := ListBuildsResponse{make(UnitResponses, 0)}
 
@@ -0,0 +1,45 @@
+// +build linux
+
+package jobs
+
+import (
+	"log"
+	"sort"
+
+	"github.com/openshift/geard/metrics"
+	"github.com/openshift/geard/systemd"
+	"github.com/openshift/go-systemd/dbus"
+)
+
+func init() {
+	metrics.AddCollector(metrics.CollectorFunc(collectContainerStates))
+}
+
+// Count the installed containers in each systemd active state.
+func collectContainerStates() []metrics.Family {
+	if systemd.Connection() == nil {
+		return []metrics.Family{}
+	}
+	counts := make(map[string]int)
+	if err := unitsMatching(reContainerUnits, func(name string, unit *dbus.UnitStatus) {
+		if unit.LoadState == "not-found" || unit.LoadState == "masked" {
+			return
+		}
+		counts[unit.ActiveState]++
+	}); err != nil {
+		log.Printf("metrics: Unable to list units from systemd: %v", err)
+		return []metrics.Family{}
+	}
+
+	states := make([]string, 0, len(counts))
+	for state := range counts {
+		states = append(states, state)
+	}
+	sort.Strings(states)
+
+	f := metrics.Family{Name: "geard_containers", Help: "Installed containers by systemd active state.", Type: metrics.GaugeType}
+	for _, state := range states {
+		f.Samples = append(f.Samples, metrics.Sample{Labels: metrics.Labels{"state": state}, Value: float64(counts[state])})
+	}
+	return []metrics.Family{f}
+}
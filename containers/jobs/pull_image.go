@@ -0,0 +1,93 @@
+// +build linux
+
+package jobs
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/fsouza/go-dockerclient"
+	"github.com/openshift/geard/containers"
+	"github.com/openshift/geard/jobs"
+)
+
+func (j *PullImageRequest) Execute(resp jobs.Response) {
+	if err := j.Check(); err != nil {
+		resp.Failure(jobs.SimpleError{jobs.ResponseInvalidRequest, err.Error()})
+		return
+	}
+
+	output := &pullOutput{resp: resp}
+	if err := pullImage(j.DockerSocket, j.Image, output); err != nil {
+		log.Printf("pull_image: Unable to pull %s: %v", j.Image, err)
+		if !output.started() {
+			resp.Failure(imagePullError(j.Image, err))
+			return
+		}
+		fmt.Fprintf(output, "Error: %s\n", err.Error())
+		return
+	}
+	fmt.Fprintf(output.success(), "Pulled %s\n", j.Image)
+}
+
+// Pull an image with the credentials stored for its registry, writing the
+// progress reported by docker to w.
+func pullImage(socket, image string, w io.Writer) error {
+	client, err := docker.NewClient(socket)
+	if err != nil {
+		return err
+	}
+	auth := docker.AuthConfiguration{}
+	credentials, err := containers.GetRegistryCredentials(containers.RegistryForImage(image))
+	if err != nil {
+		return err
+	}
+	if credentials != nil {
+		auth = docker.AuthConfiguration{Username: credentials.Username, Password: credentials.Password, Email: credentials.Email}
+	}
+	repository, tag := containers.ParseImageName(image)
+	return client.PullImage(docker.PullImageOptions{Repository: repository, Tag: tag, OutputStream: w}, auth)
+}
+
+func imagePullError(image string, err error) jobs.JobError {
+	failure := jobs.ResponseError
+	if err == docker.ErrNoSuchImage || strings.Contains(strings.ToLower(err.Error()), "not found") {
+		failure = jobs.ResponseNotFound
+	}
+	return jobs.StructuredJobError{
+		jobs.SimpleError{failure, fmt.Sprintf("Unable to pull the image %s: %s", image, err.Error())},
+		ImagePullFailure{Image: image, Reason: err.Error()},
+	}
+}
+
+// Docker reports the status of a pull before it knows whether the image
+// exists, so output is held until the first progress update (a line
+// ending in a carriage return) and a pull that fails before then is
+// still returned as a failure of the job.
+type pullOutput struct {
+	resp    jobs.Response
+	pending bytes.Buffer
+	w       io.Writer
+}
+
+func (p *pullOutput) started() bool {
+	return p.w != nil
+}
+
+func (p *pullOutput) success() io.Writer {
+	if p.w == nil {
+		p.w = p.resp.SuccessWithWrite(jobs.ResponseOk, true, false)
+		p.pending.WriteTo(p.w)
+	}
+	return p.w
+}
+
+func (p *pullOutput) Write(b []byte) (int, error) {
+	if p.w == nil && !bytes.Contains(b, []byte("\r")) {
+		return p.pending.Write(b)
+	}
+	return p.success().Write(b)
+}
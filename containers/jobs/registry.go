@@ -0,0 +1,32 @@
+// +build linux
+
+package jobs
+
+import (
+	"log"
+
+	"github.com/openshift/geard/containers"
+	"github.com/openshift/geard/jobs"
+)
+
+func (j *PutRegistryCredentialsRequest) Execute(resp jobs.Response) {
+	if err := j.Check(); err != nil {
+		resp.Failure(jobs.SimpleError{jobs.ResponseInvalidRequest, err.Error()})
+		return
+	}
+	if err := containers.SaveRegistryCredentials(&j.RegistryCredentials); err != nil {
+		log.Printf("registry: Unable to save the credentials for %s: %v", j.Registry, err)
+		resp.Failure(ErrRegistryUpdateFailed)
+		return
+	}
+	resp.Success(jobs.ResponseOk)
+}
+
+func (j *DeleteRegistryCredentialsRequest) Execute(resp jobs.Response) {
+	if err := containers.RemoveRegistryCredentials(j.Registry); err != nil {
+		log.Printf("registry: Unable to remove the credentials for %s: %v", j.Registry, err)
+		resp.Failure(ErrRegistryUpdateFailed)
+		return
+	}
+	resp.Success(jobs.ResponseOk)
+}
@@ -0,0 +1,41 @@
+// +build linux
+
+package jobs
+
+import (
+	"encoding/json"
+
+	"github.com/openshift/geard/dispatcher"
+	"github.com/openshift/geard/jobs"
+)
+
+// Container changes that converge on a desired state may safely be run
+// again if the server stops before they complete.
+func init() {
+	dispatcher.AddReplayableJob(&InstallContainerRequest{}, func(id jobs.RequestIdentifier, data []byte) (interface{}, error) {
+		req := &InstallContainerRequest{}
+		if err := json.Unmarshal(data, req); err != nil {
+			return nil, err
+		}
+		req.RequestIdentifier = id
+		req.DockerSocket = ReplayDockerSocket
+		return req, nil
+	})
+	addReplayable(&StartedContainerStateRequest{}, func() interface{} { return &StartedContainerStateRequest{} })
+	addReplayable(&StoppedContainerStateRequest{}, func() interface{} { return &StoppedContainerStateRequest{} })
+	addReplayable(&RestartContainerRequest{}, func() interface{} { return &RestartContainerRequest{} })
+	addReplayable(&DeleteContainerRequest{}, func() interface{} { return &DeleteContainerRequest{} })
+	addReplayable(&PutEnvironmentRequest{}, func() interface{} { return &PutEnvironmentRequest{} })
+	addReplayable(&PatchEnvironmentRequest{}, func() interface{} { return &PatchEnvironmentRequest{} })
+	addReplayable(&LinkContainersRequest{}, func() interface{} { return &LinkContainersRequest{} })
+}
+
+func addReplayable(job jobs.Job, f func() interface{}) {
+	dispatcher.AddReplayableJob(job, func(id jobs.RequestIdentifier, data []byte) (interface{}, error) {
+		req := f()
+		if err := json.Unmarshal(data, req); err != nil {
+			return nil, err
+		}
+		return req, nil
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"io"
 	"sort"
 	"text/tabwriter"
+	"time"
 )
 
 func (c UnitResponses) Less(a, b int) bool {
@@ -36,12 +37,12 @@ func (r *ListContainersResponse) Sort() {
 
 func (l *ListContainersResponse) WriteTableTo(w io.Writer) error {
 	tw := tabwriter.NewWriter(w, 8, 4, 1, ' ', tabwriter.DiscardEmptyColumns)
-	if _, err := fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", "ID", "ACTIVE", "SUB", "LOAD", "TYPE"); err != nil {
+	if _, err := fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", "ID", "ACTIVE", "SUB", "LOAD", "TYPE", "HEALTH"); err != nil {
 		return err
 	}
 	for i := range l.Containers {
 		container := &l.Containers[i]
-		if _, err := fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", container.Id, container.ActiveState, container.SubState, container.LoadState, container.JobType); err != nil {
+		if _, err := fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", container.Id, container.ActiveState, container.SubState, container.LoadState, container.JobType, container.Health); err != nil {
 			return err
 		}
 	}
@@ -55,12 +56,31 @@ type ListServerContainersResponse struct {
 
 func (l *ListServerContainersResponse) WriteTableTo(w io.Writer) error {
 	tw := tabwriter.NewWriter(w, 8, 4, 1, ' ', tabwriter.DiscardEmptyColumns)
-	if _, err := fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", "ID", "SERVER", "ACTIVE", "SUB", "LOAD", "TYPE"); err != nil {
+	if _, err := fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", "ID", "SERVER", "ACTIVE", "SUB", "LOAD", "TYPE", "HEALTH"); err != nil {
 		return err
 	}
 	for i := range l.Containers {
 		container := &l.Containers[i]
-		if _, err := fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", container.Id, container.Server, container.ActiveState, container.SubState, container.LoadState, container.JobType); err != nil {
+		if _, err := fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", container.Id, container.Server, container.ActiveState, container.SubState, container.LoadState, container.JobType, container.Health); err != nil {
+			return err
+		}
+	}
+	tw.Flush()
+	return nil
+}
+
+func (r *ContainerHistoryResponse) WriteTableTo(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 8, 4, 1, ' ', tabwriter.DiscardEmptyColumns)
+	if _, err := fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", "REQUEST", "CREATED", "IMAGE", "PORTS", "CURRENT"); err != nil {
+		return err
+	}
+	for i := range r.Versions {
+		v := &r.Versions[i]
+		current := ""
+		if v.Current {
+			current = "*"
+		}
+		if _, err := fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", v.RequestId, v.Created.Format(time.RFC3339), v.Image, v.Ports.String(), current); err != nil {
 			return err
 		}
 	}
@@ -81,16 +81,23 @@ func (j *RunContainerRequest) Execute(resp jobs.Response) {
 
 	log.Printf("run_container: Running container %s", unitName)
 
-	status, err := systemd.Connection().StartTransientUnit(
-		unitName,
-		"fail",
-		dbus.PropExecStart(command, true),
-		dbus.PropDescription(unitDescription),
-		dbus.PropRemainAfterExit(true),
-		dbus.PropSlice("container.slice"),
-	)
+	var status string
+	started, err := j.cancel.start(func() (err error) {
+		status, err = systemd.Connection().StartTransientUnit(
+			unitName,
+			"fail",
+			dbus.PropExecStart(command, true),
+			dbus.PropDescription(unitDescription),
+			dbus.PropRemainAfterExit(true),
+			dbus.PropSlice("container.slice"),
+		)
+		return
+	})
 
 	switch {
+	case !started:
+		resp.Failure(jobs.ErrJobCanceled)
+		return
 	case err != nil:
 		errType := reflect.TypeOf(err)
 		resp.Failure(jobs.SimpleError{jobs.ResponseError, fmt.Sprintf("Unable to start container execution due to (%s): %s", errType, err.Error())})
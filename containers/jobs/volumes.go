@@ -0,0 +1,111 @@
+// +build linux
+
+package jobs
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"os/user"
+	"strconv"
+
+	"github.com/openshift/geard/containers"
+	"github.com/openshift/geard/jobs"
+)
+
+func (j *PutVolumeRequest) Execute(resp jobs.Response) {
+	if err := containers.SaveVolume(&j.Volume); err != nil {
+		log.Printf("volumes: Unable to save volume %s: %v", j.Id, err)
+		resp.Failure(ErrVolumeUpdateFailed)
+		return
+	}
+
+	// the user of an isolated container is created when it first starts,
+	// the volume is chowned then if the user does not exist yet
+	if j.Owner != "" {
+		if u, err := user.Lookup(j.Owner.LoginFor()); err == nil {
+			if err := chownVolume(&j.Volume, u); err != nil {
+				log.Printf("volumes: Unable to change the owner of %s: %v", j.Id, err)
+				resp.Failure(ErrVolumeUpdateFailed)
+				return
+			}
+		} else if _, ok := err.(user.UnknownUserError); !ok {
+			log.Printf("volumes: Unable to look up the owner of %s: %v", j.Id, err)
+		}
+	}
+
+	resp.Success(jobs.ResponseOk)
+}
+
+func (j *DeleteVolumeRequest) Execute(resp jobs.Response) {
+	if _, err := containers.GetVolume(j.Id); err != nil {
+		if os.IsNotExist(err) {
+			resp.Failure(ErrVolumeNotFound)
+			return
+		}
+		log.Printf("volumes: Unable to read volume %s: %v", j.Id, err)
+		resp.Failure(ErrVolumeUpdateFailed)
+		return
+	}
+
+	users, err := containers.GetVolumeUsers(j.Id)
+	if err != nil {
+		log.Printf("volumes: Unable to find the containers using %s: %v", j.Id, err)
+		resp.Failure(ErrVolumeUpdateFailed)
+		return
+	}
+	if len(users) > 0 {
+		resp.Failure(ErrVolumeInUse)
+		return
+	}
+
+	if err := containers.RemoveVolume(j.Id); err != nil {
+		log.Printf("volumes: Unable to remove volume %s: %v", j.Id, err)
+		resp.Failure(ErrVolumeUpdateFailed)
+		return
+	}
+	resp.Success(jobs.ResponseOk)
+}
+
+// The arguments to docker run that mount each volume into the container.
+// Volumes that are not shared may only be mounted by one container.
+func dockerVolumeSpec(id containers.Identifier, mounts containers.VolumeMounts) (string, error) {
+	var volumeSpec bytes.Buffer
+	for i := range mounts {
+		volume, err := containers.GetVolume(mounts[i].Volume)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return "", ErrVolumeNotFound
+			}
+			log.Printf("volumes: Unable to read volume %s: %v", mounts[i].Volume, err)
+			return "", ErrContainerCreateFailed
+		}
+		if !volume.Shared {
+			users, err := containers.GetVolumeUsers(volume.Id)
+			if err != nil {
+				log.Printf("volumes: Unable to find the containers using %s: %v", volume.Id, err)
+				return "", ErrContainerCreateFailed
+			}
+			for _, user := range users {
+				if user != id {
+					return "", ErrVolumeInUse
+				}
+			}
+		}
+		volumeSpec.WriteString(mounts[i].DockerArgs(volume.Shared))
+		volumeSpec.WriteString(" ")
+	}
+	return volumeSpec.String(), nil
+}
+
+func chownVolume(volume *containers.Volume, u *user.User) error {
+	uid, err := strconv.Atoi(u.Uid)
+	if err != nil {
+		return err
+	}
+	gid, err := strconv.Atoi(u.Gid)
+	if err != nil {
+		return err
+	}
+	return volume.Chown(uid, gid)
+}
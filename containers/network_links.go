@@ -1,10 +1,12 @@
 package containers
 
 import (
+	"bufio"
 	"bytes"
 	"errors"
 	"fmt"
 	"github.com/openshift/geard/port"
+	"io"
 	"log"
 	"os"
 	"strconv"
@@ -76,6 +78,38 @@ func (n NetworkLinks) Write(path string, appends bool) error {
 	return nil
 }
 
+func GetExistingNetworkLinks(id Identifier) (NetworkLinks, error) {
+	existing, err := os.Open(id.NetworkLinksPathFor())
+	if err != nil {
+		return nil, err
+	}
+	defer existing.Close()
+
+	return ReadNetworkLinksFrom(existing)
+}
+
+// Read links in the format written by Write.
+func ReadNetworkLinksFrom(r io.Reader) (NetworkLinks, error) {
+	links := make(NetworkLinks, 0, 2)
+	scan := bufio.NewScanner(r)
+	for scan.Scan() {
+		fields := strings.Split(scan.Text(), "\t")
+		if len(fields) != 4 {
+			continue
+		}
+		from, errf := strconv.Atoi(fields[1])
+		to, errt := strconv.Atoi(fields[2])
+		if errf != nil || errt != nil {
+			continue
+		}
+		links = append(links, NetworkLink{FromHost: fields[0], FromPort: port.Port(from), ToPort: port.Port(to), ToHost: fields[3]})
+	}
+	if scan.Err() != nil {
+		return links, scan.Err()
+	}
+	return links, nil
+}
+
 func (n NetworkLinks) String() string {
 	var pairs bytes.Buffer
 	for i := range n {
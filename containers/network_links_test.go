@@ -0,0 +1,38 @@
+package containers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadNetworkLinks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "links")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	links := NetworkLinks{
+		{FromHost: "127.0.0.1", FromPort: 8080, ToHost: "db.example.com", ToPort: 4000},
+		{FromHost: "127.0.0.2", FromPort: 5432, ToHost: "10.0.0.2", ToPort: 4001},
+	}
+	path := filepath.Join(dir, "links")
+	if err := links.Write(path, false); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	read, err := ReadNetworkLinksFrom(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(read) != 2 || read[0] != links[0] || read[1] != links[1] {
+		t.Errorf("Unexpected links: %+v", read)
+	}
+}
@@ -0,0 +1,142 @@
+package containers
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/openshift/geard/config"
+)
+
+// The registry images without a registry host are pulled from.
+const DefaultRegistry = "index.docker.io"
+
+// The account the daemon uses to pull images from a private registry.
+type RegistryCredentials struct {
+	Registry string
+	Username string
+	Password string `json:",omitempty"`
+	Email    string `json:",omitempty"`
+}
+
+func (c *RegistryCredentials) Check() error {
+	if err := CheckRegistry(c.Registry); err != nil {
+		return err
+	}
+	if c.Username == "" {
+		return errors.New("A username is required to authenticate to a registry.")
+	}
+	return nil
+}
+
+func CheckRegistry(registry string) error {
+	if registry == "" || strings.ContainsAny(registry, "/ \t\n") {
+		return errors.New("The registry must be a host name with an optional port.")
+	}
+	return nil
+}
+
+// Return the registry an image is pulled from.  As with docker, the
+// first part of the image name is a registry host only if it contains a
+// '.' or ':' or is 'localhost'.
+func RegistryForImage(image string) string {
+	parts := strings.SplitN(image, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		return parts[0]
+	}
+	return DefaultRegistry
+}
+
+// Split an image name into the repository and tag, defaulting to the
+// latest tag.
+func ParseImageName(image string) (repository, tag string) {
+	i := strings.LastIndex(image, ":")
+	if i == -1 || strings.Contains(image[i+1:], "/") {
+		return image, "latest"
+	}
+	return image[:i], image[i+1:]
+}
+
+func RegistryCredentialsPath() string {
+	return filepath.Join(config.ContainerBasePath(), "registries", "credentials.json")
+}
+
+var registryLock sync.Mutex
+
+// Return the credentials stored for a registry, or nil if there are none.
+func GetRegistryCredentials(registry string) (*RegistryCredentials, error) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	all, err := readRegistryCredentials()
+	if err != nil {
+		return nil, err
+	}
+	if c, ok := all[registry]; ok {
+		return &c, nil
+	}
+	return nil, nil
+}
+
+func SaveRegistryCredentials(c *RegistryCredentials) error {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	all, err := readRegistryCredentials()
+	if err != nil {
+		return err
+	}
+	all[c.Registry] = *c
+	return writeRegistryCredentials(all)
+}
+
+func RemoveRegistryCredentials(registry string) error {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	all, err := readRegistryCredentials()
+	if err != nil {
+		return err
+	}
+	if _, ok := all[registry]; !ok {
+		return nil
+	}
+	delete(all, registry)
+	return writeRegistryCredentials(all)
+}
+
+func readRegistryCredentials() (map[string]RegistryCredentials, error) {
+	all := make(map[string]RegistryCredentials)
+	file, err := os.Open(RegistryCredentialsPath())
+	if os.IsNotExist(err) {
+		return all, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	if err := json.NewDecoder(file).Decode(&all); err != nil {
+		return nil, err
+	}
+	return all, nil
+}
+
+// Credentials are only readable by the daemon.
+func writeRegistryCredentials(all map[string]RegistryCredentials) error {
+	path := RegistryCredentialsPath()
+	tmp := path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(file).Encode(all); err != nil {
+		file.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,76 @@
+package containers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openshift/geard/config"
+)
+
+func TestRegistryForImage(t *testing.T) {
+	tests := map[string]string{
+		"fedora":                        DefaultRegistry,
+		"openshift/busybox-http-app":    DefaultRegistry,
+		"localhost/app":                 "localhost",
+		"registry.example.com/team/app": "registry.example.com",
+		"registry:5000/app:1.0":         "registry:5000",
+		"registry.example.com:5000/app": "registry.example.com:5000",
+	}
+	for image, registry := range tests {
+		if r := RegistryForImage(image); r != registry {
+			t.Errorf("Expected the registry of %s to be %s, got %s", image, registry, r)
+		}
+	}
+}
+
+func TestParseImageName(t *testing.T) {
+	tests := [][3]string{
+		{"fedora", "fedora", "latest"},
+		{"fedora:20", "fedora", "20"},
+		{"registry:5000/app", "registry:5000/app", "latest"},
+		{"registry:5000/app:1.0", "registry:5000/app", "1.0"},
+	}
+	for _, test := range tests {
+		if repository, tag := ParseImageName(test[0]); repository != test[1] || tag != test[2] {
+			t.Errorf("Expected %s to be %s and %s, got %s and %s", test[0], test[1], test[2], repository, tag)
+		}
+	}
+}
+
+func TestRegistryCredentials(t *testing.T) {
+	base, err := ioutil.TempDir("", "registry")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(base)
+	previous := config.ContainerBasePath()
+	config.SetContainerBasePath(base)
+	defer config.SetContainerBasePath(previous)
+	os.MkdirAll(filepath.Dir(RegistryCredentialsPath()), 0700)
+
+	if c, err := GetRegistryCredentials("registry:5000"); err != nil || c != nil {
+		t.Fatalf("Expected no credentials: %+v %v", c, err)
+	}
+	if err := SaveRegistryCredentials(&RegistryCredentials{Registry: "registry:5000", Username: "alice", Password: "secret"}); err != nil {
+		t.Fatal(err)
+	}
+	c, err := GetRegistryCredentials("registry:5000")
+	if err != nil || c == nil || c.Username != "alice" || c.Password != "secret" {
+		t.Fatalf("Unexpected credentials: %+v %v", c, err)
+	}
+	if info, err := os.Stat(RegistryCredentialsPath()); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Credentials should only be readable by the daemon: %v %v", info, err)
+	}
+	if err := RemoveRegistryCredentials("registry:5000"); err != nil {
+		t.Fatal(err)
+	}
+	if c, err := GetRegistryCredentials("registry:5000"); err != nil || c != nil {
+		t.Errorf("Expected the credentials to be removed: %+v %v", c, err)
+	}
+
+	if err := (&RegistryCredentials{Registry: "a/b", Username: "alice"}).Check(); err == nil {
+		t.Error("Expected a registry with a path to be rejected")
+	}
+}
@@ -0,0 +1,285 @@
+package containers
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Constraints on the resources a container may use.  Zero values are
+// not limited.
+type ResourceLimits struct {
+	// Memory in bytes
+	Memory uint64 `json:"Memory,omitempty"`
+	// The relative CPU weight of the container (1024 is the default
+	// weight of a process)
+	CpuShares uint64 `json:"CpuShares,omitempty"`
+	// The percentage of a single CPU the container may use - 200 allows
+	// two full CPUs
+	CpuQuota uint64 `json:"CpuQuota,omitempty"`
+	// The relative block IO weight of the container, from 10 to 1000
+	BlockIOWeight uint64 `json:"BlockIOWeight,omitempty"`
+	// The maximum number of processes and threads
+	Tasks uint64 `json:"Tasks,omitempty"`
+}
+
+const minimumMemoryLimit = 4 * 1024 * 1024
+
+const (
+	// Containers without a memory limit of their own share the memory
+	// limit of this slice
+	SmallSlice = "container-small"
+	// Containers with a memory limit are only bound by their own limit
+	LimitedSlice = "container-limited"
+)
+
+func (l *ResourceLimits) Check() error {
+	if l.Memory != 0 && l.Memory < minimumMemoryLimit {
+		return errors.New("The memory limit must be at least 4M")
+	}
+	if l.CpuShares == 1 {
+		return errors.New("CPU shares must be at least 2")
+	}
+	if l.BlockIOWeight != 0 && (l.BlockIOWeight < 10 || l.BlockIOWeight > 1000) {
+		return errors.New("The block IO weight must be between 10 and 1000")
+	}
+	return nil
+}
+
+func (l *ResourceLimits) Empty() bool {
+	return *l == ResourceLimits{}
+}
+
+// Return these limits with any non-zero values in changes applied.
+func (l ResourceLimits) Merge(changes ResourceLimits) ResourceLimits {
+	if changes.Memory != 0 {
+		l.Memory = changes.Memory
+	}
+	if changes.CpuShares != 0 {
+		l.CpuShares = changes.CpuShares
+	}
+	if changes.CpuQuota != 0 {
+		l.CpuQuota = changes.CpuQuota
+	}
+	if changes.BlockIOWeight != 0 {
+		l.BlockIOWeight = changes.BlockIOWeight
+	}
+	if changes.Tasks != 0 {
+		l.Tasks = changes.Tasks
+	}
+	return l
+}
+
+// The slice a container with these limits is placed in.
+func (l ResourceLimits) Slice() string {
+	if l.Memory != 0 {
+		return LimitedSlice
+	}
+	return SmallSlice
+}
+
+// The cgroup properties that apply these limits to a systemd unit or
+// slice, as Name=Value lines.
+func (l ResourceLimits) SystemdProperties() []string {
+	props := []string{}
+	if l.Memory != 0 {
+		props = append(props, "MemoryLimit="+FormatByteSize(l.Memory))
+	}
+	if l.CpuShares != 0 {
+		props = append(props, fmt.Sprintf("CPUShares=%d", l.CpuShares))
+	}
+	if l.CpuQuota != 0 {
+		props = append(props, fmt.Sprintf("CPUQuota=%d%%", l.CpuQuota))
+	}
+	if l.BlockIOWeight != 0 {
+		props = append(props, fmt.Sprintf("BlockIOWeight=%d", l.BlockIOWeight))
+	}
+	if l.Tasks != 0 {
+		props = append(props, fmt.Sprintf("TasksMax=%d", l.Tasks))
+	}
+	return props
+}
+
+// The arguments to docker run that apply these limits to the container
+// processes.
+func (l ResourceLimits) DockerArgs() string {
+	args := []string{}
+	if l.Memory != 0 {
+		args = append(args, fmt.Sprintf("--memory=%d", l.Memory))
+	}
+	if l.CpuShares != 0 {
+		args = append(args, fmt.Sprintf("--cpu-shares=%d", l.CpuShares))
+	}
+	if l.CpuQuota != 0 {
+		// docker expects microseconds of CPU time in each 100ms period
+		args = append(args, fmt.Sprintf("--cpu-quota=%d", l.CpuQuota*1000))
+	}
+	if l.BlockIOWeight != 0 {
+		args = append(args, fmt.Sprintf("--blkio-weight=%d", l.BlockIOWeight))
+	}
+	if l.Tasks != 0 {
+		args = append(args, fmt.Sprintf("--pids-limit=%d", l.Tasks))
+	}
+	return strings.Join(args, " ")
+}
+
+func (l ResourceLimits) String() string {
+	if l.Empty() {
+		return "none"
+	}
+	values := []string{}
+	if l.Memory != 0 {
+		values = append(values, "memory="+FormatByteSize(l.Memory))
+	}
+	if l.CpuShares != 0 {
+		values = append(values, fmt.Sprintf("cpu-shares=%d", l.CpuShares))
+	}
+	if l.CpuQuota != 0 {
+		values = append(values, fmt.Sprintf("cpu-quota=%d%%", l.CpuQuota))
+	}
+	if l.BlockIOWeight != 0 {
+		values = append(values, fmt.Sprintf("blkio-weight=%d", l.BlockIOWeight))
+	}
+	if l.Tasks != 0 {
+		values = append(values, fmt.Sprintf("tasks=%d", l.Tasks))
+	}
+	return strings.Join(values, " ")
+}
+
+var byteSizeSuffixes = []struct {
+	suffix string
+	size   uint64
+}{
+	{"T", 1024 * 1024 * 1024 * 1024},
+	{"G", 1024 * 1024 * 1024},
+	{"M", 1024 * 1024},
+	{"K", 1024},
+}
+
+// Parse a size in bytes with an optional K, M, G, or T suffix.
+func ParseByteSize(s string) (uint64, error) {
+	value := strings.ToUpper(strings.TrimSpace(s))
+	value = strings.TrimSuffix(value, "B")
+	multiplier := uint64(1)
+	for _, unit := range byteSizeSuffixes {
+		if strings.HasSuffix(value, unit.suffix) {
+			value = strings.TrimSuffix(value, unit.suffix)
+			multiplier = unit.size
+			break
+		}
+	}
+	size, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, errors.New(fmt.Sprintf("The size '%s' must be a number of bytes with an optional K, M, G, or T suffix", s))
+	}
+	return size * multiplier, nil
+}
+
+// Format a size in bytes using the largest suffix that represents it
+// exactly.
+func FormatByteSize(size uint64) string {
+	for _, unit := range byteSizeSuffixes {
+		if size != 0 && size%unit.size == 0 {
+			return strconv.FormatUint(size/unit.size, 10) + unit.suffix
+		}
+	}
+	return strconv.FormatUint(size, 10)
+}
+
+func GetExistingLimits(id Identifier) (*ResourceLimits, error) {
+	existing, err := os.Open(id.UnitPathFor())
+	if err != nil {
+		return nil, err
+	}
+	defer existing.Close()
+
+	return readLimitsFromUnitFile(existing)
+}
+
+func readLimitsFromUnitFile(r io.Reader) (*ResourceLimits, error) {
+	limits := &ResourceLimits{}
+	scan := bufio.NewScanner(r)
+	for scan.Scan() {
+		parts := strings.SplitN(strings.TrimSpace(scan.Text()), "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		value := parts[1]
+		switch parts[0] {
+		case "MemoryLimit":
+			limits.Memory, _ = ParseByteSize(value)
+		case "CPUShares":
+			limits.CpuShares, _ = strconv.ParseUint(value, 10, 64)
+		case "CPUQuota":
+			limits.CpuQuota, _ = strconv.ParseUint(strings.TrimSuffix(value, "%"), 10, 64)
+		case "BlockIOWeight":
+			limits.BlockIOWeight, _ = strconv.ParseUint(value, 10, 64)
+		case "TasksMax":
+			limits.Tasks, _ = strconv.ParseUint(value, 10, 64)
+		}
+	}
+	if scan.Err() != nil {
+		return limits, scan.Err()
+	}
+	return limits, nil
+}
+
+var (
+	limitProperties = map[string]bool{"MemoryLimit": true, "CPUShares": true, "CPUQuota": true, "BlockIOWeight": true, "TasksMax": true}
+	limitDockerArgs = regexp.MustCompile(` --(memory|cpu-shares|cpu-quota|blkio-weight|pids-limit)=[^ ]+`)
+)
+
+// Copy a unit file from r to w, replacing the limit properties and the
+// container slice in the [Service] section and the limit arguments passed
+// to docker run.
+func WriteLimitsToUnitFile(r io.Reader, w io.Writer, limits ResourceLimits) error {
+	out := bufio.NewWriter(w)
+	service, written := false, false
+	writeProperties := func() {
+		for _, prop := range limits.SystemdProperties() {
+			fmt.Fprintln(out, prop)
+		}
+		written = true
+	}
+
+	scan := bufio.NewScanner(r)
+	for scan.Scan() {
+		line := scan.Text()
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") {
+			if service && !written {
+				writeProperties()
+			}
+			service = trimmed == "[Service]"
+		}
+		if service {
+			parts := strings.SplitN(trimmed, "=", 2)
+			if len(parts) == 2 && limitProperties[parts[0]] {
+				continue
+			}
+			if len(parts) == 2 && parts[0] == "Slice" && (parts[1] == SmallSlice+".slice" || parts[1] == LimitedSlice+".slice") {
+				line = "Slice=" + limits.Slice() + ".slice"
+			}
+			if !written && strings.HasPrefix(trimmed, "ExecStart") {
+				writeProperties()
+			}
+			line = limitDockerArgs.ReplaceAllString(line, "")
+			if args := limits.DockerArgs(); args != "" && strings.HasPrefix(trimmed, "ExecStart=/usr/bin/docker run ") {
+				line = strings.Replace(line, "/usr/bin/docker run ", "/usr/bin/docker run "+args+" ", 1)
+			}
+		}
+		fmt.Fprintln(out, line)
+	}
+	if scan.Err() != nil {
+		return scan.Err()
+	}
+	if service && !written {
+		writeProperties()
+	}
+	return out.Flush()
+}
@@ -0,0 +1,126 @@
+package containers
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestParseByteSize(t *testing.T) {
+	for s, expected := range map[string]uint64{
+		"1024": 1024,
+		"4k":   4 * 1024,
+		"512M": 512 * 1024 * 1024,
+		"2GB":  2 * 1024 * 1024 * 1024,
+	} {
+		size, err := ParseByteSize(s)
+		if err != nil {
+			t.Errorf("Unable to parse %s: %v", s, err)
+			continue
+		}
+		if size != expected {
+			t.Errorf("Expected %s to be %d, got %d", s, expected, size)
+		}
+	}
+	if _, err := ParseByteSize("lots"); err == nil {
+		t.Error("Expected an error for an invalid size")
+	}
+	if s := FormatByteSize(512 * 1024 * 1024); s != "512M" {
+		t.Errorf("Expected 512M, got %s", s)
+	}
+	if s := FormatByteSize(1000); s != "1000" {
+		t.Errorf("Expected 1000, got %s", s)
+	}
+}
+
+func TestResourceLimits(t *testing.T) {
+	limits := ResourceLimits{Memory: 256 * 1024 * 1024, CpuShares: 512, CpuQuota: 150, BlockIOWeight: 500, Tasks: 100}
+	if err := limits.Check(); err != nil {
+		t.Fatal(err)
+	}
+	if err := (&ResourceLimits{BlockIOWeight: 5}).Check(); err == nil {
+		t.Error("Expected an error for a block IO weight below 10")
+	}
+	if err := (&ResourceLimits{Memory: 1024}).Check(); err == nil {
+		t.Error("Expected an error for a memory limit below 4M")
+	}
+
+	if limits.Slice() != LimitedSlice || (ResourceLimits{CpuShares: 512}).Slice() != SmallSlice {
+		t.Error("Expected only containers with a memory limit to be placed in the limited slice")
+	}
+
+	props := strings.Join(limits.SystemdProperties(), "\n")
+	if props != "MemoryLimit=256M\nCPUShares=512\nCPUQuota=150%\nBlockIOWeight=500\nTasksMax=100" {
+		t.Errorf("Unexpected systemd properties:\n%s", props)
+	}
+	if args := limits.DockerArgs(); args != "--memory=268435456 --cpu-shares=512 --cpu-quota=150000 --blkio-weight=500 --pids-limit=100" {
+		t.Errorf("Unexpected docker arguments: %s", args)
+	}
+
+	unit := "[Service]\nType=simple\nSlice=container-small.slice\n" + props + "\nExecStart=/usr/bin/docker run\n"
+	read, err := readLimitsFromUnitFile(strings.NewReader(unit))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *read != limits {
+		t.Errorf("Expected the limits to be read from the unit file: %+v", read)
+	}
+}
+
+func TestWriteLimitsToUnitFile(t *testing.T) {
+	unit := `[Unit]
+Description=Container test
+
+[Service]
+Type=simple
+Slice=container-small.slice
+MemoryLimit=256M
+CPUShares=512
+ExecStart=/usr/bin/docker run --rm --name "test" \
+          -a stdout -a stderr  --memory=268435456 --cpu-shares=512 \
+          "openshift/busybox-http-app"
+
+[Install]
+WantedBy=container.target
+`
+	existing, err := readLimitsFromUnitFile(strings.NewReader(unit))
+	if err != nil {
+		t.Fatal(err)
+	}
+	limits := existing.Merge(ResourceLimits{Memory: 1024 * 1024 * 1024, Tasks: 100})
+	if limits != (ResourceLimits{Memory: 1024 * 1024 * 1024, CpuShares: 512, Tasks: 100}) {
+		t.Fatalf("Unexpected merged limits: %+v", limits)
+	}
+
+	out := &bytes.Buffer{}
+	if err := WriteLimitsToUnitFile(strings.NewReader(unit), out, limits); err != nil {
+		t.Fatal(err)
+	}
+	expected := `[Unit]
+Description=Container test
+
+[Service]
+Type=simple
+Slice=container-limited.slice
+MemoryLimit=1G
+CPUShares=512
+TasksMax=100
+ExecStart=/usr/bin/docker run --memory=1073741824 --cpu-shares=512 --pids-limit=100 --rm --name "test" \
+          -a stdout -a stderr  \
+          "openshift/busybox-http-app"
+
+[Install]
+WantedBy=container.target
+`
+	if out.String() != expected {
+		t.Errorf("Unexpected unit file:\n%s", out.String())
+	}
+
+	read, err := readLimitsFromUnitFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *read != limits {
+		t.Errorf("Expected the limits to be read from the rewritten unit file: %+v", read)
+	}
+}
@@ -1,6 +1,7 @@
 package systemd
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/openshift/go-systemd/dbus"
 	"os"
@@ -31,22 +32,50 @@ type ContainerEvent struct {
 	Type EventType
 }
 
-func (e ContainerEvent) String() string {
-	switch {
-	case e.Type == Unknown:
-		return string(e.Id) + " (unknown)"
-	case e.Type == Started:
-		return string(e.Id) + " (started)"
-	case e.Type == Idled:
-		return string(e.Id) + " (idled)"
-	case e.Type == Stopped:
-		return string(e.Id) + " (stopped)"
-	case e.Type == Deleted:
-		return string(e.Id) + " (deleted)"
-	case e.Type == Errored:
-		return string(e.Id) + " (error)"
+var eventTypeNames = map[EventType]string{
+	Unknown: "unknown",
+	Started: "started",
+	Idled:   "idled",
+	Stopped: "stopped",
+	Deleted: "deleted",
+	Errored: "error",
+}
+
+func NewEventType(name string) (EventType, error) {
+	for t, n := range eventTypeNames {
+		if n == name {
+			return t, nil
+		}
 	}
-	return string(e.Id) + " (unknown)"
+	return Unknown, fmt.Errorf("%s is not a valid event type", name)
+}
+
+func (t EventType) String() string {
+	if name, ok := eventTypeNames[t]; ok {
+		return name
+	}
+	return eventTypeNames[Unknown]
+}
+
+func (t EventType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+func (t *EventType) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return err
+	}
+	value, err := NewEventType(name)
+	if err != nil {
+		return err
+	}
+	*t = value
+	return nil
+}
+
+func (e ContainerEvent) String() string {
+	return string(e.Id) + " (" + e.Type.String() + ")"
 }
 
 func NewEventListener() (*EventListener, error) {
@@ -0,0 +1,27 @@
+package systemd
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestContainerEventJSON(t *testing.T) {
+	data, err := json.Marshal(&ContainerEvent{"foo", Errored})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"Id":"foo","Type":"error"}` {
+		t.Errorf("Unexpected JSON %s", string(data))
+	}
+
+	event := ContainerEvent{}
+	if err := json.Unmarshal([]byte(`{"Id":"bar","Type":"idled"}`), &event); err != nil {
+		t.Fatal(err)
+	}
+	if event.Id != "bar" || event.Type != Idled {
+		t.Errorf("Unexpected event %+v", event)
+	}
+	if err := json.Unmarshal([]byte(`{"Type":"exploded"}`), &event); err == nil {
+		t.Error("Expected an unknown event type to be rejected")
+	}
+}
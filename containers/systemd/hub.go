@@ -0,0 +1,80 @@
+package systemd
+
+import (
+	"log"
+	"sync"
+)
+
+// Shares a single EventListener between any number of subscribers.  The
+// listener is started with the first subscription and runs until the
+// process exits.
+type EventHub struct {
+	lock        sync.Mutex
+	listener    *EventListener
+	subscribers map[*EventSubscription]bool
+}
+
+// A stream of container events.  Events are dropped if the subscriber
+// falls too far behind.
+type EventSubscription struct {
+	hub    *EventHub
+	events chan *ContainerEvent
+}
+
+const subscriptionBuffer = 100
+
+var defaultEventHub = &EventHub{}
+
+// Subscribe to the container events on this server.
+func SubscribeToEvents() (*EventSubscription, error) {
+	return defaultEventHub.Subscribe()
+}
+
+func (h *EventHub) Subscribe() (*EventSubscription, error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if h.listener == nil {
+		listener, err := NewEventListener()
+		if err != nil {
+			return nil, err
+		}
+		h.listener = listener
+		h.subscribers = make(map[*EventSubscription]bool)
+		events, errors := listener.Run()
+		go h.publish(events, errors)
+	}
+
+	s := &EventSubscription{h, make(chan *ContainerEvent, subscriptionBuffer)}
+	h.subscribers[s] = true
+	return s, nil
+}
+
+func (h *EventHub) publish(events <-chan *ContainerEvent, errors <-chan error) {
+	for {
+		select {
+		case event := <-events:
+			h.lock.Lock()
+			for s := range h.subscribers {
+				select {
+				case s.events <- event:
+				default:
+					log.Printf("events: Subscriber is not keeping up, dropped %s", event.String())
+				}
+			}
+			h.lock.Unlock()
+		case err := <-errors:
+			log.Printf("events: Error while listening for events: %v", err)
+		}
+	}
+}
+
+func (s *EventSubscription) Events() <-chan *ContainerEvent {
+	return s.events
+}
+
+func (s *EventSubscription) Close() {
+	s.hub.lock.Lock()
+	defer s.hub.lock.Unlock()
+	delete(s.hub.subscribers, s)
+}
@@ -102,6 +102,11 @@ func initPreStart(dockerSocket string, id containers.Identifier, imageName strin
 	}
 
 	u, _ := user.Lookup(id.LoginFor())
+	if err := chownOwnedVolumes(id, u); err != nil {
+		fmt.Printf("container init pre-start: Unable to change the owner of volumes: %v\n", err)
+		return err
+	}
+
 	volumes := make([]string, 0, 10)
 	for volPath := range imgInfo.Config.Volumes {
 		volumes = append(volumes, volPath)
@@ -169,10 +174,50 @@ func createUser(id containers.Identifier) error {
 		log.Println(out)
 		return err
 	}
+	// a restored home directory exists before the user does
+	u, err := user.Lookup(id.LoginFor())
+	if err != nil {
+		return err
+	}
+	uid, _ := strconv.Atoi(u.Uid)
+	gid, _ := strconv.Atoi(u.Gid)
+	if err := utils.ChownTree(id.HomePath(), uid, gid); err != nil {
+		return err
+	}
 	selinux.RestoreCon(id.HomePath(), true)
 	return nil
 }
 
+// Give the container user ownership of the mounted volumes the container
+// owns.
+func chownOwnedVolumes(id containers.Identifier, u *user.User) error {
+	mounts, err := containers.GetExistingVolumes(id)
+	if err != nil {
+		return err
+	}
+	uid, err := strconv.Atoi(u.Uid)
+	if err != nil {
+		return err
+	}
+	gid, err := strconv.Atoi(u.Gid)
+	if err != nil {
+		return err
+	}
+	for i := range mounts {
+		volume, err := containers.GetVolume(mounts[i].Volume)
+		if err != nil {
+			return err
+		}
+		if volume.Owner != id {
+			continue
+		}
+		if err := volume.Chown(uid, gid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func initPostStart(dockerSocket string, id containers.Identifier) error {
 	var (
 		u         *user.User
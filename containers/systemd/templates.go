@@ -28,6 +28,11 @@ type ContainerUnit struct {
 	SocketUnitName       string
 	SocketActivationType string
 
+	Limits containers.ResourceLimits
+
+	Volumes    containers.VolumeMounts
+	VolumeSpec string
+
 	DockerFeatures config.DockerFeatures
 }
 
@@ -43,7 +48,8 @@ Type=simple
 TimeoutStartSec=5m
 {{ if .Slice }}Slice={{.Slice}}{{ end }}
 {{ if .EnvironmentPath }}EnvironmentFile={{.EnvironmentPath}}{{ end }}
-{{end}}
+{{range .Limits.SystemdProperties}}{{.}}
+{{end}}{{end}}
 
 {{define "COMMON_CONTAINER"}}
 [Install]
@@ -56,6 +62,7 @@ X-ContainerUserId={{.User}}
 X-ContainerRequestId={{.ReqId}}
 X-ContainerType={{ if .Isolate }}isolated{{ else }}simple{{ end }}
 {{range .PortPairs}}X-PortMapping={{.Internal}}:{{.External}}
+{{end}}{{range .Volumes}}X-Volume={{.String}}
 {{end}}
 {{end}}
 
@@ -71,7 +78,7 @@ ExecStartPre={{.ExecutablePath}} init --pre "{{.Id}}" "{{.Image}}"{{ end }}
 ExecStart=/usr/bin/docker run --rm --name "{{.Id}}" \
           --volumes-from "{{.Id}}-data" \
           {{ if and .EnvironmentPath .DockerFeatures.EnvironmentFile }}--env-file "{{ .EnvironmentPath }}"{{ end }} \
-          -a stdout -a stderr {{.PortSpec}} {{.RunSpec}} \
+          -a stdout -a stderr {{.PortSpec}} {{.RunSpec}} {{.Limits.DockerArgs}} {{.VolumeSpec}} \
           {{ if .Isolate }} -v {{.RunDir}}/container-cmd.sh:/.container.cmd:ro -v {{.RunDir}}/container-init.sh:/.container.init:ro -u root {{end}} \
           "{{.Image}}" {{ if .Isolate }} /.container.init {{ end }}
 # Set links (requires container have a name)
@@ -93,7 +100,7 @@ ExecStartPre=-/usr/bin/docker rm "{{.Id}}"
 ExecStartPre={{.ExecutablePath}} init --pre "{{.Id}}" "{{.Image}}"{{ end }}
 ExecStart=/usr/bin/docker run --rm --foreground \
           {{ if and .EnvironmentPath .DockerFeatures.EnvironmentFile }}--env-file "{{ .EnvironmentPath }}"{{ end }} \
-          {{.PortSpec}} {{.RunSpec}} \
+          {{.PortSpec}} {{.RunSpec}} {{.Limits.DockerArgs}} {{.VolumeSpec}} \
           --name "{{.Id}}" --volumes-from "{{.Id}}-data" \
           {{ if .Isolate }} -v {{.RunDir}}/container-cmd.sh:/.container.cmd:ro -v {{.RunDir}}/container-init.sh:/.container.init:ro -u root {{end}} \
           "{{.Image}}" {{ if .Isolate }} /.container.init {{ end }}
@@ -113,7 +120,7 @@ ExecStart=/usr/bin/docker run \
             --name "{{.Id}}" \
             --volumes-from "{{.Id}}" \
             {{ if and .EnvironmentPath .DockerFeatures.EnvironmentFile }}--env-file "{{ .EnvironmentPath }}"{{ end }} \
-            -a stdout -a stderr {{.RunSpec}} \
+            -a stdout -a stderr {{.RunSpec}} {{.Limits.DockerArgs}} {{.VolumeSpec}} \
             --env LISTEN_FDS \
             -v {{.RunDir}}/container-init.sh:/.container.init:ro \
             -v {{.RunDir}}/container-cmd.sh:/.container.cmd:ro \
@@ -157,6 +164,7 @@ WantedBy={{.WantedBy}}
 type SliceUnit struct {
 	Name   string
 	Parent string
+	Limits containers.ResourceLimits
 }
 
 var SliceUnitTemplate = template.Must(template.New("unit.slice").Parse(`
@@ -166,8 +174,8 @@ Description=Container slice {{.Name}}
 [Slice]
 CPUAccounting=yes
 MemoryAccounting=yes
-MemoryLimit=512M
-{{ if .Parent }}Slice={{.Parent}}{{ end }}
+{{range .Limits.SystemdProperties}}{{.}}
+{{end}}{{ if .Parent }}Slice={{.Parent}}{{ end }}
 
 [Install]
 WantedBy=container.target container-active.target
@@ -0,0 +1,235 @@
+package containers
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/openshift/geard/config"
+	"github.com/openshift/geard/utils"
+)
+
+// A named directory on the host that can be mounted into containers.
+type Volume struct {
+	Id Identifier
+	// The container whose user owns the contents of the volume.  When
+	// the container is isolated the contents are owned by the user
+	// created for it.
+	Owner Identifier `json:",omitempty"`
+	// Allow the volume to be mounted by more than one container
+	Shared bool `json:",omitempty"`
+}
+
+func (v *Volume) Check() error {
+	if _, err := NewIdentifier(string(v.Id)); err != nil {
+		return err
+	}
+	if v.Owner != "" {
+		if _, err := NewIdentifier(string(v.Owner)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// A volume mounted at a path inside a container.
+type VolumeMount struct {
+	Volume   Identifier
+	Path     string
+	ReadOnly bool `json:",omitempty"`
+}
+type VolumeMounts []VolumeMount
+
+func (m *VolumeMount) Check() error {
+	if _, err := NewIdentifier(string(m.Volume)); err != nil {
+		return err
+	}
+	if !filepath.IsAbs(m.Path) {
+		return errors.New(fmt.Sprintf("The volume '%s' must be mounted at an absolute path", m.Volume))
+	}
+	if strings.ContainsAny(m.Path, ":,\"' \t\n") {
+		return errors.New(fmt.Sprintf("The mount path '%s' may not contain whitespace, quotes, colons, or commas", m.Path))
+	}
+	return nil
+}
+
+func (m VolumeMounts) Check() error {
+	paths := make(map[string]bool)
+	for i := range m {
+		if err := m[i].Check(); err != nil {
+			return err
+		}
+		path := filepath.Clean(m[i].Path)
+		if paths[path] {
+			return errors.New(fmt.Sprintf("More than one volume is mounted at %s", path))
+		}
+		paths[path] = true
+	}
+	return nil
+}
+
+// The argument to docker run that mounts the volume.  Shared volumes are
+// relabeled so that any container may use them, all others are labeled
+// for this container alone.
+func (m *VolumeMount) DockerArgs(shared bool) string {
+	options := "Z"
+	if shared {
+		options = "z"
+	}
+	if m.ReadOnly {
+		options = "ro," + options
+	}
+	return fmt.Sprintf("-v %s:%s:%s", m.Volume.VolumePathFor(), m.Path, options)
+}
+
+func (m VolumeMount) String() string {
+	if m.ReadOnly {
+		return fmt.Sprintf("%s:%s:ro", m.Volume, m.Path)
+	}
+	return fmt.Sprintf("%s:%s", m.Volume, m.Path)
+}
+
+func (m VolumeMounts) ToCompact() string {
+	mounts := make([]string, len(m))
+	for i := range m {
+		mounts[i] = m[i].String()
+	}
+	return strings.Join(mounts, ",")
+}
+
+func NewVolumeMountsFromString(s string) (VolumeMounts, error) {
+	set := strings.Split(s, ",")
+	mounts := make(VolumeMounts, 0, len(set))
+	for i := range set {
+		mount, err := NewVolumeMountFromString(set[i])
+		if err != nil {
+			return VolumeMounts{}, err
+		}
+		mounts = append(mounts, *mount)
+	}
+	return mounts, nil
+}
+
+func NewVolumeMountFromString(s string) (*VolumeMount, error) {
+	value := strings.Split(s, ":")
+	if len(value) < 2 || len(value) > 3 || (len(value) == 3 && value[2] != "ro" && value[2] != "rw") {
+		return nil, errors.New(fmt.Sprintf("The volume mount '%s' must be of the form <volume>:<path>[:ro]", s))
+	}
+	mount := &VolumeMount{Volume: Identifier(value[0]), Path: value[1]}
+	if len(value) == 3 {
+		mount.ReadOnly = value[2] == "ro"
+	}
+	if err := mount.Check(); err != nil {
+		return nil, err
+	}
+	return mount, nil
+}
+
+// Create or update the description of a volume and the directory that
+// holds its contents.
+func SaveVolume(v *Volume) error {
+	if err := os.MkdirAll(v.Id.VolumePathFor(), 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	path := v.Id.VolumeDescriptionPathFor()
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0640); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func GetVolume(id Identifier) (*Volume, error) {
+	data, err := ioutil.ReadFile(id.VolumeDescriptionPathFor())
+	if err != nil {
+		return nil, err
+	}
+	volume := &Volume{}
+	if err := json.Unmarshal(data, volume); err != nil {
+		return nil, err
+	}
+	return volume, nil
+}
+
+// Remove the description of a volume and all of its contents.
+func RemoveVolume(id Identifier) error {
+	if err := os.Remove(id.VolumeDescriptionPathFor()); err != nil {
+		return err
+	}
+	return os.RemoveAll(id.VolumePathFor())
+}
+
+// Change the owner of the contents of a volume.
+func (v *Volume) Chown(uid, gid int) error {
+	return utils.ChownTree(v.Id.VolumePathFor(), uid, gid)
+}
+
+// The containers whose unit files mount the volume.
+func GetVolumeUsers(id Identifier) ([]Identifier, error) {
+	units, err := filepath.Glob(filepath.Join(config.ContainerBasePath(), "units", "*", IdentifierPrefix+"*.service"))
+	if err != nil {
+		return nil, err
+	}
+	users := []Identifier{}
+	for _, path := range units {
+		file, err := os.Open(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		mounts, err := readVolumesFromUnitFile(file)
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+		for i := range mounts {
+			if mounts[i].Volume == id {
+				name := strings.TrimSuffix(filepath.Base(path), ".service")
+				users = append(users, Identifier(strings.TrimPrefix(name, IdentifierPrefix)))
+				break
+			}
+		}
+	}
+	return users, nil
+}
+
+func GetExistingVolumes(id Identifier) (VolumeMounts, error) {
+	existing, err := os.Open(id.UnitPathFor())
+	if err != nil {
+		return nil, err
+	}
+	defer existing.Close()
+
+	return readVolumesFromUnitFile(existing)
+}
+
+func readVolumesFromUnitFile(r io.Reader) (VolumeMounts, error) {
+	mounts := make(VolumeMounts, 0, 2)
+	scan := bufio.NewScanner(r)
+	for scan.Scan() {
+		line := scan.Text()
+		if strings.HasPrefix(line, "X-Volume=") {
+			mount, err := NewVolumeMountFromString(strings.TrimPrefix(line, "X-Volume="))
+			if err != nil {
+				continue
+			}
+			mounts = append(mounts, *mount)
+		}
+	}
+	if scan.Err() != nil {
+		return mounts, scan.Err()
+	}
+	return mounts, nil
+}
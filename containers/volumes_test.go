@@ -0,0 +1,57 @@
+package containers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseVolumeMounts(t *testing.T) {
+	mounts, err := NewVolumeMountsFromString("data:/var/lib/data,logs:/var/log/app:ro")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mounts) != 2 {
+		t.Fatalf("Expected two mounts, got %+v", mounts)
+	}
+	if mounts[0] != (VolumeMount{Volume: "data", Path: "/var/lib/data"}) {
+		t.Errorf("Unexpected first mount: %+v", mounts[0])
+	}
+	if mounts[1] != (VolumeMount{Volume: "logs", Path: "/var/log/app", ReadOnly: true}) {
+		t.Errorf("Unexpected second mount: %+v", mounts[1])
+	}
+	if s := mounts.ToCompact(); s != "data:/var/lib/data,logs:/var/log/app:ro" {
+		t.Errorf("Unexpected compact form: %s", s)
+	}
+
+	for _, s := range []string{"data", "data:relative", "data:/var/lib:rx", "data:/a path", "bad name:/data"} {
+		if _, err := NewVolumeMountFromString(s); err == nil {
+			t.Errorf("Expected an error for the mount '%s'", s)
+		}
+	}
+	if err := (VolumeMounts{{Volume: "a", Path: "/data"}, {Volume: "b", Path: "/data/"}}).Check(); err == nil {
+		t.Error("Expected an error for two volumes mounted at the same path")
+	}
+}
+
+func TestVolumeDockerArgs(t *testing.T) {
+	mount := VolumeMount{Volume: "data", Path: "/var/lib/data", ReadOnly: true}
+	args := mount.DockerArgs(false)
+	if !strings.HasPrefix(args, "-v ") || !strings.HasSuffix(args, "/volumes/data/data:/var/lib/data:ro,Z") {
+		t.Errorf("Unexpected private volume arguments: %s", args)
+	}
+	mount.ReadOnly = false
+	if args := mount.DockerArgs(true); !strings.HasSuffix(args, ":/var/lib/data:z") {
+		t.Errorf("Unexpected shared volume arguments: %s", args)
+	}
+}
+
+func TestReadVolumesFromUnitFile(t *testing.T) {
+	unit := "[Install]\nWantedBy=container.target\n\nX-ContainerId=test\nX-PortMapping=8080:4000\nX-Volume=data:/var/lib/data\nX-Volume=logs:/var/log:ro\n"
+	mounts, err := readVolumesFromUnitFile(strings.NewReader(unit))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mounts.ToCompact() != "data:/var/lib/data,logs:/var/log:ro" {
+		t.Errorf("Unexpected volumes read from the unit file: %+v", mounts)
+	}
+}
@@ -27,6 +27,14 @@ type Deployment struct {
 
 	IdPrefix     string
 	RandomizeIds bool
+
+	// The router frontends and backends created for the routes of the
+	// last deployment
+	Routed *RouterIds `json:",omitempty"`
+
+	// Replace instances created from an older image with new instances,
+	// instead of keeping them in place
+	ReplaceChangedImages bool `json:"-"`
 }
 
 func NewDeploymentFromFile(path string) (*Deployment, error) {
@@ -80,13 +88,23 @@ func parseDeployment(payload io.Reader) (*Deployment, error) {
 }
 
 func (d Deployment) Describe(placement PlacementStrategy, t transport.Transport) (next *Deployment, removed InstanceRefs, err error) {
+	for i := range d.Containers {
+		if errv := d.Containers[i].Volumes.Check(); errv != nil {
+			err = errors.New(fmt.Sprintf("The volumes for container %s are not valid: %s", d.Containers[i].Name, errv.Error()))
+			return
+		}
+	}
+
+	if err = d.checkRoutes(); err != nil {
+		return
+	}
+
 	// copy the container list and clear any intermediate state
 	sources := d.Containers.Copy()
 
 	// assign instances to containers or the remove list
 	for i := range d.Instances {
 		instance := &d.Instances[i]
-		copied := *instance
 		// is the instance invalid or no longer part of the cluster
 		if instance.On == nil {
 			continue
@@ -99,6 +117,7 @@ func (d Deployment) Describe(placement PlacementStrategy, t transport.Transport)
 			}
 			instance.on = locator
 		}
+		copied := *instance
 		if placement.RemoveFromLocation(instance.on) {
 			removed = append(removed, &copied)
 			continue
@@ -109,6 +128,13 @@ func (d Deployment) Describe(placement PlacementStrategy, t transport.Transport)
 			removed = append(removed, &copied)
 			continue
 		}
+		// instances created from an older image are replaced
+		if d.ReplaceChangedImages && copied.Image != "" && copied.Image != c.Image {
+			copied.replace = true
+			c.replaced = append(c.replaced, &copied)
+			removed = append(removed, &copied)
+			continue
+		}
 		c.AddInstance(&copied)
 	}
 
@@ -196,13 +222,28 @@ func (d Deployment) Describe(placement PlacementStrategy, t transport.Transport)
 }
 
 func (d *Deployment) createInstances(c *Container) error {
+	// identifiers of existing and replaced instances may not be reused
+	used := make(map[containers.Identifier]bool)
+	for _, instance := range c.instances {
+		used[instance.Id] = true
+	}
+	for _, instance := range c.replaced {
+		used[instance.Id] = true
+	}
+	next := 1
 	for i := len(c.instances); i < c.Count; i++ {
 		var id containers.Identifier
 		var err error
 		if d.RandomizeIds {
 			id, err = containers.NewRandomIdentifier(d.IdPrefix)
 		} else {
-			id, err = containers.NewIdentifier(d.IdPrefix + c.Name + "-" + strconv.Itoa(i+1))
+			for {
+				id, err = containers.NewIdentifier(d.IdPrefix + c.Name + "-" + strconv.Itoa(next))
+				next++
+				if err != nil || !used[id] {
+					break
+				}
+			}
 		}
 		if err != nil {
 			return err
@@ -228,6 +269,9 @@ func (d *Deployment) UpdateLinks() {
 		instance := &d.Instances[i]
 		for j := range instance.links {
 			link := &instance.links[j]
+			if link.replaced {
+				continue
+			}
 		Found:
 			for k := range d.Instances {
 				ref := &d.Instances[k]
@@ -250,15 +294,41 @@ type Container struct {
 	Image       string
 	PublicPorts port.PortPairs `json:"PublicPorts,omitempty"`
 	Links       Links          `json:"Links,omitempty"`
+	// External host names the router sends to the instances
+	Routes Routes `json:"Routes,omitempty"`
 
 	Count    int
 	Affinity string `json:"Affinity,omitempty"`
 
+	// The resources each instance requires from its host
+	Resources *ResourceRequest `json:"Resources,omitempty"`
+	// Containers that an instance may not share a host with - list the
+	// container itself to spread its instances across hosts
+	AntiAffinity []string `json:"AntiAffinity,omitempty"`
+	// Containers that must already have an instance on the same host
+	Colocate []string `json:"Colocate,omitempty"`
+	// Labels a host must have to run an instance
+	HostLabels map[string]string `json:"HostLabels,omitempty"`
+
+	// Volumes to mount into every instance - the volumes must exist on
+	// each host an instance is placed on
+	Volumes containers.VolumeMounts `json:"Volumes,omitempty"`
+
 	// Instances for this container
 	instances InstanceRefs
+	// Existing instances that are being replaced by new instances
+	replaced InstanceRefs
 }
 type Containers []Container
 
+// The resources an instance is expected to use on its host.
+type ResourceRequest struct {
+	// Memory in bytes
+	Memory uint64 `json:"Memory,omitempty"`
+	// The number of CPUs, may be fractional
+	Cpu float64 `json:"Cpu,omitempty"`
+}
+
 func (c *Container) AddInstance(instance *Instance) {
 	c.instances = append(c.instances, instance)
 }
@@ -267,6 +337,10 @@ func (c *Container) Instances() InstanceRefs {
 	return c.instances
 }
 
+func (c *Container) Replaced() InstanceRefs {
+	return c.replaced
+}
+
 func (c *Container) trimInstances() InstanceRefs {
 	count := len(c.instances) - c.Count
 	removed := make(InstanceRefs, 0, count)
@@ -304,6 +378,7 @@ func (c Containers) Copy() (dup Containers) {
 	dup = make(Containers, 0, len(c))
 	for _, container := range c {
 		container.instances = InstanceRefs{}
+		container.replaced = InstanceRefs{}
 		links := make(Links, len(container.Links))
 		copy(links, container.Links)
 		container.Links = links
@@ -1,6 +1,7 @@
 package deployment
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -16,6 +17,7 @@ import (
 
 	"github.com/openshift/geard/http"
 	"github.com/openshift/geard/port"
+	"github.com/openshift/geard/router"
 	"github.com/openshift/geard/transport"
 )
 
@@ -444,3 +446,410 @@ func TestNewDeploymentFromURL_Timeout(t *testing.T) {
 
 	}
 }
+
+func TestRecreateKeepsChangedImageInPlace(t *testing.T) {
+	dep := loadDeployment("./fixtures/mongo_deploy_existing.json")
+	dep.Containers[0].Image = "openshift/ubuntu-mongodb-cluster:next"
+	s := localhost.String()
+	for i := range dep.Instances {
+		dep.Instances[i].On = &s
+	}
+
+	changes, removed, err := dep.Describe(oneHost, loopbackTransport)
+	if err != nil {
+		t.Fatal("Should not have received an error", err)
+	}
+	if len(removed) != 0 {
+		t.Fatalf("Expected no instances to be removed, got %+v", removed)
+	}
+	if len(changes.Instances) != 3 {
+		t.Fatalf("Expected %d instances, got %d", 3, len(changes.Instances))
+	}
+	for i := range changes.Instances {
+		if changes.Instances[i].Added() {
+			t.Fatalf("Expected the existing instance to be kept: %+v", changes.Instances[i])
+		}
+	}
+}
+
+func TestReplaceChangedImage(t *testing.T) {
+	dep := loadDeployment("./fixtures/mongo_deploy_existing.json")
+	dep.Containers[0].Image = "openshift/ubuntu-mongodb-cluster:next"
+	dep.ReplaceChangedImages = true
+	s := localhost.String()
+	for i := range dep.Instances {
+		dep.Instances[i].On = &s
+	}
+
+	changes, removed, err := dep.Describe(oneHost, loopbackTransport)
+	if err != nil {
+		t.Fatal("Should not have received an error", err)
+	}
+	if len(changes.Instances) != 3 {
+		t.Fatalf("Expected %d instances, got %d", 3, len(changes.Instances))
+	}
+	if len(removed) != 3 {
+		t.Fatalf("Expected to replace %d instances, got %d", 3, len(removed))
+	}
+	for i := range removed {
+		if !removed[i].Replaced() {
+			t.Fatalf("Expected instance %s to be replaced", removed[i].Id)
+		}
+	}
+	for i := range changes.Instances {
+		instance := &changes.Instances[i]
+		if !instance.Added() || instance.Image != dep.Containers[0].Image {
+			t.Fatalf("Expected a new instance with the changed image: %+v", instance)
+		}
+		if _, found := dep.Instances.Find(instance.Id); found {
+			t.Fatalf("Expected a new identifier for %s", instance.Id)
+		}
+		if len(instance.NetworkLinks()) != 3 {
+			t.Fatalf("Expected only links to the new instances: %+v", instance.NetworkLinks())
+		}
+		if len(instance.links) != 6 {
+			t.Fatalf("Expected links to both new and replaced instances: %+v", instance.links)
+		}
+		if mapping := instance.Ports[0]; mapping.Target.Host == "192.168.1.1" || mapping.Target.Host == "192.168.1.2" || mapping.Target.Host == "192.168.1.3" {
+			t.Fatalf("Expected the replaced instance ports to stay reserved: %+v", mapping)
+		}
+	}
+}
+
+func TestPlanRollingUpdate(t *testing.T) {
+	if err := (RollingUpdate{}).Check(); err == nil {
+		t.Fatal("Expected an error when no instances may be unavailable or added")
+	}
+
+	dep := loadDeployment("./fixtures/mongo_deploy_existing.json")
+	dep.Containers[0].Image = "openshift/ubuntu-mongodb-cluster:next"
+	dep.ReplaceChangedImages = true
+	s := localhost.String()
+	for i := range dep.Instances {
+		dep.Instances[i].On = &s
+	}
+	changes, removed, err := dep.Describe(oneHost, loopbackTransport)
+	if err != nil {
+		t.Fatal("Should not have received an error", err)
+	}
+	assignPorts(changes)
+	changes.UpdateLinks()
+
+	rollout, err := RollingUpdate{MaxUnavailable: 1, MaxSurge: 1}.Plan(changes, removed)
+	if err != nil {
+		t.Fatal("Should not have received an error", err)
+	}
+	if len(rollout.Batches) != 2 {
+		t.Fatalf("Expected %d batches, got %d", 2, len(rollout.Batches))
+	}
+	first, second := rollout.Batches[0], rollout.Batches[1]
+	if len(first.Stopped) != 1 || len(first.Added) != 2 || len(first.Retired) != 1 {
+		t.Fatalf("Unexpected first batch %+v", first)
+	}
+	if len(second.Stopped) != 1 || len(second.Added) != 1 || len(second.Retired) != 0 {
+		t.Fatalf("Unexpected second batch %+v", second)
+	}
+
+	if linked := rollout.Linked(); len(linked) != 0 {
+		t.Fatalf("Expected no new instances to be linked before they are started: %+v", linked)
+	}
+	rollout.Deactivate(first.Stopped)
+	rollout.Activate(first.Added)
+	linked := rollout.Linked()
+	if len(linked) != 2 {
+		t.Fatalf("Expected the first batch to be linked, got %d", len(linked))
+	}
+	if links := rollout.NetworkLinks(linked[0]); len(links) != 4 {
+		t.Fatalf("Expected links to the running instances only: %+v", links)
+	}
+	rollout.Deactivate(first.Retired)
+	if links := rollout.NetworkLinks(linked[0]); len(links) != 3 {
+		t.Fatalf("Expected links to the running instances only: %+v", links)
+	}
+}
+
+func TestPlanDeployment(t *testing.T) {
+	dep := loadDeployment("./fixtures/mongo_deploy_existing.json")
+	s := localhost.String()
+	for i := range dep.Instances {
+		dep.Instances[i].On = &s
+	}
+
+	plan, err := dep.Plan(oneHost, loopbackTransport)
+	if err != nil {
+		t.Fatal("Should not have received an error", err)
+	}
+	if !plan.Empty() {
+		t.Fatalf("Expected no changes to an unchanged deployment: %+v", plan)
+	}
+
+	dep.Containers[0].Image = "openshift/ubuntu-mongodb-cluster:next"
+	dep.ReplaceChangedImages = true
+	plan, err = dep.Plan(oneHost, loopbackTransport)
+	if err != nil {
+		t.Fatal("Should not have received an error", err)
+	}
+	if len(plan.Hosts) != 1 || plan.Hosts[0].Host != s {
+		t.Fatalf("Expected changes on one host: %+v", plan.Hosts)
+	}
+	changes := plan.Hosts[0].Changes
+	if len(changes) != 3 {
+		t.Fatalf("Expected %d changes, got %d", 3, len(changes))
+	}
+	for _, change := range changes {
+		if change.Type != ReplaceInstance || change.Previous == "" || change.PreviousOn != s {
+			t.Fatalf("Expected the instance to be replaced: %+v", change)
+		}
+		if len(change.Reserved) != 1 || len(change.Links) != 3 {
+			t.Fatalf("Expected a reserved port and links to the new instances: %+v", change)
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	if err := plan.WriteTextTo(buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "replace  db-4 (db) from openshift/ubuntu-mongodb-cluster:next replacing db-1") {
+		t.Fatalf("Unexpected plan output:\n%s", buf.String())
+	}
+	body, err := json.Marshal(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := &Plan{}
+	if err := json.Unmarshal(body, decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(plan, decoded) {
+		t.Fatalf("Expected the plan to survive encoding:\n%+v\n%+v", plan, decoded)
+	}
+}
+
+func TestPlanNewDeployment(t *testing.T) {
+	dep := loadDeployment("./fixtures/simple_deploy.json")
+	plan, err := dep.Plan(oneHost, loopbackTransport)
+	if err != nil {
+		t.Fatal("Should not have received an error", err)
+	}
+	if len(plan.Hosts) != 1 {
+		t.Fatalf("Expected changes on one host: %+v", plan.Hosts)
+	}
+	for _, change := range plan.Hosts[0].Changes {
+		if change.Type != AddInstance {
+			t.Fatalf("Expected only new instances: %+v", change)
+		}
+	}
+}
+
+func inspectHosts(capacities map[string]*HostCapacity) HostInspector {
+	return func(on transport.Locator) (*HostCapacity, error) {
+		if capacity, ok := capacities[on.String()]; ok {
+			return capacity, nil
+		}
+		return nil, fmt.Errorf("host %s is unreachable", on.String())
+	}
+}
+
+func hostsFor(t *testing.T, names ...string) transport.Locators {
+	locators := transport.Locators{}
+	for _, name := range names {
+		locator, err := transport.NewHostLocator(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		locators = append(locators, locator)
+	}
+	return locators
+}
+
+func TestResourcePlacement(t *testing.T) {
+	dep := createDeployment(`{
+    "containers":[
+      {
+        "name":"db",
+        "count":2,
+        "image":"openshift/mongodb",
+        "antiaffinity":["db"],
+        "resources":{"memory":1073741824}
+      },
+      {
+        "name":"cache",
+        "count":1,
+        "image":"openshift/redis",
+        "hostlabels":{"zone":"east"}
+      },
+      {
+        "name":"web",
+        "count":1,
+        "image":"openshift/web",
+        "colocate":["cache"],
+        "resources":{"cpu":1.5}
+      }
+    ]
+  }`)
+	gb := uint64(1024 * 1024 * 1024)
+	placement := NewResourcePlacement(hostsFor(t, "host1", "host2", "host3"), inspectHosts(map[string]*HostCapacity{
+		"host1": {MemoryAvailable: 4 * gb, Cpus: 4, Labels: map[string]string{"zone": "east"}},
+		"host2": {MemoryAvailable: 2 * gb, Cpus: 1},
+		"host3": {MemoryAvailable: 512 * 1024 * 1024, Cpus: 8, Labels: map[string]string{"zone": "west"}},
+	}))
+	next, _, err := dep.Describe(placement, loopbackTransport)
+	if err != nil {
+		t.Fatal("Should not have received an error", err)
+	}
+
+	on := make(map[string][]string)
+	for i := range next.Instances {
+		on[next.Instances[i].From] = append(on[next.Instances[i].From], *next.Instances[i].On)
+	}
+	if !reflect.DeepEqual(on["db"], []string{"host1", "host2"}) {
+		t.Fatalf("Expected db on the hosts with enough memory, one per host: %v", on["db"])
+	}
+	if !reflect.DeepEqual(on["cache"], []string{"host1"}) {
+		t.Fatalf("Expected cache on the labeled host: %v", on["cache"])
+	}
+	if !reflect.DeepEqual(on["web"], []string{"host1"}) {
+		t.Fatalf("Expected web next to the cache: %v", on["web"])
+	}
+}
+
+func TestResourcePlacementFailure(t *testing.T) {
+	dep := createDeployment(`{
+    "containers":[
+      {
+        "name":"db",
+        "count":3,
+        "image":"openshift/mongodb",
+        "antiaffinity":["db"]
+      }
+    ]
+  }`)
+	placement := NewResourcePlacement(hostsFor(t, "host1", "host2", "host3"), inspectHosts(map[string]*HostCapacity{
+		"host1": {Cpus: 1},
+		"host2": {Cpus: 1},
+	}))
+	_, _, err := dep.Describe(placement, loopbackTransport)
+	if err == nil {
+		t.Fatal("Expected an error when instances cannot be placed")
+	}
+	for _, reason := range []string{"db-3 (db)", "host1 already runs an instance of db", "host3 could not be inspected: host host3 is unreachable"} {
+		if !strings.Contains(err.Error(), reason) {
+			t.Fatalf("Expected the error to explain %q: %s", reason, err.Error())
+		}
+	}
+}
+
+func TestRouterUpdate(t *testing.T) {
+	dep := createDeployment(`{
+    "containers":[
+      {
+        "name":"web",
+        "count":2,
+        "image":"pmorie/sti-html-app",
+        "publicports":[
+          {"internal":8080,"external":0},
+          {"internal":8443,"external":0}
+        ],
+        "routes":[
+          {"host":"www.example.com"},
+          {"host":"secure.example.com","protocols":["tls"],"port":8443}
+        ]
+      },
+      {
+        "name":"db",
+        "count":1,
+        "image":"pmorie/sti-db-app"
+      }
+    ]
+  }`)
+	next, _, err := dep.Describe(oneHost, loopbackTransport)
+	if err != nil {
+		t.Fatal("Error when describing one host", err)
+	}
+	assignPorts(next)
+	// an instance that failed to install is not routed
+	next.Instances[1].Ports[0].External = 0
+
+	update, err := next.RouterUpdate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(update.Frontends) != 2 || len(update.Backends) != 2 {
+		t.Fatalf("Expected two frontends and two backends: %+v", update)
+	}
+	if f := update.Frontends[0]; f.Id != "web-route-1" || f.BackendId != "web-8080" || !reflect.DeepEqual(f.Protocols, []string{"http"}) {
+		t.Errorf("Unexpected frontend %+v", f)
+	}
+	if f := update.Frontends[1]; f.BackendId != "web-8443" || !reflect.DeepEqual(f.Protocols, []string{"tls"}) {
+		t.Errorf("Unexpected frontend %+v", f)
+	}
+	servers := update.Backends[0].Servers
+	if update.Backends[0].Id != "web-8080" || len(servers) != 1 {
+		t.Fatalf("Expected one server for the first backend: %+v", update.Backends[0])
+	}
+	if servers[0].Id != "web-1" || servers[0].Host != "127.0.0.1" || servers[0].Ports[0].Port != next.Instances[0].Ports[0].External {
+		t.Errorf("Unexpected server %+v", servers[0])
+	}
+	if servers := update.Backends[1].Servers; len(servers) != 2 || !reflect.DeepEqual(servers[0].Ports[0].Protocols, []string{"tls"}) {
+		t.Errorf("Unexpected servers for the tls backend: %+v", servers)
+	}
+}
+
+func TestRouterUpdateRemovesStaleRoutes(t *testing.T) {
+	dep := createDeployment(`{
+    "containers":[
+      {
+        "name":"web",
+        "count":1,
+        "image":"pmorie/sti-html-app",
+        "publicports":[
+          {"internal":8080,"external":0}
+        ],
+        "routes":[
+          {"host":"www.example.com"}
+        ]
+      }
+    ]
+  }`)
+	dep.Routed = &RouterIds{
+		Frontends: []router.Identifier{"web-route-1", "web-route-2"},
+		Backends:  []router.Identifier{"web-8080", "web-8443"},
+	}
+	next, _, err := dep.Describe(oneHost, loopbackTransport)
+	if err != nil {
+		t.Fatal("Error when describing one host", err)
+	}
+	assignPorts(next)
+
+	update, err := next.RouterUpdate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(update.Frontends) != 2 || update.Frontends[1].Id != "web-route-2" || update.Frontends[1].BackendId != "" {
+		t.Errorf("Expected the stale frontend to be removed: %+v", update.Frontends)
+	}
+	if !reflect.DeepEqual(update.RemoveBackends, []router.Identifier{"web-8443"}) {
+		t.Errorf("Expected the stale backend to be removed: %+v", update.RemoveBackends)
+	}
+	routed := RoutedBy(update)
+	if !reflect.DeepEqual(routed.Frontends, []router.Identifier{"web-route-1"}) || !reflect.DeepEqual(routed.Backends, []router.Identifier{"web-8080"}) {
+		t.Errorf("Unexpected routes after the update: %+v", routed)
+	}
+}
+
+func TestRouteMustBeToPublicPort(t *testing.T) {
+	dep := createDeployment(`{
+    "containers":[
+      {
+        "name":"web",
+        "count":1,
+        "image":"pmorie/sti-html-app",
+        "publicports":[{"internal":8080}],
+        "routes":[{"host":"www.example.com","port":9090}]
+      }
+    ]
+  }`)
+	if _, _, err := dep.Describe(oneHost, loopbackTransport); err == nil {
+		t.Fatal("Expected a route to a port that is not public to be rejected")
+	}
+}
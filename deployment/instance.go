@@ -25,6 +25,8 @@ type Instance struct {
 	add bool
 	// Is this instance flagged for removal
 	remove bool
+	// Is this instance being replaced by an instance with a newer image
+	replace bool
 
 	// The container this instance is associated with
 	container *Container
@@ -42,10 +44,24 @@ func (i *Instance) NetworkLinks() containers.NetworkLinks {
 	return i.links.NetworkLinks()
 }
 
+// The volumes the container definition mounts.
+func (i *Instance) Volumes() containers.VolumeMounts {
+	if i.container == nil {
+		return nil
+	}
+	return i.container.Volumes
+}
+
 func (i *Instance) Added() bool {
 	return i.add
 }
 
+// True if the image of the container has changed and this instance
+// will be replaced by a new one.
+func (i *Instance) Replaced() bool {
+	return i.replace
+}
+
 func (i *Instance) MarkRemoved() {
 	i.remove = true
 }
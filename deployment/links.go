@@ -33,13 +33,23 @@ type InstanceLink struct {
 	from     string
 	fromPort port.Port
 	matched  bool
+
+	// The instance this link points to
+	to containers.Identifier
+	// Is the instance this link points to being replaced
+	replaced bool
 }
 type InstanceLinks []InstanceLink
 
+// The links to instances that are part of the deployment - links to
+// replaced instances are omitted.
 func (links InstanceLinks) NetworkLinks() (dup containers.NetworkLinks) {
-	dup = make(containers.NetworkLinks, len(links))
+	dup = make(containers.NetworkLinks, 0, len(links))
 	for i := range links {
-		dup[i] = links[i].NetworkLink
+		if links[i].replaced {
+			continue
+		}
+		dup = append(dup, links[i].NetworkLink)
 	}
 	return
 }
@@ -203,6 +213,39 @@ func (link containerLink) appendLinks() error {
 					},
 					from:     link.Target.Name,
 					fromPort: port,
+					to:       target.Id,
+				})
+			}
+		}
+
+		// keep the existing links to replaced instances so that a rolling
+		// update can continue to use them until they are stopped
+		replacedInstances := link.Target.Replaced()
+		for j := range replacedInstances {
+			target := replacedInstances[j]
+			for k := range link.Ports {
+				port := link.Ports[k]
+				mapping, found := target.Ports.Find(port)
+				if !found || mapping.Target.Empty() {
+					continue
+				}
+				name, err := target.ResolveHostname()
+				if err != nil {
+					return err
+				}
+
+				instance.links = append(instance.links, InstanceLink{
+					NetworkLink: containers.NetworkLink{
+						FromHost: mapping.Target.Host,
+						FromPort: mapping.Target.Port,
+
+						ToPort: mapping.External,
+						ToHost: name,
+					},
+					from:     link.Target.Name,
+					fromPort: port,
+					to:       target.Id,
+					replaced: true,
 				})
 			}
 		}
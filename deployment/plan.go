@@ -0,0 +1,263 @@
+package deployment
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/openshift/geard/containers"
+	"github.com/openshift/geard/port"
+	"github.com/openshift/geard/transport"
+)
+
+type ChangeType string
+
+const (
+	AddInstance     ChangeType = "add"
+	RemoveInstance  ChangeType = "remove"
+	ReplaceInstance ChangeType = "replace"
+	MoveInstance    ChangeType = "move"
+	RelinkInstance  ChangeType = "relink"
+)
+
+// A change to a single instance on a host.
+type InstanceChange struct {
+	Type  ChangeType
+	Id    containers.Identifier
+	From  string
+	Image string `json:",omitempty"`
+
+	// The instance that is replaced or moved by this change, and the
+	// host it was on
+	Previous   containers.Identifier `json:",omitempty"`
+	PreviousOn string                `json:",omitempty"`
+
+	// The ports reserved for links by this change
+	Reserved PortMappings `json:",omitempty"`
+	// The links that will be written to the instance
+	Links containers.NetworkLinks `json:",omitempty"`
+}
+
+// The changes to the instances on one host.
+type HostPlan struct {
+	Host    string
+	Changes []InstanceChange
+}
+
+// The changes that deploying a descriptor will make, grouped by host.
+type Plan struct {
+	Hosts []HostPlan
+}
+
+type reservedPort struct {
+	id   containers.Identifier
+	port port.Port
+}
+
+// Describe the deployment and return the changes it would make without
+// altering any hosts.
+func (d Deployment) Plan(placement PlacementStrategy, t transport.Transport) (*Plan, error) {
+	// Describe assigns ports in place, so record the existing reservations first
+	existing := make(map[reservedPort]bool)
+	for i := range d.Instances {
+		instance := &d.Instances[i]
+		for j := range instance.Ports {
+			if !instance.Ports[j].Target.Empty() {
+				existing[reservedPort{instance.Id, instance.Ports[j].Internal}] = true
+			}
+		}
+	}
+
+	next, removed, err := d.Describe(placement, t)
+	if err != nil {
+		return nil, err
+	}
+	return newPlan(next, removed, existing), nil
+}
+
+func newPlan(next *Deployment, removed InstanceRefs, existing map[reservedPort]bool) *Plan {
+	hosts := make(map[string][]InstanceChange)
+	record := func(on *string, change InstanceChange) {
+		host := ""
+		if on != nil {
+			host = *on
+		}
+		hosts[host] = append(hosts[host], change)
+	}
+
+	added := make(map[containers.Identifier]bool)
+	removedFrom := make(map[string]bool)
+	for i := range removed {
+		removedFrom[removed[i].From] = true
+	}
+
+	for i := range next.Containers {
+		name := next.Containers[i].Name
+
+		// pair new instances with the instances they replace or move
+		replaced := InstanceRefs{}
+		moved := InstanceRefs{}
+		for j := range removed {
+			if removed[j].From != name {
+				continue
+			}
+			if removed[j].replace {
+				replaced = append(replaced, removed[j])
+			} else {
+				moved = append(moved, removed[j])
+			}
+		}
+
+		for j := range next.Instances {
+			instance := &next.Instances[j]
+			if !instance.add || instance.From != name {
+				continue
+			}
+			added[instance.Id] = true
+
+			change := InstanceChange{
+				Type:     AddInstance,
+				Id:       instance.Id,
+				From:     instance.From,
+				Image:    instance.Image,
+				Reserved: instance.reservedPorts(existing),
+				Links:    instance.NetworkLinks(),
+			}
+			switch {
+			case len(replaced) > 0:
+				change.Type = ReplaceInstance
+				change.Previous, change.PreviousOn = replaced[0].Id, *replaced[0].On
+				replaced = replaced[1:]
+			case len(moved) > 0:
+				change.Type = MoveInstance
+				change.Previous, change.PreviousOn = moved[0].Id, *moved[0].On
+				moved = moved[1:]
+			}
+			record(instance.On, change)
+		}
+
+		for _, instance := range replaced {
+			record(instance.On, InstanceChange{Type: RemoveInstance, Id: instance.Id, From: instance.From})
+		}
+		for _, instance := range moved {
+			record(instance.On, InstanceChange{Type: RemoveInstance, Id: instance.Id, From: instance.From})
+		}
+	}
+
+	// instances of containers that are no longer in the deployment
+	for i := range removed {
+		if _, found := next.Containers.Find(removed[i].From); !found {
+			record(removed[i].On, InstanceChange{Type: RemoveInstance, Id: removed[i].Id, From: removed[i].From})
+		}
+	}
+
+	// existing instances are relinked when ports are reserved or their
+	// targets change
+	for i := range next.Instances {
+		instance := &next.Instances[i]
+		if instance.add {
+			continue
+		}
+		reserved := instance.reservedPorts(existing)
+		relinked := false
+		for j := range instance.links {
+			link := &instance.links[j]
+			if link.replaced || added[link.to] || removedFrom[link.from] {
+				relinked = true
+				break
+			}
+		}
+		if len(reserved) == 0 && !relinked {
+			continue
+		}
+		record(instance.On, InstanceChange{
+			Type:     RelinkInstance,
+			Id:       instance.Id,
+			From:     instance.From,
+			Reserved: reserved,
+			Links:    instance.NetworkLinks(),
+		})
+	}
+
+	plan := &Plan{Hosts: make([]HostPlan, 0, len(hosts))}
+	for host, changes := range hosts {
+		plan.Hosts = append(plan.Hosts, HostPlan{host, changes})
+	}
+	sort.Sort(hostPlansByName(plan.Hosts))
+	return plan
+}
+
+// The port mappings with a link reservation that did not exist before.
+func (i *Instance) reservedPorts(existing map[reservedPort]bool) PortMappings {
+	reserved := PortMappings{}
+	for j := range i.Ports {
+		mapping := i.Ports[j]
+		if mapping.Target.Empty() || existing[reservedPort{i.Id, mapping.Internal}] {
+			continue
+		}
+		reserved = append(reserved, mapping)
+	}
+	return reserved
+}
+
+// True if the plan makes no changes.
+func (p *Plan) Empty() bool {
+	return len(p.Hosts) == 0
+}
+
+type hostPlansByName []HostPlan
+
+func (a hostPlansByName) Len() int           { return len(a) }
+func (a hostPlansByName) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a hostPlansByName) Less(i, j int) bool { return a[i].Host < a[j].Host }
+
+func (p *Plan) WriteTextTo(w io.Writer) error {
+	if p.Empty() {
+		_, err := fmt.Fprintf(w, "No changes\n")
+		return err
+	}
+	for _, host := range p.Hosts {
+		if _, err := fmt.Fprintf(w, "==> %s\n", host.Host); err != nil {
+			return err
+		}
+		for _, change := range host.Changes {
+			if err := change.writeTo(w); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *InstanceChange) writeTo(w io.Writer) error {
+	var err error
+	switch c.Type {
+	case ReplaceInstance:
+		_, err = fmt.Fprintf(w, "  %-8s %s (%s) from %s replacing %s on %s\n", c.Type, c.Id, c.From, c.Image, c.Previous, c.PreviousOn)
+	case MoveInstance:
+		_, err = fmt.Fprintf(w, "  %-8s %s (%s) from %s moving %s from %s\n", c.Type, c.Id, c.From, c.Image, c.Previous, c.PreviousOn)
+	case AddInstance:
+		_, err = fmt.Fprintf(w, "  %-8s %s (%s) from %s\n", c.Type, c.Id, c.From, c.Image)
+	default:
+		_, err = fmt.Fprintf(w, "  %-8s %s (%s)\n", c.Type, c.Id, c.From)
+	}
+	if err != nil {
+		return err
+	}
+	for _, mapping := range c.Reserved {
+		if _, err := fmt.Fprintf(w, "             reserve %s for port %d\n", mapping.Target.String(), mapping.Internal); err != nil {
+			return err
+		}
+	}
+	for _, link := range c.Links {
+		// the external port is not known until the target is installed
+		to := link.ToHost + ":pending"
+		if !link.ToPort.Default() {
+			to = port.HostPort{Host: link.ToHost, Port: link.ToPort}.String()
+		}
+		if _, err := fmt.Fprintf(w, "             link    %s:%d -> %s\n", link.FromHost, link.FromPort, to); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -76,29 +76,38 @@ type InstancePortTable struct {
 func NewInstancePortTable(sources Containers) (*InstancePortTable, error) {
 	table := &InstancePortTable{make(map[port.HostPort]bool)}
 
-	// make existing reservations
+	// make existing reservations, including those of instances that are
+	// being replaced and will run alongside their replacements
 	for i := range sources {
-		instances := sources[i].Instances()
-
-		for j := range instances {
-			instance := instances[j]
-			for k := range instance.Ports {
-				target := instance.Ports[k].Target
-				if target.Empty() {
-					continue
-				}
-
-				_, found := table.reserved[target]
-				if found {
-					return nil, errors.New(fmt.Sprintf("deployment: The port %s is assigned to multiple instances (last: %s)", target.String(), instance.Id))
-				}
-				table.reserved[target] = true
-			}
+		if err := table.reserveExisting(sources[i].Instances()); err != nil {
+			return nil, err
+		}
+		if err := table.reserveExisting(sources[i].Replaced()); err != nil {
+			return nil, err
 		}
 	}
 	return table, nil
 }
 
+func (p *InstancePortTable) reserveExisting(instances InstanceRefs) error {
+	for j := range instances {
+		instance := instances[j]
+		for k := range instance.Ports {
+			target := instance.Ports[k].Target
+			if target.Empty() {
+				continue
+			}
+
+			_, found := p.reserved[target]
+			if found {
+				return errors.New(fmt.Sprintf("deployment: The port %s is assigned to multiple instances (last: %s)", target.String(), instance.Id))
+			}
+			p.reserved[target] = true
+		}
+	}
+	return nil
+}
+
 func (p *InstancePortTable) Reserve(loopback, same bool, from port.Port) port.HostPort {
 	switch {
 	case same && loopback:
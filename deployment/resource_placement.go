@@ -0,0 +1,182 @@
+package deployment
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/openshift/geard/transport"
+)
+
+// The capacity and usage of a host at the time it was inspected.
+type HostCapacity struct {
+	Containers      int
+	MemoryAvailable uint64
+	Cpus            int
+	Load            float64
+	Labels          map[string]string
+}
+
+// Retrieve the current capacity of a host.
+type HostInspector func(on transport.Locator) (*HostCapacity, error)
+
+// Places each instance on the host with the most free capacity that
+// satisfies the resource requests, affinity rules, and host labels of
+// its container.  Instances of the same container are spread across
+// hosts when possible.
+type ResourcePlacement struct {
+	locators transport.Locators
+	inspect  HostInspector
+}
+
+func NewResourcePlacement(locators transport.Locators, inspect HostInspector) *ResourcePlacement {
+	return &ResourcePlacement{locators, inspect}
+}
+
+func (p *ResourcePlacement) RemoveFromLocation(on transport.Locator) bool {
+	return SimplePlacement(p.locators).RemoveFromLocation(on)
+}
+
+func (p *ResourcePlacement) Assign(added InstanceRefs, sources Containers) error {
+	hosts := make([]*placementHost, 0, len(p.locators))
+	for _, locator := range p.locators {
+		host := &placementHost{locator: locator, instances: make(map[string]int)}
+		if capacity, err := p.inspect(locator); err != nil {
+			host.err = err
+		} else {
+			host.labels = capacity.Labels
+			host.memory = int64(capacity.MemoryAvailable)
+			host.cpu = float64(capacity.Cpus) - capacity.Load
+			host.total = capacity.Containers
+		}
+		hosts = append(hosts, host)
+	}
+
+	// count the instances that are already on each host
+	for i := range sources {
+		for _, instance := range sources[i].Instances() {
+			if instance.add || instance.On == nil {
+				continue
+			}
+			for _, host := range hosts {
+				if host.locator.String() == *instance.On {
+					host.instances[instance.From]++
+				}
+			}
+		}
+	}
+
+	// instances that must be colocated are placed after the instances
+	// they depend on
+	ordered := make(InstanceRefs, 0, len(added))
+	for i := range added {
+		if len(added[i].container.Colocate) == 0 {
+			ordered = append(ordered, added[i])
+		}
+	}
+	for i := range added {
+		if len(added[i].container.Colocate) != 0 {
+			ordered = append(ordered, added[i])
+		}
+	}
+
+	failures := []string{}
+	for _, instance := range ordered {
+		c := instance.container
+		var best *placementHost
+		reasons := []string{}
+		for _, host := range hosts {
+			if reason := host.reject(c); reason != "" {
+				reasons = append(reasons, host.locator.String()+" "+reason)
+				continue
+			}
+			if best == nil || host.preferredTo(best, c) {
+				best = host
+			}
+		}
+		if best == nil {
+			if len(hosts) == 0 {
+				reasons = append(reasons, "no hosts were specified")
+			}
+			failures = append(failures, fmt.Sprintf("%s (%s): %s", instance.Id, c.Name, strings.Join(reasons, "; ")))
+			continue
+		}
+		instance.Place(best.locator)
+		best.reserve(c)
+	}
+	if len(failures) > 0 {
+		return errors.New("deployment: Unable to place one or more instances:\n  " + strings.Join(failures, "\n  "))
+	}
+	return nil
+}
+
+// The remaining capacity of a host as instances are assigned to it.
+type placementHost struct {
+	locator transport.Locator
+	err     error
+
+	labels    map[string]string
+	memory    int64
+	cpu       float64
+	total     int
+	instances map[string]int
+}
+
+// Return the reason an instance of the container cannot be placed on
+// this host, or an empty string.
+func (h *placementHost) reject(c *Container) string {
+	if h.err != nil {
+		return fmt.Sprintf("could not be inspected: %s", h.err.Error())
+	}
+	keys := make([]string, 0, len(c.HostLabels))
+	for k := range c.HostLabels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if actual, ok := h.labels[k]; !ok || actual != c.HostLabels[k] {
+			return fmt.Sprintf("does not have the label %s=%s", k, c.HostLabels[k])
+		}
+	}
+	for _, name := range c.AntiAffinity {
+		if h.instances[name] > 0 {
+			return fmt.Sprintf("already runs an instance of %s", name)
+		}
+	}
+	for _, name := range c.Colocate {
+		if h.instances[name] == 0 {
+			return fmt.Sprintf("does not run an instance of %s", name)
+		}
+	}
+	if r := c.Resources; r != nil {
+		if r.Memory > 0 && int64(r.Memory) > h.memory {
+			return fmt.Sprintf("does not have enough memory (%d MB requested, %d MB available)", r.Memory/(1024*1024), h.memory/(1024*1024))
+		}
+		if r.Cpu > 0 && r.Cpu > h.cpu {
+			return fmt.Sprintf("does not have enough CPU (%.2f requested, %.2f available)", r.Cpu, h.cpu)
+		}
+	}
+	return ""
+}
+
+// Prefer hosts with the fewest instances of the container, then the
+// most free memory, then the fewest containers.
+func (h *placementHost) preferredTo(other *placementHost, c *Container) bool {
+	if h.instances[c.Name] != other.instances[c.Name] {
+		return h.instances[c.Name] < other.instances[c.Name]
+	}
+	if h.memory != other.memory {
+		return h.memory > other.memory
+	}
+	return h.total < other.total
+}
+
+func (h *placementHost) reserve(c *Container) {
+	h.instances[c.Name]++
+	h.total++
+	if r := c.Resources; r != nil {
+		h.memory -= int64(r.Memory)
+		h.cpu -= r.Cpu
+	}
+}
@@ -0,0 +1,160 @@
+package deployment
+
+import (
+	"errors"
+
+	"github.com/openshift/geard/containers"
+)
+
+// Replace the instances of a container whose image has changed in
+// batches, so that some instances remain available throughout.
+type RollingUpdate struct {
+	// The number of old instances of a container that may be stopped
+	// before their replacements are active.
+	MaxUnavailable int
+	// The number of new instances of a container that may be started
+	// in addition to the old instances that are still running.
+	MaxSurge int
+}
+
+// One step of a rolling update.
+type RolloutBatch struct {
+	// Old instances stopped before the batch is started
+	Stopped InstanceRefs
+	// New instances installed and started by the batch
+	Added InstanceRefs
+	// Old instances stopped once every added instance is active
+	Retired InstanceRefs
+}
+
+// The batches of a rolling update and the set of instances that are
+// running at each point, used to rewire links as the update progresses.
+type Rollout struct {
+	Batches []RolloutBatch
+
+	next     *Deployment
+	replaced InstanceRefs
+	live     map[containers.Identifier]bool
+}
+
+func (r RollingUpdate) Check() error {
+	if r.MaxUnavailable < 0 || r.MaxSurge < 0 {
+		return errors.New("deployment: max unavailable and max surge may not be negative")
+	}
+	if r.MaxUnavailable == 0 && r.MaxSurge == 0 {
+		return errors.New("deployment: one of max unavailable or max surge must be greater than zero")
+	}
+	return nil
+}
+
+// Plan the batches that replace the changed instances returned by
+// Describe.  Removed instances that are not being replaced are not part
+// of the rollout, and added instances with nothing to replace are started
+// in the first batches of their container.
+func (r RollingUpdate) Plan(next *Deployment, removed InstanceRefs) (*Rollout, error) {
+	if err := r.Check(); err != nil {
+		return nil, err
+	}
+
+	rollout := &Rollout{
+		Batches:  []RolloutBatch{},
+		next:     next,
+		replaced: InstanceRefs{},
+		live:     make(map[containers.Identifier]bool),
+	}
+	for i := range next.Instances {
+		if !next.Instances[i].add {
+			rollout.live[next.Instances[i].Id] = true
+		}
+	}
+	for i := range removed {
+		if removed[i].replace {
+			rollout.replaced = append(rollout.replaced, removed[i])
+			rollout.live[removed[i].Id] = true
+		}
+	}
+
+	step := r.MaxUnavailable + r.MaxSurge
+	for i := range next.Containers {
+		name := next.Containers[i].Name
+		added := InstanceRefs{}
+		for j := range next.Instances {
+			if next.Instances[j].add && next.Instances[j].From == name {
+				added = append(added, &next.Instances[j])
+			}
+		}
+		old := InstanceRefs{}
+		for j := range rollout.replaced {
+			if rollout.replaced[j].From == name {
+				old = append(old, rollout.replaced[j])
+			}
+		}
+
+		for k := 0; k < len(added) || k < len(old); k += step {
+			batch := RolloutBatch{Added: added.slice(k, step)}
+			stopping := old.slice(k, step)
+			stop := r.MaxUnavailable
+			if stop > len(stopping) {
+				stop = len(stopping)
+			}
+			batch.Stopped = stopping[:stop]
+			batch.Retired = stopping[stop:]
+			rollout.Batches = append(rollout.Batches, batch)
+		}
+	}
+	return rollout, nil
+}
+
+func (refs InstanceRefs) slice(from, count int) InstanceRefs {
+	if from >= len(refs) {
+		return InstanceRefs{}
+	}
+	to := from + count
+	if to > len(refs) {
+		to = len(refs)
+	}
+	return refs[from:to]
+}
+
+// The old instances that are replaced by the rollout.
+func (r *Rollout) Replaced() InstanceRefs {
+	return r.replaced
+}
+
+// Mark instances as running so that other instances will be linked to
+// them.
+func (r *Rollout) Activate(instances InstanceRefs) {
+	for i := range instances {
+		r.live[instances[i].Id] = true
+	}
+}
+
+// Mark instances as stopped so that no instances are linked to them.
+func (r *Rollout) Deactivate(instances InstanceRefs) {
+	for i := range instances {
+		delete(r.live, instances[i].Id)
+	}
+}
+
+// The running instances of the deployment that have links.
+func (r *Rollout) Linked() InstanceRefs {
+	linked := InstanceRefs{}
+	for i := range r.next.Instances {
+		instance := &r.next.Instances[i]
+		if r.live[instance.Id] && len(instance.links) > 0 {
+			linked = append(linked, instance)
+		}
+	}
+	return linked
+}
+
+// The links of an instance to the instances that are currently running.
+func (r *Rollout) NetworkLinks(instance *Instance) containers.NetworkLinks {
+	links := make(containers.NetworkLinks, 0, len(instance.links))
+	for i := range instance.links {
+		if r.live[instance.links[i].to] {
+			links = append(links, instance.links[i].NetworkLink)
+		}
+	}
+	return links
+}
@@ -0,0 +1,235 @@
+package deployment
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/openshift/geard/port"
+	"github.com/openshift/geard/router"
+	rjobs "github.com/openshift/geard/router/jobs"
+)
+
+// An external host name (and optional path) that the router sends to
+// the instances of a container.
+type Route struct {
+	Host string
+	Path string `json:"Path,omitempty"`
+	// http, https, or tls - defaults to http
+	Protocols []string `json:"Protocols,omitempty"`
+	// A certificate already held by the router, required for https
+	CertificateId router.Identifier `json:"CertificateId,omitempty"`
+	// The internal port traffic is sent to - defaults to the first public
+	// port of the container
+	Port port.Port `json:"Port,omitempty"`
+}
+type Routes []Route
+
+type RouterIds struct {
+	Frontends []router.Identifier
+	Backends  []router.Identifier
+}
+
+func (r *Route) protocols() []string {
+	if len(r.Protocols) == 0 {
+		return []string{router.ProtocolHttp}
+	}
+	return r.Protocols
+}
+
+// The internal port of the container the route is sent to.
+func (r *Route) target(c *Container) (port.Port, error) {
+	if len(c.PublicPorts) == 0 {
+		return 0, errors.New(fmt.Sprintf("The container %s has routes but no public ports", c.Name))
+	}
+	if r.Port.Default() {
+		return c.PublicPorts[0].Internal, nil
+	}
+	for i := range c.PublicPorts {
+		if c.PublicPorts[i].Internal == r.Port {
+			return r.Port, nil
+		}
+	}
+	return 0, errors.New(fmt.Sprintf("The route %s of container %s is to port %d, which is not a public port", r.Host, c.Name, r.Port))
+}
+
+// Router identifiers may not contain the '.' allowed in container
+// identifiers.
+func routeIdentifier(s string) router.Identifier {
+	return router.Identifier(strings.Replace(s, ".", "_", -1))
+}
+
+func (d *Deployment) backendIdFor(c *Container, p port.Port) router.Identifier {
+	return routeIdentifier(d.IdPrefix + c.Name + "-" + strconv.Itoa(int(p)))
+}
+
+func (d *Deployment) frontendIdFor(c *Container, index int) router.Identifier {
+	return routeIdentifier(d.IdPrefix + c.Name + "-route-" + strconv.Itoa(index+1))
+}
+
+func (d *Deployment) frontendFor(c *Container, index int) (*rjobs.FrontendDescription, error) {
+	route := &c.Routes[index]
+	target, err := route.target(c)
+	if err != nil {
+		return nil, err
+	}
+	f := &rjobs.FrontendDescription{
+		Frontend: router.Frontend{
+			Id:        d.frontendIdFor(c, index),
+			Host:      route.Host,
+			Path:      route.Path,
+			Protocols: route.protocols(),
+			BackendId: d.backendIdFor(c, target),
+		},
+		CertificateId: route.CertificateId,
+	}
+	if err := f.Check(); err != nil {
+		return nil, errors.New(fmt.Sprintf("The route %s of container %s is not valid: %s", route.Host, c.Name, err.Error()))
+	}
+	return f, nil
+}
+
+// True if the deployment has routes, or routed containers on the last
+// deployment whose routes must be removed.
+func (d *Deployment) HasRoutes() bool {
+	if d.Routed != nil && (len(d.Routed.Frontends) > 0 || len(d.Routed.Backends) > 0) {
+		return true
+	}
+	for i := range d.Containers {
+		if len(d.Containers[i].Routes) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Deployment) checkRoutes() error {
+	for i := range d.Containers {
+		c := &d.Containers[i]
+		for j := range c.Routes {
+			if _, err := d.frontendFor(c, j); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// The frontends and backends that send the routes of each container to
+// its current instances.  Each routed port of a container is a backend
+// with a server for every instance that has an external port assigned.
+// Frontends and backends routed by the last deployment that are no
+// longer needed are removed.
+func (d *Deployment) RouterUpdate() (*rjobs.UpdateFrontendRequest, error) {
+	update := &rjobs.UpdateFrontendRequest{}
+	for i := range d.Containers {
+		c := &d.Containers[i]
+		if len(c.Routes) == 0 {
+			continue
+		}
+
+		// the protocols each routed port must accept
+		targets := make(map[port.Port][]string)
+		for j := range c.Routes {
+			f, err := d.frontendFor(c, j)
+			if err != nil {
+				return nil, err
+			}
+			update.Frontends = append(update.Frontends, *f)
+
+			target, _ := c.Routes[j].target(c)
+			for _, protocol := range f.Protocols {
+				if protocol == router.ProtocolTls {
+					targets[target] = appendProtocol(targets[target], router.ProtocolTls)
+				} else {
+					targets[target] = appendProtocol(targets[target], router.ProtocolHttp)
+				}
+			}
+		}
+
+		ports := make(port.PortPairs, 0, len(targets))
+		for p := range targets {
+			ports = append(ports, port.PortPair{Internal: p})
+		}
+		sort.Sort(byInternalPort(ports))
+
+		for _, p := range ports {
+			backend := rjobs.BackendDescription{Backend: router.Backend{Id: d.backendIdFor(c, p.Internal), Servers: router.Servers{}}}
+			for j := range d.Instances {
+				instance := &d.Instances[j]
+				if instance.From != c.Name || instance.On == nil {
+					continue
+				}
+				mapping, found := instance.Ports.Find(p.Internal)
+				if !found || mapping.External.Default() {
+					continue
+				}
+				host, err := instance.ResolveHostname()
+				if err != nil {
+					return nil, err
+				}
+				backend.Servers = append(backend.Servers, router.Server{
+					Id:    routeIdentifier(string(instance.Id)),
+					Host:  host,
+					Ports: router.Ports{{Port: mapping.External, Protocols: targets[p.Internal]}},
+				})
+			}
+			update.Backends = append(update.Backends, backend)
+		}
+	}
+
+	if d.Routed != nil {
+		routed := RoutedBy(update)
+		for _, id := range d.Routed.Frontends {
+			if !hasIdentifier(routed.Frontends, id) {
+				update.Frontends = append(update.Frontends, rjobs.FrontendDescription{Frontend: router.Frontend{Id: id}})
+			}
+		}
+		for _, id := range d.Routed.Backends {
+			if !hasIdentifier(routed.Backends, id) {
+				update.RemoveBackends = append(update.RemoveBackends, id)
+			}
+		}
+	}
+	return update, nil
+}
+
+// The frontends and backends that remain on the router after an update.
+func RoutedBy(update *rjobs.UpdateFrontendRequest) *RouterIds {
+	routed := &RouterIds{}
+	for i := range update.Frontends {
+		if update.Frontends[i].BackendId != "" {
+			routed.Frontends = append(routed.Frontends, update.Frontends[i].Id)
+		}
+	}
+	for i := range update.Backends {
+		routed.Backends = append(routed.Backends, update.Backends[i].Id)
+	}
+	return routed
+}
+
+func hasIdentifier(ids []router.Identifier, id router.Identifier) bool {
+	for i := range ids {
+		if ids[i] == id {
+			return true
+		}
+	}
+	return false
+}
+
+func appendProtocol(protocols []string, protocol string) []string {
+	for i := range protocols {
+		if protocols[i] == protocol {
+			return protocols
+		}
+	}
+	return append(protocols, protocol)
+}
+
+type byInternalPort port.PortPairs
+
+func (a byInternalPort) Len() int           { return len(a) }
+func (a byInternalPort) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a byInternalPort) Less(i, j int) bool { return a[i].Internal < a[j].Internal }
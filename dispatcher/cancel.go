@@ -0,0 +1,69 @@
+package dispatcher
+
+import (
+	"log"
+
+	"github.com/openshift/geard/jobs"
+)
+
+var (
+	ErrJobAlreadyFinished = jobs.SimpleError{Failure: jobs.ResponseAlreadyExists, Reason: "The job has already finished."}
+	ErrJobNotCancelable   = jobs.SimpleError{Failure: jobs.ResponseInvalidRequest, Reason: "The job is running and cannot be interrupted."}
+)
+
+// Cancel a job.  A queued job is removed from the queue without being
+// executed, a running job is interrupted if it implements
+// jobs.CancelableJob.  Only the client that submitted a job may cancel
+// it.
+func (d *Dispatcher) Cancel(id jobs.RequestIdentifier, client string) error {
+	tracker := d.trackerFor(id, client)
+	if tracker == nil {
+		if _, err := d.Status(id, client); err != nil {
+			return err
+		}
+		return ErrJobAlreadyFinished
+	}
+
+	cancelable, interruptible := tracker.job.(jobs.CancelableJob)
+	switch tracker.response.cancel(interruptible) {
+	case JobQueued:
+		log.Printf("job %s canceled while queued", id.String())
+		// if a worker has already taken the job it will be skipped
+		if t, ok := d.queueFor(tracker.job).remove(id); ok {
+			d.canceled(t)
+		}
+		return nil
+	case JobRunning:
+		if !interruptible {
+			return ErrJobNotCancelable
+		}
+		if err := cancelable.Cancel(); err != nil {
+			tracker.response.uncancel()
+			return err
+		}
+		log.Printf("job %s canceled while running", id.String())
+		return nil
+	}
+	return ErrJobAlreadyFinished
+}
+
+// Stop a queued or running job.
+type CancelJobRequest struct {
+	Id     jobs.RequestIdentifier
+	Client string `json:"-"`
+}
+
+type cancelJob struct {
+	*CancelJobRequest
+	dispatcher *Dispatcher
+}
+
+func (j *cancelJob) Fast() bool { return true }
+
+func (j *cancelJob) Execute(resp jobs.Response) {
+	if err := j.dispatcher.Cancel(j.Id, j.Client); err != nil {
+		resp.Failure(err)
+		return
+	}
+	resp.Success(jobs.ResponseOk)
+}
@@ -5,9 +5,10 @@
 package dispatcher
 
 import (
-	"errors"
 	"log"
 	"reflect"
+	"sync/atomic"
+	"time"
 
 	"github.com/openshift/geard/jobs"
 )
@@ -18,50 +19,122 @@ type Dispatcher struct {
 	Concurrent        int
 	TrackDuplicateIds int
 
-	fastJobs   chan jobTracker
-	slowJobs   chan jobTracker
+	// If set, every accepted job is recorded on disk so that duplicate
+	// requests are detected across restarts and unfinished jobs can be
+	// recovered.
+	Journal *Journal
+	// Finished journal entries older than this are removed on start.
+	JournalRetention time.Duration
+	// The number of bytes of streamed output retained for each job.
+	JobOutputLimit int
+
+	// The maximum number of jobs a single client may have waiting in each
+	// queue, 0 for no limit.
+	UserQueueDepth int
+	// The maximum number of jobs a single client may have running from each
+	// queue, 0 for no limit.
+	UserConcurrent int
+	// The relative share of workers given to each priority class,
+	// DefaultPriorityWeights if nil.
+	PriorityWeights map[Priority]int
+
+	fastJobs   *jobQueue
+	slowJobs   *jobQueue
 	recentJobs *RequestIdentifierMap
+	continuous int32
 }
 
 type Fast interface {
 	Fast() bool
 }
 
+// Jobs that only read state are not recorded in the journal.
+type ReadOnly interface {
+	ReadOnly() bool
+}
+
+// Jobs that run until they are canceled, such as event streams, are
+// started immediately outside of the queues and are not recorded in the
+// journal.
+type Continuous interface {
+	Continuous() bool
+}
+
+func isContinuous(j jobs.Job) bool {
+	c, ok := j.(Continuous)
+	return ok && c.Continuous()
+}
+
 func (d *Dispatcher) Start() {
 	d.recentJobs = NewRequestIdentifierMap(d.TrackDuplicateIds)
-	d.fastJobs = make(chan jobTracker, d.QueueFast)
-	d.slowJobs = make(chan jobTracker, d.QueueSlow)
+	weights := d.PriorityWeights
+	if weights == nil {
+		weights = DefaultPriorityWeights
+	}
+	d.fastJobs = newJobQueue(d.QueueFast, d.UserQueueDepth, d.UserConcurrent, weights)
+	d.slowJobs = newJobQueue(d.QueueSlow, d.UserQueueDepth, d.UserConcurrent, weights)
 	for i := 0; i < d.Concurrent; i++ {
 		d.work(d.fastJobs)
 		d.work(d.slowJobs)
 	}
+	if d.Journal != nil {
+		d.recover()
+	}
 }
 
-func (d *Dispatcher) work(queue <-chan jobTracker) {
+func (d *Dispatcher) work(queue *jobQueue) {
 	go func() {
-		for tracker := range queue {
-			id := tracker.id
-			log.Printf("job START %s, %s: %+v", reflect.TypeOf(tracker.job).String(), id.String(), tracker.job)
-			tracker.job.Execute(tracker.response)
-			log.Printf("job END   %s", id.String())
-			close(tracker.complete)
-			d.recentJobs.Put(id, nil)
+		for {
+			tracker := queue.pop()
+			d.run(tracker)
+			queue.done(tracker.user)
 		}
 	}()
 }
 
+func (d *Dispatcher) run(tracker jobTracker) {
+	id := tracker.id
+	if !tracker.response.running() {
+		d.canceled(tracker)
+		return
+	}
+	log.Printf("job START %s, %s: %+v", reflect.TypeOf(tracker.job).String(), id.String(), tracker.job)
+	d.transition(tracker, JobRunning)
+	started := time.Now()
+	tracker.job.Execute(tracker.response)
+	jobDurations.Observe(time.Since(started).Seconds(), jobTypeName(tracker.job))
+	log.Printf("job END   %s", id.String())
+	d.complete(tracker)
+}
+
 type jobTracker struct {
-	id       jobs.RequestIdentifier
-	job      jobs.Job
-	response jobs.Response
-	complete chan bool
+	id        jobs.RequestIdentifier
+	user      string
+	priority  Priority
+	job       jobs.Job
+	response  *trackingResponse
+	complete  chan bool
+	journaled bool
 }
 
-func (d *Dispatcher) Dispatch(id jobs.RequestIdentifier, j jobs.Job, resp jobs.Response) (done <-chan bool, err error) {
+// Queue a job for execution on behalf of the client in the provided
+// context.  Jobs are scheduled by priority class and then round robin
+// between clients.
+func (d *Dispatcher) Dispatch(context *jobs.JobContext, j jobs.Job, resp jobs.Response) (done <-chan bool, err error) {
+	id := context.Id
 	complete := make(chan bool)
-	tracker := jobTracker{id, j, resp, complete}
+	journaled := d.Journal != nil
+	if r, ok := j.(ReadOnly); ok && r.ReadOnly() {
+		journaled = false
+	}
+	continuous := isContinuous(j)
+	if continuous {
+		journaled = false
+	}
+	tracker := jobTracker{id, context.Client, priorityOf(j), j, newTrackingResponse(resp, d.JobOutputLimit), complete, journaled}
 
-	if existing, found := d.recentJobs.Put(id, tracker); found {
+	existing, found := d.recentJobs.Put(id, tracker)
+	if found {
 		var join jobs.Join
 		if existing != nil {
 			other, _ := existing.(jobTracker)
@@ -92,23 +165,41 @@ func (d *Dispatcher) Dispatch(id jobs.RequestIdentifier, j jobs.Job, resp jobs.R
 			return
 		}
 		log.Println("Queueing an already existing job ", j)
+	} else if journaled {
+		if _, errg := d.Journal.Get(id); errg == nil {
+			d.recentJobs.Put(id, nil)
+			err = jobs.ErrRanToCompletion
+			return
+		}
 	}
 
-	var queue chan jobTracker
-	fast := false
-	if f, ok := j.(Fast); ok {
-		fast = f.Fast()
+	if continuous {
+		go func() {
+			atomic.AddInt32(&d.continuous, 1)
+			defer atomic.AddInt32(&d.continuous, -1)
+			d.run(tracker)
+		}()
+		done = complete
+		return
 	}
-	if fast {
-		queue = d.fastJobs
-	} else {
-		queue = d.slowJobs
+
+	queue := d.queueFor(j)
+
+	if journaled {
+		if errj := d.Journal.Queued(id, context.Client, j); errj != nil {
+			log.Printf("dispatcher: Unable to record job %s in the journal: %v", id.String(), errj)
+		}
 	}
 
-	select {
-	case queue <- tracker:
-	default:
-		err = errors.New("The server is at maximum capacity - please try again shortly")
+	if errq := queue.push(tracker, false); errq != nil {
+		if journaled {
+			d.Journal.Remove(id)
+		}
+		// the job was never accepted, so the client may retry it
+		if !found {
+			d.recentJobs.Remove(id)
+		}
+		err = errq
 		return
 	}
 
@@ -116,6 +207,103 @@ func (d *Dispatcher) Dispatch(id jobs.RequestIdentifier, j jobs.Job, resp jobs.R
 	return
 }
 
+func (d *Dispatcher) queueFor(j jobs.Job) *jobQueue {
+	if f, ok := j.(Fast); ok && f.Fast() {
+		return d.fastJobs
+	}
+	return d.slowJobs
+}
+
+func (d *Dispatcher) transition(tracker jobTracker, state JobState) {
+	if !tracker.journaled {
+		return
+	}
+	if err := d.Journal.Transition(tracker.id, state); err != nil {
+		log.Printf("dispatcher: Unable to record job %s as %s: %v", tracker.id.String(), state, err)
+	}
+}
+
+func (d *Dispatcher) finish(tracker jobTracker) {
+	state, result, output := tracker.response.finish()
+	if state == JobFailed {
+		jobFailures.Inc(jobTypeName(tracker.job), failureName(result.Failure))
+	}
+	if !tracker.journaled {
+		return
+	}
+	if err := d.Journal.Finish(tracker.id, state, result, output); err != nil {
+		log.Printf("dispatcher: Unable to record job %s as %s: %v", tracker.id.String(), state, err)
+	}
+}
+
+// Record the outcome of a job and release any callers waiting on it.
+func (d *Dispatcher) complete(tracker jobTracker) {
+	d.finish(tracker)
+	close(tracker.complete)
+	d.recentJobs.Put(tracker.id, nil)
+}
+
+func (d *Dispatcher) canceled(tracker jobTracker) {
+	tracker.response.Response.Failure(jobs.ErrJobCanceled)
+	log.Printf("job CANCELED %s", tracker.id.String())
+	d.complete(tracker)
+}
+
+func (d *Dispatcher) failed(id jobs.RequestIdentifier) {
+	if err := d.Journal.Transition(id, JobFailed); err != nil {
+		log.Printf("dispatcher: Unable to record job %s as %s: %v", id.String(), JobFailed, err)
+	}
+}
+
+// Replay or fail any jobs that were queued or running when the server
+// last stopped.
+func (d *Dispatcher) recover() {
+	if d.JournalRetention != 0 {
+		if err := d.Journal.Prune(time.Now().Add(-d.JournalRetention)); err != nil {
+			log.Printf("dispatcher: Unable to prune the journal: %v", err)
+		}
+	}
+	entries, err := d.Journal.Unfinished()
+	if err != nil {
+		log.Printf("dispatcher: Unable to read the journal: %v", err)
+		return
+	}
+	for _, entry := range entries {
+		id, err := entry.RequestIdentifier()
+		if err != nil {
+			log.Printf("dispatcher: Journal entry %s has an invalid identifier: %v", entry.Id, err)
+			continue
+		}
+		replay, ok := replayable[entry.Type]
+		if !ok || len(entry.Request) == 0 {
+			log.Printf("dispatcher: Job %s (%s) did not complete before the server stopped", entry.Id, entry.Type)
+			d.failed(id)
+			continue
+		}
+		request, err := replay(id, entry.Request)
+		if err != nil {
+			log.Printf("dispatcher: Unable to replay job %s (%s): %v", entry.Id, entry.Type, err)
+			d.failed(id)
+			continue
+		}
+		job, err := jobs.JobFor(request)
+		if err != nil {
+			log.Printf("dispatcher: Unable to replay job %s (%s): %v", entry.Id, entry.Type, err)
+			d.failed(id)
+			continue
+		}
+
+		log.Printf("dispatcher: Replaying job %s (%s)", entry.Id, entry.Type)
+		tracker := jobTracker{id, entry.Client, priorityOf(job), job, newTrackingResponse(&replayResponse{id}, d.JobOutputLimit), make(chan bool), true}
+		d.recentJobs.Put(id, tracker)
+		d.transition(tracker, JobQueued)
+
+		// replayed jobs were already accepted, so they may exceed the queue
+		// limits
+		d.queueFor(job).push(tracker, true)
+	}
+}
+
 func closedChannel() <-chan bool {
 	c := make(chan bool)
 	close(c)
@@ -0,0 +1,87 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	nethttp "net/http"
+
+	"github.com/openshift/geard/dispatcher"
+	"github.com/openshift/geard/http"
+	"github.com/openshift/geard/jobs"
+	"github.com/openshift/go-json-rest"
+)
+
+type HttpExtension struct{}
+
+func (h *HttpExtension) Routes() []http.HttpJobHandler {
+	return []http.HttpJobHandler{
+		&HttpJobStatusRequest{},
+		&HttpCancelJobRequest{},
+	}
+}
+
+func (h *HttpExtension) HttpJobFor(job interface{}) (exc http.RemoteExecutable, err error) {
+	switch j := job.(type) {
+	case *dispatcher.JobStatusRequest:
+		exc = &HttpJobStatusRequest{JobStatusRequest: *j}
+	case *dispatcher.CancelJobRequest:
+		exc = &HttpCancelJobRequest{CancelJobRequest: *j}
+	default:
+		err = jobs.ErrNoJobForRequest
+	}
+	return
+}
+
+type HttpJobStatusRequest struct {
+	dispatcher.JobStatusRequest
+	http.DefaultRequest
+}
+
+func (h *HttpJobStatusRequest) HttpMethod() string { return "GET" }
+func (h *HttpJobStatusRequest) HttpPath() string   { return http.Inline("/jobs/:id", h.Id.String()) }
+func (h *HttpJobStatusRequest) Handler(conf *http.HttpConfiguration) http.JobHandler {
+	return func(context *jobs.JobContext, r *rest.Request) (interface{}, error) {
+		id, err := requestIdentifierFor(r)
+		if err != nil {
+			return nil, err
+		}
+		return &dispatcher.JobStatusRequest{Id: id, Client: context.Client}, nil
+	}
+}
+func (h *HttpJobStatusRequest) UnmarshalHttpResponse(headers nethttp.Header, r io.Reader, mode http.ResponseContentMode) (interface{}, error) {
+	if r == nil {
+		return nil, errors.New("Unexpected empty response body to HttpJobStatusRequest")
+	}
+	entry := &dispatcher.JournalEntry{}
+	if err := json.NewDecoder(r).Decode(entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+type HttpCancelJobRequest struct {
+	dispatcher.CancelJobRequest
+	http.DefaultRequest
+}
+
+func (h *HttpCancelJobRequest) HttpMethod() string { return "DELETE" }
+func (h *HttpCancelJobRequest) HttpPath() string   { return http.Inline("/jobs/:id", h.Id.String()) }
+func (h *HttpCancelJobRequest) Handler(conf *http.HttpConfiguration) http.JobHandler {
+	return func(context *jobs.JobContext, r *rest.Request) (interface{}, error) {
+		id, err := requestIdentifierFor(r)
+		if err != nil {
+			return nil, err
+		}
+		return &dispatcher.CancelJobRequest{Id: id, Client: context.Client}, nil
+	}
+}
+
+func requestIdentifierFor(r *rest.Request) (jobs.RequestIdentifier, error) {
+	id, err := jobs.NewRequestIdentifierFromString(r.PathParam("id"))
+	if err != nil {
+		return nil, jobs.SimpleError{Failure: jobs.ResponseInvalidRequest, Reason: fmt.Sprintf("Invalid job identifier: %s", err.Error())}
+	}
+	return id, nil
+}
@@ -0,0 +1,290 @@
+package dispatcher
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openshift/geard/jobs"
+	"github.com/openshift/geard/utils"
+)
+
+type JobState string
+
+const (
+	JobQueued    JobState = "queued"
+	JobRunning   JobState = "running"
+	JobSucceeded JobState = "succeeded"
+	JobFailed    JobState = "failed"
+	JobCanceled  JobState = "canceled"
+)
+
+func (s JobState) Finished() bool {
+	return s == JobSucceeded || s == JobFailed || s == JobCanceled
+}
+
+// A record of a single job, written to disk every time the state of
+// the job changes.
+type JournalEntry struct {
+	Id   string
+	Type string
+	// The client that submitted the job, which is the only client that
+	// may read its status or cancel it
+	Client  string           `json:",omitempty"`
+	Request utils.RawMessage `json:",omitempty"`
+	State   JobState
+	Created time.Time
+	Updated time.Time
+
+	// Set once the job has finished
+	Result *JobResult `json:",omitempty"`
+	// The most recent output streamed by the job
+	Output string `json:",omitempty"`
+}
+
+// The response written by a job.  Success is set if the job succeeded,
+// otherwise Failure and Message describe the error.  Data is the
+// structured data returned with either.
+type JobResult struct {
+	Success jobs.ResponseSuccess
+	Failure jobs.ResponseFailure
+	Message string                 `json:",omitempty"`
+	Data    interface{}            `json:",omitempty"`
+	Pending map[string]interface{} `json:",omitempty"`
+}
+
+func (e *JournalEntry) RequestIdentifier() (jobs.RequestIdentifier, error) {
+	return jobs.NewRequestIdentifierFromString(e.Id)
+}
+
+// A crash safe record of the jobs accepted by a dispatcher.  Each job is
+// stored in its own file and replaced atomically on every change, so a
+// restart will see either the previous or the next state of a job.
+type Journal struct {
+	path string
+	lock sync.Mutex
+}
+
+var ErrJournalEntryNotFound = errors.New("No journal entry exists for that request identifier.")
+
+func NewJournal(path string) *Journal {
+	return &Journal{path: path}
+}
+
+func (j *Journal) pathFor(id string) string {
+	return utils.IsolateContentPathWithPerm(j.path, id, "", 0750)
+}
+
+// Record a new job in the queued state.  Only the requests of replayable
+// jobs are kept, serialized as JSON, since nothing else reads them back -
+// other jobs, and replayable jobs whose request cannot be serialized, are
+// recorded without a payload.  Fields that must not be stored should be
+// excluded from the JSON of the request.  Requests may still carry
+// secrets like environment variables, so entries are only readable by
+// the server and the request is dropped once the job finishes.
+func (j *Journal) Queued(id jobs.RequestIdentifier, client string, job jobs.Job) error {
+	now := time.Now()
+	entry := &JournalEntry{
+		Id:      id.String(),
+		Type:    JobType(job),
+		Client:  client,
+		State:   JobQueued,
+		Created: now,
+		Updated: now,
+	}
+	if _, ok := replayable[entry.Type]; ok {
+		if data, err := json.Marshal(job); err == nil {
+			entry.Request = utils.RawMessage(data)
+		}
+	}
+	return j.write(entry)
+}
+
+// Transition an existing job to a new state.
+func (j *Journal) Transition(id jobs.RequestIdentifier, state JobState) error {
+	return j.update(id.String(), func(entry *JournalEntry) {
+		entry.State = state
+		if state.Finished() {
+			entry.Request = nil
+		}
+	})
+}
+
+// Record the final state of a job and its result.
+func (j *Journal) Finish(id jobs.RequestIdentifier, state JobState, result *JobResult, output string) error {
+	if result != nil && result.Data != nil {
+		if _, err := json.Marshal(result.Data); err != nil {
+			result.Data = nil
+		}
+	}
+	return j.update(id.String(), func(entry *JournalEntry) {
+		entry.State = state
+		entry.Request = nil
+		entry.Result = result
+		entry.Output = output
+	})
+}
+
+func (j *Journal) Remove(id jobs.RequestIdentifier) error {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	if err := os.Remove(j.pathFor(id.String())); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (j *Journal) update(id string, f func(*JournalEntry)) error {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	entry, err := j.read(j.pathFor(id))
+	if err != nil {
+		return err
+	}
+	f(entry)
+	entry.Updated = time.Now()
+	return j.writeLocked(entry)
+}
+
+func (j *Journal) Get(id jobs.RequestIdentifier) (*JournalEntry, error) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	return j.read(j.pathFor(id.String()))
+}
+
+// Return all entries that were not finished the last time they were
+// recorded.
+func (j *Journal) Unfinished() ([]*JournalEntry, error) {
+	entries := []*JournalEntry{}
+	err := j.walk(func(path string, entry *JournalEntry) {
+		if !entry.State.Finished() {
+			entries = append(entries, entry)
+		}
+	})
+	return entries, err
+}
+
+// Remove finished entries last updated before the provided time.
+func (j *Journal) Prune(before time.Time) error {
+	return j.walk(func(path string, entry *JournalEntry) {
+		if entry.State.Finished() && entry.Updated.Before(before) {
+			if err := os.Remove(path); err != nil {
+				log.Printf("journal: Unable to remove %s: %v", path, err)
+			}
+		}
+	})
+}
+
+func (j *Journal) walk(f func(string, *JournalEntry)) error {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	dirs, err := ioutil.ReadDir(j.path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		base := filepath.Join(j.path, dir.Name())
+		files, err := ioutil.ReadDir(base)
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			if file.IsDir() || strings.HasSuffix(file.Name(), ".tmp") {
+				continue
+			}
+			path := filepath.Join(base, file.Name())
+			entry, err := j.read(path)
+			if err != nil {
+				log.Printf("journal: Skipping unreadable entry %s: %v", path, err)
+				continue
+			}
+			f(path, entry)
+		}
+	}
+	return nil
+}
+
+func (j *Journal) read(path string) (*JournalEntry, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrJournalEntryNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	entry := &JournalEntry{}
+	if err := json.Unmarshal(data, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (j *Journal) write(entry *JournalEntry) error {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	return j.writeLocked(entry)
+}
+
+func (j *Journal) writeLocked(entry *JournalEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	path := j.pathFor(entry.Id)
+	tmp := path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// The name a job is recorded under in the journal - the package path and
+// name of the underlying type.
+func JobType(job interface{}) string {
+	t := reflect.TypeOf(job)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.PkgPath() == "" {
+		return t.String()
+	}
+	return t.PkgPath() + "." + t.Name()
+}
+
+// Recreate a request from the payload recorded in the journal.  The
+// request is mapped to a job with jobs.JobFor.
+type ReplayFunc func(id jobs.RequestIdentifier, request []byte) (interface{}, error)
+
+var replayable = make(map[string]ReplayFunc)
+
+// Register a job type as safe to execute again when the server restarts
+// before the job has finished.  Unfinished jobs of any other type are
+// marked failed on restart.
+func AddReplayableJob(job jobs.Job, f ReplayFunc) {
+	replayable[JobType(job)] = f
+}
@@ -0,0 +1,191 @@
+package dispatcher
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/openshift/geard/jobs"
+)
+
+type journalTestJob struct {
+	Value string
+	ran   chan string
+}
+
+func (j *journalTestJob) Execute(resp jobs.Response) {
+	if j.ran != nil {
+		j.ran <- j.Value
+	}
+	resp.Success(jobs.ResponseOk)
+}
+
+var replayed = make(chan string, 1)
+
+func init() {
+	jobs.AddJobExtension(jobs.JobExtensionFunc(func(request interface{}) (jobs.Job, error) {
+		if job, ok := request.(*journalTestJob); ok {
+			return job, nil
+		}
+		return nil, jobs.ErrNoJobForRequest
+	}))
+	AddReplayableJob(&journalTestJob{}, func(id jobs.RequestIdentifier, data []byte) (interface{}, error) {
+		job := &journalTestJob{ran: replayed}
+		if err := json.Unmarshal(data, job); err != nil {
+			return nil, err
+		}
+		return job, nil
+	})
+}
+
+func tempJournal(t *testing.T) (*Journal, func()) {
+	dir, err := ioutil.TempDir("", "journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewJournal(dir), func() { os.RemoveAll(dir) }
+}
+
+func TestJournalRecordsStates(t *testing.T) {
+	journal, cleanup := tempJournal(t)
+	defer cleanup()
+
+	id := jobs.NewRequestIdentifier()
+	if err := journal.Queued(id, "", &journalTestJob{Value: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := journal.Transition(id, JobRunning); err != nil {
+		t.Fatal(err)
+	}
+
+	entry, err := journal.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.State != JobRunning {
+		t.Errorf("Expected running, got %s", entry.State)
+	}
+	if entry.Type != "github.com/openshift/geard/dispatcher.journalTestJob" {
+		t.Errorf("Unexpected type %s", entry.Type)
+	}
+	if string(entry.Request) != `{"Value":"a"}` {
+		t.Errorf("Unexpected request %s", string(entry.Request))
+	}
+	if info, err := os.Stat(journal.pathFor(id.String())); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the entry to only be readable by the server: %v %v", info, err)
+	}
+
+	unfinished, err := journal.Unfinished()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unfinished) != 1 || unfinished[0].Id != id.String() {
+		t.Errorf("Expected one unfinished entry, got %+v", unfinished)
+	}
+
+	journal.Transition(id, JobSucceeded)
+	if unfinished, _ := journal.Unfinished(); len(unfinished) != 0 {
+		t.Errorf("Expected no unfinished entries, got %+v", unfinished)
+	}
+	if entry, _ := journal.Get(id); entry == nil || len(entry.Request) != 0 {
+		t.Errorf("Expected the request to be dropped once the job finished, got %+v", entry)
+	}
+	if err := journal.Prune(time.Now().Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := journal.Get(id); err != ErrJournalEntryNotFound {
+		t.Errorf("Expected entry to be pruned, got %v", err)
+	}
+}
+
+type secretTestJob struct {
+	Password string
+}
+
+func (j *secretTestJob) Execute(resp jobs.Response) {
+	resp.Success(jobs.ResponseOk)
+}
+
+func TestJournalOnlyKeepsReplayableRequests(t *testing.T) {
+	journal, cleanup := tempJournal(t)
+	defer cleanup()
+
+	id := jobs.NewRequestIdentifier()
+	if err := journal.Queued(id, "", &secretTestJob{Password: "secret"}); err != nil {
+		t.Fatal(err)
+	}
+	entry, err := journal.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entry.Request) != 0 {
+		t.Errorf("Expected no request for a job that cannot be replayed, got %s", string(entry.Request))
+	}
+
+	replay := jobs.NewRequestIdentifier()
+	journal.Queued(replay, "", &journalTestJob{Value: "a"})
+	d := &Dispatcher{Journal: journal}
+	status, err := d.Status(replay, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status.Request) != 0 {
+		t.Errorf("Expected the request to be left out of the status, got %s", string(status.Request))
+	}
+}
+
+func TestDispatcherRejectsDuplicatesAfterRestart(t *testing.T) {
+	journal, cleanup := tempJournal(t)
+	defer cleanup()
+
+	ran := make(chan string, 1)
+	id := jobs.NewRequestIdentifier()
+
+	first := &Dispatcher{QueueFast: 1, QueueSlow: 1, Concurrent: 1, TrackDuplicateIds: 10, Journal: journal}
+	first.Start()
+	done, err := first.Dispatch(&jobs.JobContext{Id: id}, &journalTestJob{Value: "a", ran: ran}, &replayResponse{id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-done
+	if <-ran != "a" {
+		t.Error("Expected job to run")
+	}
+	if entry, _ := journal.Get(id); entry == nil || entry.State != JobSucceeded {
+		t.Errorf("Expected job to be recorded as succeeded, got %+v", entry)
+	}
+
+	second := &Dispatcher{QueueFast: 1, QueueSlow: 1, Concurrent: 1, TrackDuplicateIds: 10, Journal: journal}
+	second.Start()
+	if _, err := second.Dispatch(&jobs.JobContext{Id: id}, &journalTestJob{Value: "a", ran: ran}, &replayResponse{id}); err != jobs.ErrRanToCompletion {
+		t.Errorf("Expected duplicate to be rejected, got %v", err)
+	}
+}
+
+func TestDispatcherReplaysUnfinishedJobs(t *testing.T) {
+	journal, cleanup := tempJournal(t)
+	defer cleanup()
+
+	id := jobs.NewRequestIdentifier()
+	journal.Queued(id, "", &journalTestJob{Value: "replay"})
+	failed := jobs.NewRequestIdentifier()
+	journal.Queued(failed, "", jobs.JobFunction(func(jobs.Response) {}))
+
+	d := &Dispatcher{QueueFast: 1, QueueSlow: 1, Concurrent: 1, TrackDuplicateIds: 10, Journal: journal}
+	d.Start()
+
+	select {
+	case value := <-replayed:
+		if value != "replay" {
+			t.Errorf("Unexpected replayed value %s", value)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Job was not replayed")
+	}
+
+	if entry, _ := journal.Get(failed); entry == nil || entry.State != JobFailed {
+		t.Errorf("Expected job without replay to be failed, got %+v", entry)
+	}
+}
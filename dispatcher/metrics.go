@@ -0,0 +1,71 @@
+package dispatcher
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/openshift/geard/jobs"
+	"github.com/openshift/geard/metrics"
+)
+
+var (
+	jobDurations = metrics.NewSummaryVec("geard_job_duration_seconds", "Time spent executing jobs.", "type")
+	jobFailures  = metrics.NewCounterVec("geard_job_failures_total", "Jobs that returned an error.", "type", "code")
+)
+
+var failureNames = map[jobs.ResponseFailure]string{
+	jobs.ResponseError:          "error",
+	jobs.ResponseAlreadyExists:  "already_exists",
+	jobs.ResponseNotFound:       "not_found",
+	jobs.ResponseInvalidRequest: "invalid_request",
+	jobs.ResponseRateLimit:      "rate_limit",
+	jobs.ResponseNotAcceptable:  "not_acceptable",
+	jobs.ResponseForbidden:      "forbidden",
+}
+
+func failureName(f jobs.ResponseFailure) string {
+	if name, ok := failureNames[f]; ok {
+		return name
+	}
+	return strconv.Itoa(int(f))
+}
+
+func jobTypeName(job jobs.Job) string {
+	return strings.TrimLeft(reflect.TypeOf(job).String(), "*")
+}
+
+var priorityNames = map[Priority]string{
+	PriorityLow:    "low",
+	PriorityNormal: "normal",
+	PriorityHigh:   "high",
+}
+
+// Report the jobs waiting and running in each queue, along with the
+// duration and failures of completed jobs.
+func (d *Dispatcher) Collect() []metrics.Family {
+	waiting := metrics.Family{Name: "geard_dispatcher_queued_jobs", Help: "Jobs waiting to run.", Type: metrics.GaugeType}
+	running := metrics.Family{Name: "geard_dispatcher_running_jobs", Help: "Jobs currently running.", Type: metrics.GaugeType}
+	queues := []struct {
+		name  string
+		queue *jobQueue
+	}{{"fast", d.fastJobs}, {"slow", d.slowJobs}}
+	for _, q := range queues {
+		if q.queue == nil {
+			continue
+		}
+		depths := q.queue.depth()
+		for priority := PriorityHigh; priority >= PriorityLow; priority-- {
+			count := depths[priority]
+			waiting.Samples = append(waiting.Samples, metrics.Sample{Labels: metrics.Labels{"queue": q.name, "priority": priorityNames[priority]}, Value: float64(count)})
+		}
+		running.Samples = append(running.Samples, metrics.Sample{Labels: metrics.Labels{"queue": q.name}, Value: float64(q.queue.active())})
+	}
+
+	running.Samples = append(running.Samples, metrics.Sample{Labels: metrics.Labels{"queue": "continuous"}, Value: float64(atomic.LoadInt32(&d.continuous))})
+
+	families := []metrics.Family{waiting, running}
+	families = append(families, jobDurations.Collect()...)
+	return append(families, jobFailures.Collect()...)
+}
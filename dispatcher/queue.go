@@ -0,0 +1,275 @@
+package dispatcher
+
+import (
+	"sync"
+
+	"github.com/openshift/geard/jobs"
+)
+
+// The relative importance of a job.  Higher priority classes are
+// scheduled more often, but lower classes are never starved.
+type Priority int
+
+const (
+	PriorityLow Priority = iota
+	PriorityNormal
+	PriorityHigh
+
+	priorityClasses = 3
+)
+
+type Prioritized interface {
+	Priority() Priority
+}
+
+func priorityOf(j jobs.Job) Priority {
+	if p, ok := j.(Prioritized); ok {
+		switch priority := p.Priority(); {
+		case priority < PriorityLow:
+			return PriorityLow
+		case priority > PriorityHigh:
+			return PriorityHigh
+		default:
+			return priority
+		}
+	}
+	return PriorityNormal
+}
+
+// The default share of workers given to each priority class when all
+// classes have work waiting.
+var DefaultPriorityWeights = map[Priority]int{
+	PriorityHigh:   6,
+	PriorityNormal: 3,
+	PriorityLow:    1,
+}
+
+var (
+	ErrQueueFull     = jobs.SimpleError{Failure: jobs.ResponseRateLimit, Reason: "The server is at maximum capacity - please try again shortly"}
+	ErrUserQueueFull = jobs.SimpleError{Failure: jobs.ResponseRateLimit, Reason: "You have too many jobs waiting on this server - please try again shortly"}
+)
+
+// A queue that schedules jobs by weighted priority class, and within a
+// class round robin between users so that no user can monopolize the
+// workers.  Limits may be placed on the number of jobs a single user may
+// have waiting or running.
+type jobQueue struct {
+	lock sync.Mutex
+	cond *sync.Cond
+
+	// Maximum number of waiting jobs
+	size int
+	// Maximum number of waiting jobs per user, 0 for no limit
+	userDepth int
+	// Maximum number of running jobs per user, 0 for no limit
+	userConcurrent int
+
+	classes [priorityClasses]priorityClass
+	queued  int
+	waiting map[string]int
+	running map[string]int
+}
+
+type priorityClass struct {
+	weight  int
+	current int
+
+	// users with waiting jobs, in round robin order
+	users   []string
+	next    int
+	pending map[string][]jobTracker
+}
+
+func newJobQueue(size, userDepth, userConcurrent int, weights map[Priority]int) *jobQueue {
+	q := &jobQueue{
+		size:           size,
+		userDepth:      userDepth,
+		userConcurrent: userConcurrent,
+		waiting:        make(map[string]int),
+		running:        make(map[string]int),
+	}
+	q.cond = sync.NewCond(&q.lock)
+	for i := range q.classes {
+		weight := weights[Priority(i)]
+		if weight < 1 {
+			weight = 1
+		}
+		q.classes[i] = priorityClass{weight: weight, pending: make(map[string][]jobTracker)}
+	}
+	return q
+}
+
+// Add a job to the queue, or return an error if the queue or the
+// user's share of the queue is full.  Forced jobs ignore the limits.
+func (q *jobQueue) push(t jobTracker, force bool) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if !force {
+		if q.queued >= q.size {
+			return ErrQueueFull
+		}
+		if q.userDepth > 0 && q.waiting[t.user] >= q.userDepth {
+			return ErrUserQueueFull
+		}
+	}
+
+	c := &q.classes[t.priority]
+	if _, ok := c.pending[t.user]; !ok {
+		c.users = append(c.users, t.user)
+	}
+	c.pending[t.user] = append(c.pending[t.user], t)
+	q.queued++
+	q.waiting[t.user]++
+	q.cond.Signal()
+	return nil
+}
+
+// Wait for the next job that may run and mark its user as running a job.
+func (q *jobQueue) pop() jobTracker {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for {
+		if t, ok := q.nextLocked(); ok {
+			q.running[t.user]++
+			return t
+		}
+		q.cond.Wait()
+	}
+}
+
+// Record that a job returned by pop has completed.
+func (q *jobQueue) done(user string) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.running[user]--; q.running[user] <= 0 {
+		delete(q.running, user)
+	}
+	q.cond.Broadcast()
+}
+
+// Remove a waiting job from the queue, returning false if it is not
+// waiting.
+func (q *jobQueue) remove(id jobs.RequestIdentifier) (jobTracker, bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	key := string(id)
+	for i := range q.classes {
+		c := &q.classes[i]
+		for user, trackers := range c.pending {
+			for j := range trackers {
+				if string(trackers[j].id) == key {
+					t := trackers[j]
+					c.pending[user] = append(trackers[:j], trackers[j+1:]...)
+					c.dropIfEmpty(user)
+					q.dequeued(user)
+					return t, true
+				}
+			}
+		}
+	}
+	return jobTracker{}, false
+}
+
+// The number of waiting jobs in each priority class.
+func (q *jobQueue) depth() map[Priority]int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	depths := make(map[Priority]int, priorityClasses)
+	for i := range q.classes {
+		count := 0
+		for _, trackers := range q.classes[i].pending {
+			count += len(trackers)
+		}
+		depths[Priority(i)] = count
+	}
+	return depths
+}
+
+// The number of jobs taken from the queue that have not completed.
+func (q *jobQueue) active() int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	count := 0
+	for _, running := range q.running {
+		count += running
+	}
+	return count
+}
+
+// Select a class by smooth weighted round robin among the classes that
+// have a runnable job, then the next runnable user within that class.
+func (q *jobQueue) nextLocked() (jobTracker, bool) {
+	var best *priorityClass
+	total := 0
+	for i := range q.classes {
+		c := &q.classes[i]
+		if !c.runnable(q) {
+			continue
+		}
+		c.current += c.weight
+		total += c.weight
+		if best == nil || c.current > best.current {
+			best = c
+		}
+	}
+	if best == nil {
+		return jobTracker{}, false
+	}
+	best.current -= total
+
+	for n := 0; n < len(best.users); n++ {
+		i := (best.next + n) % len(best.users)
+		user := best.users[i]
+		if !q.canRun(user) {
+			continue
+		}
+		trackers := best.pending[user]
+		t := trackers[0]
+		best.pending[user] = trackers[1:]
+		best.next = i + 1
+		best.dropIfEmpty(user)
+		q.dequeued(user)
+		return t, true
+	}
+	return jobTracker{}, false
+}
+
+func (q *jobQueue) canRun(user string) bool {
+	return q.userConcurrent <= 0 || q.running[user] < q.userConcurrent
+}
+
+func (q *jobQueue) dequeued(user string) {
+	q.queued--
+	if q.waiting[user]--; q.waiting[user] <= 0 {
+		delete(q.waiting, user)
+	}
+}
+
+func (c *priorityClass) runnable(q *jobQueue) bool {
+	for _, user := range c.users {
+		if q.canRun(user) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *priorityClass) dropIfEmpty(user string) {
+	if len(c.pending[user]) > 0 {
+		return
+	}
+	delete(c.pending, user)
+	for i := range c.users {
+		if c.users[i] == user {
+			c.users = append(c.users[:i], c.users[i+1:]...)
+			if c.next > i {
+				c.next--
+			}
+			break
+		}
+	}
+	if c.next >= len(c.users) {
+		c.next = 0
+	}
+}
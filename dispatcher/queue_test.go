@@ -0,0 +1,91 @@
+package dispatcher
+
+import (
+	"testing"
+
+	"github.com/openshift/geard/jobs"
+)
+
+func queuedTracker(user string, priority Priority) jobTracker {
+	return jobTracker{id: jobs.NewRequestIdentifier(), user: user, priority: priority}
+}
+
+func TestQueueRoundRobinBetweenUsers(t *testing.T) {
+	q := newJobQueue(100, 0, 0, DefaultPriorityWeights)
+	for i := 0; i < 5; i++ {
+		q.push(queuedTracker("greedy", PriorityNormal), false)
+	}
+	q.push(queuedTracker("a", PriorityNormal), false)
+	q.push(queuedTracker("b", PriorityNormal), false)
+
+	order := []string{}
+	for i := 0; i < 4; i++ {
+		t := q.pop()
+		order = append(order, t.user)
+		q.done(t.user)
+	}
+	if order[0] != "greedy" || order[1] != "a" || order[2] != "b" || order[3] != "greedy" {
+		t.Errorf("Expected users to alternate, got %v", order)
+	}
+}
+
+func TestQueueWeightsPriorityClasses(t *testing.T) {
+	q := newJobQueue(100, 0, 0, DefaultPriorityWeights)
+	for i := 0; i < 20; i++ {
+		q.push(queuedTracker("", PriorityLow), false)
+		q.push(queuedTracker("", PriorityHigh), false)
+	}
+
+	counts := map[Priority]int{}
+	for i := 0; i < 14; i++ {
+		t := q.pop()
+		counts[t.priority]++
+		q.done(t.user)
+	}
+	if counts[PriorityHigh] != 12 || counts[PriorityLow] != 2 {
+		t.Errorf("Expected high priority jobs to get 6 of every 7 slots, got %v", counts)
+	}
+}
+
+func TestQueueUserLimits(t *testing.T) {
+	q := newJobQueue(10, 2, 1, DefaultPriorityWeights)
+	if err := q.push(queuedTracker("a", PriorityNormal), false); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.push(queuedTracker("a", PriorityHigh), false); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.push(queuedTracker("a", PriorityNormal), false); err != ErrUserQueueFull {
+		t.Errorf("Expected user queue to be full, got %v", err)
+	}
+	if err := q.push(queuedTracker("a", PriorityNormal), true); err != nil {
+		t.Errorf("Expected forced push to succeed, got %v", err)
+	}
+	b := queuedTracker("b", PriorityLow)
+	q.push(b, false)
+
+	if first := q.pop(); first.user != "a" {
+		t.Fatalf("Expected user a to run first, got %s", first.user)
+	}
+	// a is at its concurrency limit, so b runs despite its lower priority
+	if second := q.pop(); second.user != "b" {
+		t.Errorf("Expected user b to run while a is busy, got %s", second.user)
+	}
+
+	if _, ok := q.remove(b.id); ok {
+		t.Error("Expected a running job not to be removable")
+	}
+	if depth := q.depth(); depth[PriorityNormal] != 2 || depth[PriorityHigh] != 0 {
+		t.Errorf("Unexpected queue depth %v", depth)
+	}
+}
+
+func TestQueueFull(t *testing.T) {
+	q := newJobQueue(1, 0, 0, DefaultPriorityWeights)
+	if err := q.push(queuedTracker("", PriorityNormal), false); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.push(queuedTracker("", PriorityNormal), false); err != ErrQueueFull {
+		t.Errorf("Expected queue to be full, got %v", err)
+	}
+}
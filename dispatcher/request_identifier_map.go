@@ -21,7 +21,7 @@ func NewRequestIdentifierMap(size int) *RequestIdentifierMap {
 	}
 }
 
-func (m RequestIdentifierMap) Get(id jobs.RequestIdentifier) interface{} {
+func (m *RequestIdentifierMap) Get(id jobs.RequestIdentifier) interface{} {
 	key := string(id)
 
 	m.lock.RLock()
@@ -30,7 +30,7 @@ func (m RequestIdentifierMap) Get(id jobs.RequestIdentifier) interface{} {
 	return m.keys[key]
 }
 
-func (m RequestIdentifierMap) Put(id jobs.RequestIdentifier, v interface{}) (interface{}, bool) {
+func (m *RequestIdentifierMap) Put(id jobs.RequestIdentifier, v interface{}) (interface{}, bool) {
 	key := string(id)
 
 	m.lock.Lock()
@@ -53,3 +53,21 @@ func (m RequestIdentifierMap) Put(id jobs.RequestIdentifier, v interface{}) (int
 	m.keys[key] = v
 	return nil, false
 }
+
+func (m *RequestIdentifierMap) Remove(id jobs.RequestIdentifier) {
+	key := string(id)
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if _, contains := m.keys[key]; !contains {
+		return
+	}
+	delete(m.keys, key)
+	for e := m.order.Front(); e != nil; e = e.Next() {
+		if e.Value.(string) == key {
+			m.order.Remove(e)
+			break
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package dispatcher
+
+import (
+	"io"
+	"io/ioutil"
+	"log"
+	"sync"
+
+	"github.com/openshift/geard/jobs"
+)
+
+// Records the outcome of a job and the tail of any streamed output
+// so that it can be reported after the client has gone away.
+type trackingResponse struct {
+	jobs.Response
+
+	lock     sync.Mutex
+	state    JobState
+	canceled bool
+	result   JobResult
+	output   *tailBuffer
+}
+
+func newTrackingResponse(resp jobs.Response, limit int) *trackingResponse {
+	return &trackingResponse{
+		Response: resp,
+		state:    JobQueued,
+		output:   &tailBuffer{max: limit},
+	}
+}
+
+func (r *trackingResponse) Success(t jobs.ResponseSuccess) {
+	r.succeeded(t, nil)
+	r.Response.Success(t)
+}
+
+func (r *trackingResponse) SuccessWithData(t jobs.ResponseSuccess, data interface{}) {
+	r.succeeded(t, data)
+	r.Response.SuccessWithData(t, data)
+}
+
+func (r *trackingResponse) SuccessWithWrite(t jobs.ResponseSuccess, flush, structured bool) io.Writer {
+	r.succeeded(t, nil)
+	return &tailWriter{r.Response.SuccessWithWrite(t, flush, structured), r}
+}
+
+func (r *trackingResponse) WritePendingSuccess(name string, value interface{}) {
+	r.lock.Lock()
+	if r.result.Pending == nil {
+		r.result.Pending = make(map[string]interface{})
+	}
+	r.result.Pending[name] = value
+	r.lock.Unlock()
+	r.Response.WritePendingSuccess(name, value)
+}
+
+func (r *trackingResponse) Failure(reason error) {
+	r.lock.Lock()
+	r.state = JobFailed
+	r.result.Message = reason.Error()
+	if e, ok := reason.(jobs.JobError); ok {
+		r.result.Failure = e.ResponseFailure()
+		r.result.Data = e.ResponseData()
+	}
+	r.lock.Unlock()
+	r.Response.Failure(reason)
+}
+
+func (r *trackingResponse) succeeded(t jobs.ResponseSuccess, data interface{}) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.result.Success = t
+	r.result.Data = data
+}
+
+// Mark the job as running, or return false if it was canceled while
+// queued.
+func (r *trackingResponse) running() bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.state == JobCanceled {
+		return false
+	}
+	r.state = JobRunning
+	return true
+}
+
+// Request that the job be canceled and return the state it was in.  A
+// queued job is canceled immediately, a running job only if it can be
+// interrupted.
+func (r *trackingResponse) cancel(interruptible bool) JobState {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	switch r.state {
+	case JobQueued:
+		r.state = JobCanceled
+		return JobQueued
+	case JobRunning:
+		if interruptible {
+			r.canceled = true
+		}
+	}
+	return r.state
+}
+
+// Clear a cancellation the running job refused, so that the job is not
+// reported as canceled when it finishes.
+func (r *trackingResponse) uncancel() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.canceled = false
+}
+
+// Mark the job finished and return the final state, result, and output.
+func (r *trackingResponse) finish() (JobState, *JobResult, string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	switch {
+	case r.canceled || r.state == JobCanceled:
+		r.state = JobCanceled
+	case r.state != JobFailed:
+		r.state = JobSucceeded
+	}
+	result := r.result
+	return r.state, &result, r.output.String()
+}
+
+// The current state of the job and the output written so far.
+func (r *trackingResponse) current() (JobState, string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.state, r.output.String()
+}
+
+type tailWriter struct {
+	io.Writer
+	response *trackingResponse
+}
+
+func (w *tailWriter) Write(p []byte) (int, error) {
+	w.response.lock.Lock()
+	w.response.output.Write(p)
+	w.response.lock.Unlock()
+	return w.Writer.Write(p)
+}
+
+// Retains at most max bytes of the most recently written data.
+type tailBuffer struct {
+	max int
+	buf []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	if b.max <= 0 {
+		return len(p), nil
+	}
+	if len(p) >= b.max {
+		b.buf = append(b.buf[:0], p[len(p)-b.max:]...)
+		return len(p), nil
+	}
+	if over := len(b.buf) + len(p) - b.max; over > 0 {
+		b.buf = append(b.buf[:0], b.buf[over:]...)
+	}
+	b.buf = append(b.buf, p...)
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	return string(b.buf)
+}
+
+// A response for jobs replayed from the journal - no client is waiting
+// for the result, so it is only logged.
+type replayResponse struct {
+	id jobs.RequestIdentifier
+}
+
+func (r *replayResponse) StreamResult() bool {
+	return false
+}
+
+func (r *replayResponse) Success(t jobs.ResponseSuccess) {
+	log.Printf("job %s: replay succeeded", r.id.String())
+}
+
+func (r *replayResponse) SuccessWithData(t jobs.ResponseSuccess, data interface{}) {
+	r.Success(t)
+}
+
+func (r *replayResponse) SuccessWithWrite(t jobs.ResponseSuccess, flush, structured bool) io.Writer {
+	r.Success(t)
+	return ioutil.Discard
+}
+
+func (r *replayResponse) Failure(reason error) {
+	log.Printf("job %s: replay failed: %v", r.id.String(), reason)
+}
+
+func (r *replayResponse) WritePendingSuccess(name string, value interface{}) {
+}
@@ -0,0 +1,134 @@
+package dispatcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/openshift/geard/jobs"
+)
+
+var ErrJobNotFound = jobs.SimpleError{Failure: jobs.ResponseNotFound, Reason: "No job with that request identifier is known to this server."}
+
+// Return the state of a queued, running, or recorded job submitted by
+// client.  Queued and running jobs include the output they have written
+// so far.  Jobs submitted by other clients are not found, since their
+// output may hold the data of another user.  The request of the job is
+// never returned.
+func (d *Dispatcher) Status(id jobs.RequestIdentifier, client string) (*JournalEntry, error) {
+	tracker := d.trackerFor(id, client)
+
+	var entry *JournalEntry
+	if d.Journal != nil {
+		e, err := d.Journal.Get(id)
+		if err != nil && err != ErrJournalEntryNotFound {
+			return nil, err
+		}
+		if e != nil && e.Client == client {
+			entry = e
+			entry.Request = nil
+		}
+	}
+
+	if tracker == nil {
+		if entry == nil {
+			return nil, ErrJobNotFound
+		}
+		return entry, nil
+	}
+
+	if entry == nil {
+		entry = &JournalEntry{Id: id.String(), Type: JobType(tracker.job)}
+	}
+	entry.State, entry.Output = tracker.response.current()
+	return entry, nil
+}
+
+// The tracker of a job that is queued, running, or recently finished, if
+// it was submitted by client.
+func (d *Dispatcher) trackerFor(id jobs.RequestIdentifier, client string) *jobTracker {
+	if d.recentJobs == nil {
+		return nil
+	}
+	if t, ok := d.recentJobs.Get(id).(jobTracker); ok && t.user == client {
+		return &t
+	}
+	return nil
+}
+
+func (e *JournalEntry) WriteTableTo(w io.Writer) error {
+	fmt.Fprintf(w, "Job:     %s\n", e.Id)
+	fmt.Fprintf(w, "Type:    %s\n", e.Type)
+	fmt.Fprintf(w, "State:   %s\n", e.State)
+	if !e.Created.IsZero() {
+		fmt.Fprintf(w, "Created: %s\n", e.Created.Format(time.RFC3339))
+		fmt.Fprintf(w, "Updated: %s\n", e.Updated.Format(time.RFC3339))
+	}
+	if r := e.Result; r != nil {
+		if e.State == JobFailed {
+			fmt.Fprintf(w, "Failure: %d\n", r.Failure)
+			if r.Message != "" {
+				fmt.Fprintf(w, "Message: %s\n", r.Message)
+			}
+		} else {
+			fmt.Fprintf(w, "Success: %d\n", r.Success)
+		}
+		for k, v := range r.Pending {
+			fmt.Fprintf(w, "%s: %v\n", k, v)
+		}
+		if r.Data != nil {
+			data, err := json.MarshalIndent(r.Data, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "Data:\n%s\n", data)
+		}
+	}
+	if e.Output != "" {
+		fmt.Fprintf(w, "Output:\n%s", e.Output)
+		if !strings.HasSuffix(e.Output, "\n") {
+			fmt.Fprintln(w)
+		}
+	}
+	return nil
+}
+
+// Return the state, result, and recent output of a job.
+type JobStatusRequest struct {
+	Id jobs.RequestIdentifier
+	// The client asking, which must be the client that submitted the job
+	Client string `json:"-"`
+}
+
+type jobStatus struct {
+	*JobStatusRequest
+	dispatcher *Dispatcher
+}
+
+func (j *jobStatus) Fast() bool     { return true }
+func (j *jobStatus) ReadOnly() bool { return true }
+
+func (j *jobStatus) Execute(resp jobs.Response) {
+	entry, err := j.dispatcher.Status(j.Id, j.Client)
+	if err != nil {
+		resp.Failure(err)
+		return
+	}
+	resp.SuccessWithData(jobs.ResponseOk, entry)
+}
+
+// Return a job extension for requests that operate on the jobs known to
+// this dispatcher.
+func NewJobExtension(d *Dispatcher) jobs.JobExtension {
+	return jobs.JobExtensionFunc(func(request interface{}) (jobs.Job, error) {
+		switch r := request.(type) {
+		case *JobStatusRequest:
+			return &jobStatus{r, d}, nil
+		case *CancelJobRequest:
+			return &cancelJob{r, d}, nil
+		}
+		return nil, jobs.ErrNoJobForRequest
+	})
+}
@@ -0,0 +1,265 @@
+package dispatcher
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/openshift/geard/jobs"
+)
+
+func TestTailBufferKeepsRecentOutput(t *testing.T) {
+	b := &tailBuffer{max: 5}
+	b.Write([]byte("abc"))
+	b.Write([]byte("def"))
+	if s := b.String(); s != "bcdef" {
+		t.Errorf("Unexpected buffer contents %q", s)
+	}
+	b.Write([]byte("0123456789"))
+	if s := b.String(); s != "56789" {
+		t.Errorf("Unexpected buffer contents %q", s)
+	}
+
+	none := &tailBuffer{}
+	none.Write([]byte("abc"))
+	if s := none.String(); s != "" {
+		t.Errorf("Expected no output to be retained, got %q", s)
+	}
+}
+
+func TestStatusReportsResult(t *testing.T) {
+	journal, cleanup := tempJournal(t)
+	defer cleanup()
+
+	d := &Dispatcher{QueueFast: 1, QueueSlow: 1, Concurrent: 1, TrackDuplicateIds: 10, Journal: journal, JobOutputLimit: 4}
+	d.Start()
+
+	id := jobs.NewRequestIdentifier()
+	done, err := d.Dispatch(&jobs.JobContext{Id: id}, jobs.JobFunction(func(resp jobs.Response) {
+		w := resp.SuccessWithWrite(jobs.ResponseOk, false, false)
+		fmt.Fprintf(w, "hello world")
+	}), &replayResponse{id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-done
+
+	entry, err := d.Status(id, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.State != JobSucceeded {
+		t.Errorf("Expected succeeded, got %s", entry.State)
+	}
+	if entry.Output != "orld" {
+		t.Errorf("Unexpected output %q", entry.Output)
+	}
+
+	failed := jobs.NewRequestIdentifier()
+	done, err = d.Dispatch(&jobs.JobContext{Id: failed}, jobs.JobFunction(func(resp jobs.Response) {
+		resp.Failure(jobs.StructuredJobError{SimpleError: jobs.SimpleError{Failure: jobs.ResponseNotFound, Reason: "missing"}, Data: "data"})
+	}), &replayResponse{failed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-done
+
+	entry, err = d.Status(failed, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.State != JobFailed || entry.Result == nil {
+		t.Fatalf("Expected failed with a result, got %+v", entry)
+	}
+	if entry.Result.Failure != jobs.ResponseNotFound || entry.Result.Message != "missing" || entry.Result.Data != "data" {
+		t.Errorf("Unexpected result %+v", entry.Result)
+	}
+
+	if _, err := d.Status(jobs.NewRequestIdentifier(), ""); err != ErrJobNotFound {
+		t.Errorf("Expected not found, got %v", err)
+	}
+}
+
+type cancelTestJob struct {
+	started  chan bool
+	canceled chan bool
+}
+
+func (j *cancelTestJob) Execute(resp jobs.Response) {
+	close(j.started)
+	<-j.canceled
+	resp.Failure(jobs.ErrJobCanceled)
+}
+
+func (j *cancelTestJob) Cancel() error {
+	close(j.canceled)
+	return nil
+}
+
+func TestCancelQueuedAndRunningJobs(t *testing.T) {
+	journal, cleanup := tempJournal(t)
+	defer cleanup()
+
+	d := &Dispatcher{QueueFast: 1, QueueSlow: 2, Concurrent: 1, TrackDuplicateIds: 10, Journal: journal}
+	d.Start()
+
+	running := &cancelTestJob{make(chan bool), make(chan bool)}
+	runningId := jobs.NewRequestIdentifier()
+	runningDone, err := d.Dispatch(&jobs.JobContext{Id: runningId}, running, &replayResponse{runningId})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-running.started
+
+	ran := make(chan string, 1)
+	queuedId := jobs.NewRequestIdentifier()
+	queuedDone, err := d.Dispatch(&jobs.JobContext{Id: queuedId}, &journalTestJob{Value: "queued", ran: ran}, &replayResponse{queuedId})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.Cancel(queuedId, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Cancel(runningId, ""); err != nil {
+		t.Fatal(err)
+	}
+	<-runningDone
+	<-queuedDone
+
+	select {
+	case <-ran:
+		t.Error("Canceled job should not have been executed")
+	default:
+	}
+	for _, id := range []jobs.RequestIdentifier{runningId, queuedId} {
+		entry, err := d.Status(id, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entry.State != JobCanceled {
+			t.Errorf("Expected job to be canceled, got %s", entry.State)
+		}
+	}
+	if err := d.Cancel(runningId, ""); err != ErrJobAlreadyFinished {
+		t.Errorf("Expected finished job to reject cancel, got %v", err)
+	}
+}
+
+type refusingTestJob struct {
+	cancelTestJob
+}
+
+func (j *refusingTestJob) Execute(resp jobs.Response) {
+	close(j.started)
+	<-j.canceled
+	resp.Success(jobs.ResponseOk)
+}
+
+func (j *refusingTestJob) Cancel() error {
+	return ErrJobNotCancelable
+}
+
+func TestRefusedCancelDoesNotCancelJob(t *testing.T) {
+	journal, cleanup := tempJournal(t)
+	defer cleanup()
+
+	d := &Dispatcher{QueueFast: 1, QueueSlow: 1, Concurrent: 1, TrackDuplicateIds: 10, Journal: journal}
+	d.Start()
+
+	job := &refusingTestJob{cancelTestJob{make(chan bool), make(chan bool)}}
+	id := jobs.NewRequestIdentifier()
+	done, err := d.Dispatch(&jobs.JobContext{Id: id}, job, &replayResponse{id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-job.started
+
+	if err := d.Cancel(id, ""); err != ErrJobNotCancelable {
+		t.Errorf("Expected the job to refuse cancellation, got %v", err)
+	}
+	close(job.canceled)
+	<-done
+
+	entry, err := d.Status(id, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.State != JobSucceeded {
+		t.Errorf("Expected job to succeed, got %s", entry.State)
+	}
+}
+
+func TestStatusAndCancelOnlyForSubmittingClient(t *testing.T) {
+	journal, cleanup := tempJournal(t)
+	defer cleanup()
+
+	d := &Dispatcher{QueueFast: 1, QueueSlow: 1, Concurrent: 1, TrackDuplicateIds: 10, Journal: journal}
+	d.Start()
+
+	job := &cancelTestJob{make(chan bool), make(chan bool)}
+	id := jobs.NewRequestIdentifier()
+	done, err := d.Dispatch(&jobs.JobContext{Id: id, Client: "10.0.0.1"}, job, &replayResponse{id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-job.started
+
+	if _, err := d.Status(id, "10.0.0.2"); err != ErrJobNotFound {
+		t.Errorf("Expected the job to be hidden from another client, got %v", err)
+	}
+	if err := d.Cancel(id, "10.0.0.2"); err != ErrJobNotFound {
+		t.Errorf("Expected another client to be unable to cancel the job, got %v", err)
+	}
+	if err := d.Cancel(id, "10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	<-done
+
+	// the journaled status is also only returned to the submitting client
+	d.recentJobs.Remove(id)
+	if _, err := d.Status(id, "10.0.0.2"); err != ErrJobNotFound {
+		t.Errorf("Expected the journaled job to be hidden from another client, got %v", err)
+	}
+	if entry, err := d.Status(id, "10.0.0.1"); err != nil || entry.State != JobCanceled {
+		t.Errorf("Expected the submitting client to see the canceled job: %+v %v", entry, err)
+	}
+}
+
+type continuousTestJob struct {
+	cancelTestJob
+}
+
+func (j *continuousTestJob) Continuous() bool { return true }
+
+func TestContinuousJobsBypassQueues(t *testing.T) {
+	d := &Dispatcher{QueueFast: 1, QueueSlow: 1, Concurrent: 1, TrackDuplicateIds: 10}
+	d.Start()
+
+	blocking := &cancelTestJob{make(chan bool), make(chan bool)}
+	blockingId := jobs.NewRequestIdentifier()
+	blockingDone, err := d.Dispatch(&jobs.JobContext{Id: blockingId}, blocking, &replayResponse{blockingId})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-blocking.started
+
+	continuous := &continuousTestJob{cancelTestJob{make(chan bool), make(chan bool)}}
+	id := jobs.NewRequestIdentifier()
+	done, err := d.Dispatch(&jobs.JobContext{Id: id}, continuous, &replayResponse{id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-continuous.started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Continuous job did not start while the workers were busy")
+	}
+
+	if err := d.Cancel(id, ""); err != nil {
+		t.Fatal(err)
+	}
+	<-done
+	d.Cancel(blockingId, "")
+	<-blockingDone
+}
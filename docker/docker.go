@@ -65,6 +65,30 @@ func (d *DockerClient) InspectContainer(containerName string) (*docker.Container
 	return c, err
 }
 
+// Create and run a container that only holds the volumes of an image, as
+// the data container of a unit does.  The image is pulled if it is not
+// present.
+func (d *DockerClient) CreateDataContainer(name, image string) (*docker.Container, error) {
+	opts := docker.CreateContainerOptions{Name: name, Config: &docker.Config{Image: image, Entrypoint: []string{"true"}}}
+	c, err := d.client.CreateContainer(opts)
+	if err == docker.ErrNoSuchImage {
+		if err := d.client.PullImage(docker.PullImageOptions{Repository: image}, docker.AuthConfiguration{}); err != nil {
+			return nil, err
+		}
+		c, err = d.client.CreateContainer(opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := d.client.StartContainer(c.ID, nil); err != nil {
+		return nil, err
+	}
+	if _, err := d.client.WaitContainer(c.ID); err != nil {
+		return nil, err
+	}
+	return d.InspectContainer(c.ID)
+}
+
 func (d *DockerClient) GetImage(imageName string) (*docker.Image, error) {
 	if img, err := d.client.InspectImage(imageName); err != nil {
 		if err == docker.ErrNoSuchImage {
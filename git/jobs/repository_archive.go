@@ -21,6 +21,10 @@ type GitArchiveContentRequest struct {
 	Ref          GitCommitRef
 }
 
+func (j GitArchiveContentRequest) ReadOnly() bool {
+	return true
+}
+
 func (j GitArchiveContentRequest) Execute(resp jobs.Response) {
 	w := resp.SuccessWithWrite(jobs.ResponseOk, false, false)
 	if err := writeGitRepositoryArchive(w, j.RepositoryId.RepositoryPathFor(), j.Ref); err != nil {
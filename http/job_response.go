@@ -152,6 +152,8 @@ func (s *httpJobResponse) Failure(err error) {
 			code = http.StatusNotAcceptable
 		case jobs.ResponseRateLimit:
 			code = 429 // http.statusTooManyRequests
+		case jobs.ResponseForbidden:
+			code = http.StatusForbidden
 		}
 	}
 
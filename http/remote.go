@@ -33,6 +33,12 @@ type RemoteExecutable interface {
 	MarshalHttpRequestBody(io.Writer) error
 	UnmarshalHttpResponse(headers http.Header, r io.Reader, mode ResponseContentMode) (interface{}, error)
 }
+
+// A job whose streamed response is a sequence of JSON documents that are
+// passed to the caller unchanged.
+type JsonStreamPassthrough interface {
+	PassthroughJsonStream() bool
+}
 type ServerAware interface {
 	SetServer(string)
 }
@@ -155,7 +161,9 @@ func (h *HttpTransport) ExecuteRemote(baseUrl *url.URL, job RemoteExecutable, re
 	switch code := resp.StatusCode; {
 	case code == 202:
 		if isJson {
-			return errors.New("Decoding of streaming JSON has not been implemented")
+			if p, ok := job.(JsonStreamPassthrough); !ok || !p.PassthroughJsonStream() {
+				return errors.New("Decoding of streaming JSON has not been implemented")
+			}
 		}
 		data, err := job.UnmarshalHttpResponse(resp.Header, nil, ResponseTable)
 		if err != nil {
@@ -3,16 +3,19 @@
 package http
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"log"
 	"mime"
+	"net"
 	"net/http"
 	"strings"
 
 	"github.com/openshift/geard/config"
 	"github.com/openshift/geard/dispatcher"
 	"github.com/openshift/geard/jobs"
+	"github.com/openshift/geard/metrics"
 	"github.com/openshift/go-json-rest"
 )
 
@@ -23,6 +26,10 @@ func ApiVersion() string {
 type HttpConfiguration struct {
 	Docker     config.DockerConfiguration
 	Dispatcher *dispatcher.Dispatcher
+	// Queue jobs fairly between the users named by X-Request-User rather
+	// than between client addresses.  Only safe when every request passes
+	// through a front end that sets the header.
+	TrustRequestUser bool
 }
 
 type JobHandler func(*jobs.JobContext, *rest.Request) (interface{}, error)
@@ -36,6 +43,14 @@ type HttpStreamable interface {
 	Streamable() bool
 }
 
+// Jobs that read their request body while writing their response, such
+// as interactive processes.
+type HttpFullDuplex interface {
+	FullDuplex() bool
+}
+
+type originalWriterKey struct{}
+
 func (conf *HttpConfiguration) Handler() (http.Handler, error) {
 	handler := rest.ResourceHandler{
 		EnableRelaxedContentType: true,
@@ -52,10 +67,13 @@ func (conf *HttpConfiguration) Handler() (http.Handler, error) {
 		}
 	}
 
-	routes := make([]rest.Route, len(handlers))
+	routes := make([]rest.Route, len(handlers), len(handlers)+1)
 	for i := range handlers {
 		routes[i] = conf.jobRestHandler(handlers[i])
 	}
+	routes = append(routes, rest.Route{"GET", "/metrics", func(w *rest.ResponseWriter, r *rest.Request) {
+		metrics.Handler(w, r.Request)
+	}})
 
 	if err := handler.SetRoutes(routes...); err != nil {
 		for i := range routes {
@@ -63,18 +81,27 @@ func (conf *HttpConfiguration) Handler() (http.Handler, error) {
 		}
 		return nil, err
 	}
-	return &handler, nil
+	// the rest handler wraps the response writer, but full duplex jobs need
+	// the original
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), originalWriterKey{}, w)))
+	}), nil
 }
 
 func (conf *HttpConfiguration) jobRestHandler(handler HttpJobHandler) rest.Route {
 	return rest.Route{
 		handler.HttpMethod(),
 		handler.HttpPath(),
-		conf.handleWithMethod(handler.Handler(conf)),
+		conf.handleWithMethod(handler.Handler(conf), isFullDuplex(handler)),
 	}
 }
 
-func (conf *HttpConfiguration) handleWithMethod(method JobHandler) func(*rest.ResponseWriter, *rest.Request) {
+func isFullDuplex(handler HttpJobHandler) bool {
+	d, ok := handler.(HttpFullDuplex)
+	return ok && d.FullDuplex()
+}
+
+func (conf *HttpConfiguration) handleWithMethod(method JobHandler, fullDuplex bool) func(*rest.ResponseWriter, *rest.Request) {
 	return func(w *rest.ResponseWriter, r *rest.Request) {
 		match := r.Header.Get("If-Match")
 		segments := strings.Split(match, ",")
@@ -100,6 +127,22 @@ func (conf *HttpConfiguration) handleWithMethod(method JobHandler) func(*rest.Re
 			}
 			context.Id = id
 		}
+		context.User = r.Header.Get("X-Request-User")
+		if conf.TrustRequestUser && context.User != "" {
+			context.Client = "user:" + context.User
+		} else if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			context.Client = host
+		} else {
+			context.Client = r.RemoteAddr
+		}
+
+		if fullDuplex {
+			if original, ok := r.Context().Value(originalWriterKey{}).(http.ResponseWriter); ok {
+				if err := http.NewResponseController(original).EnableFullDuplex(); err != nil {
+					log.Printf("http: Unable to read and write %s concurrently: %v", r.URL.Path, err)
+				}
+			}
+		}
 
 		// parse the incoming request into an object
 		jobRequest, errh := method(context, r)
@@ -131,14 +174,26 @@ func (conf *HttpConfiguration) handleWithMethod(method JobHandler) func(*rest.Re
 		response := NewHttpJobResponse(w.ResponseWriter, !canStream, mode)
 
 		// queue / handle the request
-		wait, errd := conf.Dispatcher.Dispatch(context.Id, job, response)
+		wait, errd := conf.Dispatcher.Dispatch(context, job, response)
 		if errd == jobs.ErrRanToCompletion {
 			http.Error(w, errd.Error(), http.StatusNoContent)
 			return
+		} else if errd == dispatcher.ErrUserQueueFull {
+			serveRequestError(w, apiRequestError{errd, errd.Error(), http.StatusTooManyRequests})
+			return
 		} else if errd != nil {
 			serveRequestError(w, apiRequestError{errd, errd.Error(), http.StatusServiceUnavailable})
 			return
 		}
+		if c, ok := job.(dispatcher.Continuous); ok && c.Continuous() {
+			// continuous jobs only exist to serve this client
+			select {
+			case <-wait:
+				return
+			case <-r.Context().Done():
+				conf.Dispatcher.Cancel(context.Id, context.Client)
+			}
+		}
 		<-wait
 	}
 }
@@ -6,16 +6,19 @@ import (
 	cmd "github.com/openshift/geard/cmd"
 	"github.com/openshift/geard/docker"
 	"github.com/openshift/geard/idler"
+	"github.com/openshift/geard/idler/config"
+	"github.com/openshift/geard/idler/iptables"
 	"github.com/openshift/geard/systemd"
 	"github.com/spf13/cobra"
-	
-	"net"
-	"strings"
+
+	"time"
 )
 
 var (
-	hostIp      string
-	idleTimeout int
+	hostIp        string
+	idleTimeout   int
+	numQueues     int
+	checkInterval int
 )
 
 func init() {
@@ -25,14 +28,24 @@ func init() {
 			Short: "(local) A daemon that monitors container traffic and makes idle/unidle decisions",
 			Run:   startIdler,
 		}
-		idlerCmd.PersistentFlags().StringVarP(&hostIp, "host-ip", "H", guessHostIp(), "IP address to listen for traffic on")
-		idlerCmd.PersistentFlags().IntVarP(&idleTimeout, "idle-timeout", "T", 60, "Set the number of minutes of inactivity before an application is idled")
+		idlerCmd.PersistentFlags().StringVarP(&hostIp, "host-ip", "H", iptables.GuessHostIp(), "IP address to listen for traffic on")
+		idlerCmd.PersistentFlags().IntVarP(&idleTimeout, "idle-timeout", "T", 60, "Set the number of minutes of inactivity before an application without an idle policy is idled")
+		idlerCmd.PersistentFlags().IntVar(&numQueues, "queues", config.NumQueues, "The number of netfilter queues to open, one more than the number of containers that may be woken at once")
+		idlerCmd.PersistentFlags().IntVar(&checkInterval, "check-interval", int(config.CheckInterval/time.Second), "Seconds between checks of container traffic against idle policies")
 		parent.AddCommand(idlerCmd)
 	}, true)
 }
 
 func startIdler(c *cobra.Command, args []string) {
 	systemd.Require()
+	if numQueues < 2 || numQueues > 65536 {
+		cmd.Fail(1, "The number of queues must be between 2 and 65536")
+	}
+	if idleTimeout < 1 || checkInterval < 1 {
+		cmd.Fail(1, "The idle timeout and check interval must be positive")
+	}
+	config.NumQueues = numQueues
+	config.CheckInterval = time.Duration(checkInterval) * time.Second
 	dockerSocket := c.Flags().Lookup("docker-socket").Value.String()
 
 	dockerClient, err := docker.GetConnection(dockerSocket)
@@ -40,35 +53,10 @@ func startIdler(c *cobra.Command, args []string) {
 		cmd.Fail(1, "Unable to connect to docker on URI %s", dockerSocket)
 	}
 
+	if err := iptables.SaveHostIp(hostIp); err != nil {
+		cmd.Fail(1, "Unable to record the host IP for the daemon: %s", err.Error())
+	}
 	if err := idler.StartIdler(dockerClient, hostIp, idleTimeout); err != nil {
 		cmd.Fail(2, err.Error())
 	}
 }
-
-func guessHostIp() string {
-	ifaces, err := net.Interfaces()
-	if err != nil {
-		return ""
-	}
-
-	for _, iface := range ifaces {
-		if strings.HasPrefix(iface.Name, "veth") || strings.HasPrefix(iface.Name, "lo") ||
-			strings.HasPrefix(iface.Name, "docker") {
-			continue
-		}
-
-		addrs, err := iface.Addrs()
-		if err != nil {
-			return ""
-		}
-
-		if len(addrs) == 0 {
-			continue
-		}
-
-		ip, _, _ := net.ParseCIDR(addrs[0].String())
-		return ip.String()
-	}
-
-	return ""
-}
@@ -2,7 +2,16 @@
 
 package config
 
-const (
-	UsePreroutingIdler = true
-	NumQueues          = 10
+import "time"
+
+const UsePreroutingIdler = true
+
+var (
+	// Queue 0 receives the first packet sent to an idled container, the
+	// rest hold packets while a container starts, so up to NumQueues-1
+	// containers may be woken at once.
+	NumQueues = 10
+	// How often the packet counts of containers are checked against their
+	// idle policies.
+	CheckInterval = time.Minute
 )
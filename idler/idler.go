@@ -26,8 +26,11 @@ type Idler struct {
 	waitChan      chan uint16
 	openChannels  []containers.Identifier
 	hostIp        string
-	idleTimeout   time.Duration
 	eventListener *csystemd.EventListener
+	// The policy of containers that have not set their own
+	defaultPolicy containers.IdlePolicy
+	// When each container last received traffic, or was started
+	lastActive map[containers.Identifier]time.Time
 }
 
 var idler *Idler
@@ -59,7 +62,8 @@ func newIdler(d *docker.DockerClient, hostIp string, idleTimeout int) *Idler {
 			return nil
 		}
 	}
-	idler.idleTimeout = time.Minute * time.Duration(idleTimeout)
+	idler.defaultPolicy = containers.IdlePolicy{Mode: containers.IdleAfter, Minutes: idleTimeout}
+	idler.lastActive = make(map[containers.Identifier]time.Time)
 
 	return &idler
 }
@@ -72,7 +76,7 @@ func (idler *Idler) Run() {
 	}
 
 	packets := idler.qh[0].GetPackets()
-	ticker := time.NewTicker(idler.idleTimeout)
+	ticker := time.NewTicker(config.CheckInterval)
 	events, errors := idler.eventListener.Run()
 
 	for true {
@@ -80,11 +84,13 @@ func (idler *Idler) Run() {
 		case e := <-events:
 			fmt.Printf("[%v] Event: %v\n", time.Now().Format(time.RFC3339), e)
 			switch {
-			case e.Type == containers.Stopped || e.Type == containers.Deleted || e.Type == containers.Errored:
+			case e.Type == csystemd.Stopped || e.Type == csystemd.Deleted || e.Type == csystemd.Errored:
 				iptables.DeleteContainer(e.Id, idler.hostIp)
-			case e.Type == containers.Started:
+				delete(idler.lastActive, e.Id)
+			case e.Type == csystemd.Started:
 				iptables.UnidleContainer(e.Id, idler.hostIp)
-			case e.Type == containers.Idled:
+				idler.lastActive[e.Id] = time.Now()
+			case e.Type == csystemd.Idled:
 				//No-op
 			}
 		case e := <-errors:
@@ -118,9 +124,15 @@ func (idler *Idler) Run() {
 			w := new(tabwriter.Writer)
 			w.Init(&packetData, 0, 8, 0, '\t', 0)
 
-			fmt.Fprintf(w, "[%v] Packet counts:\n\tContainer\tActive?\tIdled?\tPackets\n", time.Now().Format(time.RFC3339))
+			now := time.Now()
+			fmt.Fprintf(w, "[%v] Packet counts:\n\tContainer\tActive?\tIdled?\tPackets\tPolicy\n", now.Format(time.RFC3339))
 			iptables.ResetPacketCount()
 			for id, pkts := range cpkt {
+				if _, seen := idler.lastActive[id]; pkts > 0 || !seen {
+					idler.lastActive[id] = now
+				}
+				policy := idler.policyFor(id)
+
 				started, err := csystemd.UnitStartOnBoot(id)
 				if err != nil {
 					fmt.Printf("Error reading container state for %v: %v\n", id, err)
@@ -132,8 +144,8 @@ func (idler *Idler) Run() {
 					idleFlag = true
 				}
 
-				fmt.Fprintf(w, "\t%v\t%v\t%v\t%v", id, started, idleFlag, pkts)
-				if started && pkts == 0 {
+				fmt.Fprintf(w, "\t%v\t%v\t%v\t%v\t%v", id, started, idleFlag, pkts, policy)
+				if started && policy != nil && policy.ShouldIdle(now.Sub(idler.lastActive[id]), now) {
 					if idler.idleContainer(id) {
 						fmt.Fprintf(w, "\tidling...")
 					}
@@ -147,6 +159,19 @@ func (idler *Idler) Run() {
 	}
 }
 
+// The idle policy of the container, or nil if it cannot be read.
+func (idler *Idler) policyFor(id containers.Identifier) *containers.IdlePolicy {
+	policy, err := containers.GetIdlePolicy(id)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &idler.defaultPolicy
+		}
+		fmt.Printf("Error reading idle policy for %v: %v\n", id, err)
+		return nil
+	}
+	return policy
+}
+
 func (idler *Idler) unidleContainer(id containers.Identifier, p netfilter.NFPacket) {
 	newChanId, wasAlreadyAssigned := idler.getAvailableWaiter(id)
 
@@ -0,0 +1,63 @@
+// +build idler
+
+package iptables
+
+import (
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"strings"
+
+	gearconfig "github.com/openshift/geard/config"
+)
+
+// The address the idler was started with, which the daemon must use for
+// the rules of containers it idles or unidles.
+func HostIpPath() string {
+	return filepath.Join(gearconfig.ContainerBasePath(), "idler-host-ip")
+}
+
+func SaveHostIp(ip string) error {
+	return ioutil.WriteFile(HostIpPath(), []byte(ip+"\n"), 0644)
+}
+
+// The address recorded by the idler, or the guessed address if the idler
+// has not been started.
+func HostIp() string {
+	if data, err := ioutil.ReadFile(HostIpPath()); err == nil {
+		if ip := strings.TrimSpace(string(data)); ip != "" {
+			return ip
+		}
+	}
+	return GuessHostIp()
+}
+
+// The address of the first interface that is not a loopback, docker, or
+// container interface, which the idler watches for traffic by default.
+func GuessHostIp() string {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return ""
+	}
+
+	for _, iface := range ifaces {
+		if strings.HasPrefix(iface.Name, "veth") || strings.HasPrefix(iface.Name, "lo") ||
+			strings.HasPrefix(iface.Name, "docker") {
+			continue
+		}
+
+		addrs, err := iface.Addrs()
+		if err != nil {
+			return ""
+		}
+
+		if len(addrs) == 0 {
+			continue
+		}
+
+		ip, _, _ := net.ParseCIDR(addrs[0].String())
+		return ip.String()
+	}
+
+	return ""
+}
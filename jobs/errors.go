@@ -2,6 +2,7 @@ package jobs
 
 var (
 	ErrRanToCompletion = SimpleError{ResponseError, "This job has run to completion."}
+	ErrJobCanceled     = SimpleError{ResponseError, "This job was canceled."}
 )
 
 const (
@@ -16,6 +17,7 @@ const (
 	ResponseInvalidRequest
 	ResponseRateLimit
 	ResponseNotAcceptable
+	ResponseForbidden
 )
 
 // An error with a code and message to user
@@ -23,6 +23,15 @@ func (job JobFunction) Execute(res Response) {
 	job(res)
 }
 
+// A job that can be interrupted while it is executing.  Cancel is
+// invoked from a different goroutine than Execute and should cause
+// Execute to return as soon as possible, undoing or stopping any
+// work it has started.
+type CancelableJob interface {
+	Job
+	Cancel() error
+}
+
 // A client may rejoin a running job by re-executing the request,
 // and a job that supports this interface will be notified that
 // a second client has connected.  Typically the join will stream
@@ -62,6 +71,8 @@ type JobError interface {
 type JobContext struct {
 	Id   RequestIdentifier
 	User string
+	// Jobs are queued fairly between clients
+	Client string
 }
 
 type RequestIdentifier []byte
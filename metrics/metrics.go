@@ -0,0 +1,135 @@
+// Expose internal server state in the Prometheus text exposition format.
+// Packages register a Collector for the values they own, and the set of
+// collectors is gathered each time the metrics endpoint is read.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const ContentType = "text/plain; version=0.0.4"
+
+type MetricType string
+
+const (
+	CounterType MetricType = "counter"
+	GaugeType   MetricType = "gauge"
+	SummaryType MetricType = "summary"
+)
+
+type Labels map[string]string
+
+type Sample struct {
+	// Appended to the name of the family, such as "_sum" or "_count".
+	Suffix string
+	Labels Labels
+	Value  float64
+}
+
+// A set of samples sharing a name and type.
+type Family struct {
+	Name    string
+	Help    string
+	Type    MetricType
+	Samples []Sample
+}
+
+type Collector interface {
+	Collect() []Family
+}
+
+type CollectorFunc func() []Family
+
+func (f CollectorFunc) Collect() []Family {
+	return f()
+}
+
+var (
+	collectors     []Collector
+	collectorsLock sync.Mutex
+)
+
+// Register a collector to be gathered on every read of the metrics.
+func AddCollector(c Collector) {
+	collectorsLock.Lock()
+	defer collectorsLock.Unlock()
+	collectors = append(collectors, c)
+}
+
+// Gather all registered collectors, ordered by family name.
+func Gather() []Family {
+	collectorsLock.Lock()
+	registered := make([]Collector, len(collectors))
+	copy(registered, collectors)
+	collectorsLock.Unlock()
+
+	families := []Family{}
+	for i := range registered {
+		families = append(families, registered[i].Collect()...)
+	}
+	sort.Sort(byName(families))
+	return families
+}
+
+type byName []Family
+
+func (a byName) Len() int           { return len(a) }
+func (a byName) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a byName) Less(i, j int) bool { return a[i].Name < a[j].Name }
+
+func WriteTo(w io.Writer, families []Family) error {
+	buf := bufio.NewWriter(w)
+	for i := range families {
+		f := &families[i]
+		if f.Help != "" {
+			fmt.Fprintf(buf, "# HELP %s %s\n", f.Name, escapeHelp(f.Help))
+		}
+		fmt.Fprintf(buf, "# TYPE %s %s\n", f.Name, f.Type)
+		for j := range f.Samples {
+			s := &f.Samples[j]
+			fmt.Fprintf(buf, "%s%s%s %s\n", f.Name, s.Suffix, formatLabels(s.Labels), strconv.FormatFloat(s.Value, 'g', -1, 64))
+		}
+	}
+	return buf.Flush()
+}
+
+// Serve the current value of all registered collectors.
+func Handler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	if err := WriteTo(w, Gather()); err != nil {
+		log.Printf("metrics: Unable to write metrics: %v", err)
+	}
+}
+
+func formatLabels(labels Labels) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + "=\"" + labelEscaper.Replace(labels[name]) + "\""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+var (
+	helpEscaper  = strings.NewReplacer("\\", "\\\\", "\n", "\\n")
+	labelEscaper = strings.NewReplacer("\\", "\\\\", "\n", "\\n", "\"", "\\\"")
+)
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteTextFormat(t *testing.T) {
+	durations := NewSummaryVec("test_duration_seconds", "Time spent.", "type")
+	durations.Observe(1.5, "build")
+	durations.Observe(0.5, "build")
+	failures := NewCounterVec("test_failures_total", "Failed \"jobs\".", "type", "code")
+	failures.Inc("install", "not_found")
+
+	families := append(durations.Collect(), failures.Collect()...)
+	families = append(families, Family{Name: "test_free", Type: GaugeType, Samples: []Sample{{Value: 3}}})
+
+	buf := &bytes.Buffer{}
+	if err := WriteTo(buf, families); err != nil {
+		t.Fatal(err)
+	}
+	expected := `# HELP test_duration_seconds Time spent.
+# TYPE test_duration_seconds summary
+test_duration_seconds_sum{type="build"} 2
+test_duration_seconds_count{type="build"} 2
+# HELP test_failures_total Failed "jobs".
+# TYPE test_failures_total counter
+test_failures_total{code="not_found",type="install"} 1
+# TYPE test_free gauge
+test_free 3
+`
+	if buf.String() != expected {
+		t.Errorf("Unexpected output:\n%s", buf.String())
+	}
+}
+
+func TestLabelsAreEscaped(t *testing.T) {
+	if s := formatLabels(Labels{"a": "x\"y\\z\n"}); s != `{a="x\"y\\z\n"}` {
+		t.Errorf("Unexpected labels %s", s)
+	}
+}
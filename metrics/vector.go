@@ -0,0 +1,115 @@
+package metrics
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// A set of values of the same metric partitioned by label values.
+type vector struct {
+	name   string
+	help   string
+	labels []string
+
+	lock   sync.Mutex
+	values map[string]*vectorValue
+}
+
+type vectorValue struct {
+	labels Labels
+	sum    float64
+	count  uint64
+}
+
+func newVector(name, help string, labels []string) vector {
+	return vector{name: name, help: help, labels: labels, values: make(map[string]*vectorValue)}
+}
+
+func (v *vector) observe(value float64, labelValues []string) {
+	key := strings.Join(labelValues, "\xff")
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	existing, ok := v.values[key]
+	if !ok {
+		labels := make(Labels, len(v.labels))
+		for i := range v.labels {
+			if i < len(labelValues) {
+				labels[v.labels[i]] = labelValues[i]
+			} else {
+				labels[v.labels[i]] = ""
+			}
+		}
+		existing = &vectorValue{labels: labels}
+		v.values[key] = existing
+	}
+	existing.sum += value
+	existing.count++
+}
+
+// Iterate over the values in a stable order.
+func (v *vector) each(f func(*vectorValue)) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	keys := make([]string, 0, len(v.values))
+	for key := range v.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		f(v.values[key])
+	}
+}
+
+// A monotonically increasing count partitioned by label values.
+type CounterVec struct {
+	vector
+}
+
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{newVector(name, help, labels)}
+}
+
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.observe(1, labelValues)
+}
+
+func (c *CounterVec) Add(value float64, labelValues ...string) {
+	c.observe(value, labelValues)
+}
+
+func (c *CounterVec) Collect() []Family {
+	f := Family{Name: c.name, Help: c.help, Type: CounterType, Samples: []Sample{}}
+	c.each(func(v *vectorValue) {
+		f.Samples = append(f.Samples, Sample{Labels: v.labels, Value: v.sum})
+	})
+	return []Family{f}
+}
+
+// The count and total of a set of observations, such as durations,
+// partitioned by label values.
+type SummaryVec struct {
+	vector
+}
+
+func NewSummaryVec(name, help string, labels ...string) *SummaryVec {
+	return &SummaryVec{newVector(name, help, labels)}
+}
+
+func (s *SummaryVec) Observe(value float64, labelValues ...string) {
+	s.observe(value, labelValues)
+}
+
+func (s *SummaryVec) Collect() []Family {
+	f := Family{Name: s.name, Help: s.help, Type: SummaryType, Samples: []Sample{}}
+	s.each(func(v *vectorValue) {
+		f.Samples = append(f.Samples,
+			Sample{Suffix: "_sum", Labels: v.labels, Value: v.sum},
+			Sample{Suffix: "_count", Labels: v.labels, Value: float64(v.count)},
+		)
+	})
+	return []Family{f}
+}
@@ -12,6 +12,11 @@ import (
 const portsPerBlock = Port(100) // changing this breaks disk structure... don't do it!
 const maxReadFailures = 3
 
+const (
+	defaultMinPort = Port(4000)
+	defaultMaxPort = Port(60000)
+)
+
 func StartPortAllocator(min, max Port) {
 	lock.Lock()
 	defer lock.Unlock()
@@ -35,7 +40,7 @@ func StartPortAllocator(min, max Port) {
 // come open now.
 //
 func allocatePort() Port {
-	StartPortAllocator(4000, 60000)
+	StartPortAllocator(defaultMinPort, defaultMaxPort)
 	p := <-internalPortAllocator.ports
 	log.Printf("ports: Reserved port %d", p)
 	return p
@@ -59,6 +64,16 @@ var (
 	lock                  = sync.Mutex{}
 )
 
+// The range of ports the allocator will hand out.
+func allocatorRange() (min, max Port) {
+	lock.Lock()
+	defer lock.Unlock()
+	if !started {
+		return defaultMinPort, defaultMaxPort
+	}
+	return internalPortAllocator.min, internalPortAllocator.max
+}
+
 func (p *portAllocator) findPorts() {
 	for {
 		foundInBlock := 0
@@ -0,0 +1,50 @@
+package port
+
+import (
+	"os"
+
+	"github.com/openshift/geard/metrics"
+)
+
+func init() {
+	metrics.AddCollector(metrics.CollectorFunc(collectPorts))
+}
+
+// Count the ports reserved on disk within the allocator range.
+func reservedPorts(min, max Port) int {
+	reserved := 0
+	for block := min / portsPerBlock; block*portsPerBlock < max; block++ {
+		parent, _ := (block * portsPerBlock).PortPathsFor()
+		f, err := os.Open(parent)
+		if err != nil {
+			continue
+		}
+		names, _ := f.Readdirnames(-1)
+		f.Close()
+		for _, p := range namesToPorts(names) {
+			if p >= min && p < max {
+				reserved++
+			}
+		}
+	}
+	return reserved
+}
+
+func collectPorts() []metrics.Family {
+	min, max := allocatorRange()
+	reserved := reservedPorts(min, max)
+	return []metrics.Family{
+		{
+			Name:    "geard_ports_free",
+			Help:    "External ports available to the port allocator.",
+			Type:    metrics.GaugeType,
+			Samples: []metrics.Sample{{Value: float64(int(max-min) - reserved)}},
+		},
+		{
+			Name:    "geard_ports_reserved",
+			Help:    "External ports reserved by containers.",
+			Type:    metrics.GaugeType,
+			Samples: []metrics.Sample{{Value: float64(reserved)}},
+		},
+	}
+}
@@ -20,30 +20,42 @@ func (p Port) PortPathsFor() (base string, path string) {
 }
 
 func AtomicReserveExternalPorts(path string, ports, existing PortPairs) (PortPairs, error) {
+	reserved, unreserve, err := ReserveExternalPorts(path, ports, existing)
+	if err != nil {
+		return ports, err
+	}
+
+	if len(unreserve) > 0 {
+		log.Printf("ports: Releasing %v", unreserve)
+	}
+	ReleaseExternalPorts(unreserve) // Ignore errors
+
+	return reserved, nil
+}
+
+// Reserve ports as AtomicReserveExternalPorts does, but leave the existing
+// ports that are no longer needed reserved.  The caller releases those
+// once the reserved ports are in use, or releases the ports in reserved
+// that are not in existing to back out.
+func ReserveExternalPorts(path string, ports, existing PortPairs) (reserved, unreserve PortPairs, err error) {
 	reservations, errp := ports.reserve()
 	if errp != nil {
-		return ports, errp
+		return nil, nil, errp
 	}
 	unreserve, erru := reservations.reuse(existing)
 	if erru != nil {
-		return ports, erru
+		return nil, nil, erru
 	}
 
-	reserved := make(PortPairs, len(reservations))
+	reserved = make(PortPairs, len(reservations))
 	for i := range reservations {
 		reserved[i] = reservations[i].PortPair
 	}
 
 	if err := reservations.reserve(path); err != nil {
-		return ports, err
-	}
-
-	if len(unreserve) > 0 {
-		log.Printf("ports: Releasing %v", unreserve)
+		return nil, nil, err
 	}
-	ReleaseExternalPorts(unreserve) // Ignore errors
-
-	return reserved, nil
+	return reserved, unreserve, nil
 }
 
 func ReleaseExternalPorts(ports PortPairs) error {
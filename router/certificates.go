@@ -0,0 +1,296 @@
+package router
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Certificates that expire within this period are reported with a
+// warning.
+var ExpiryWarningPeriod = 30 * 24 * time.Hour
+
+var ErrCertificateNotFound = errors.New("The specified certificate does not exist")
+
+// The details of a stored certificate.  The private key is never
+// returned.
+type CertificateInfo struct {
+	Id        Identifier
+	Names     []string
+	Issuer    string
+	NotBefore time.Time
+	NotAfter  time.Time
+	Warnings  []string `json:",omitempty"`
+}
+
+// True if the certificate is valid for the host name at the given time.
+func (c *CertificateInfo) Serves(host string, now time.Time) bool {
+	if now.After(c.NotAfter) || now.Before(c.NotBefore) {
+		return false
+	}
+	host = strings.ToLower(host)
+	for _, name := range c.Names {
+		name = strings.ToLower(name)
+		if name == host {
+			return true
+		}
+		if strings.HasPrefix(name, "*.") {
+			if i := strings.Index(host, "."); i > 0 && host[i:] == name[1:] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func infoFor(id Identifier, chain []*x509.Certificate, now time.Time) *CertificateInfo {
+	leaf := chain[0]
+	info := &CertificateInfo{
+		Id:        id,
+		Issuer:    leaf.Issuer.CommonName,
+		NotBefore: leaf.NotBefore,
+		NotAfter:  leaf.NotAfter,
+	}
+	if leaf.Subject.CommonName != "" {
+		info.Names = append(info.Names, leaf.Subject.CommonName)
+	}
+	for _, name := range leaf.DNSNames {
+		if name != leaf.Subject.CommonName {
+			info.Names = append(info.Names, name)
+		}
+	}
+
+	for i, cert := range chain {
+		subject := "The certificate"
+		if i > 0 {
+			subject = fmt.Sprintf("The chain certificate '%s'", cert.Subject.CommonName)
+		}
+		switch {
+		case now.After(cert.NotAfter):
+			info.Warnings = append(info.Warnings, fmt.Sprintf("%s expired on %s", subject, cert.NotAfter.Format(time.RFC3339)))
+		case now.Before(cert.NotBefore):
+			info.Warnings = append(info.Warnings, fmt.Sprintf("%s is not valid until %s", subject, cert.NotBefore.Format(time.RFC3339)))
+		case cert.NotAfter.Sub(now) < ExpiryWarningPeriod:
+			days := int(cert.NotAfter.Sub(now).Hours() / 24)
+			info.Warnings = append(info.Warnings, fmt.Sprintf("%s expires in %d days, on %s", subject, days, cert.NotAfter.Format(time.RFC3339)))
+		}
+	}
+	return info
+}
+
+// Read a PEM encoded certificate chain, leaf first.
+func parseChain(data []byte) ([]*x509.Certificate, error) {
+	chain := []*x509.Certificate{}
+	rest := data
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			return nil, errors.New(fmt.Sprintf("The certificate chain may only contain certificates, not '%s'", block.Type))
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("Certificate %d of the chain is not valid: %s", len(chain)+1, err.Error()))
+		}
+		chain = append(chain, cert)
+	}
+	if len(bytes.TrimSpace(rest)) != 0 {
+		return nil, errors.New("The certificate chain contains data that is not PEM encoded")
+	}
+	if len(chain) == 0 {
+		return nil, errors.New("The certificate chain must contain at least one PEM encoded certificate")
+	}
+	for i := 0; i < len(chain)-1; i++ {
+		if err := chain[i].CheckSignatureFrom(chain[i+1]); err != nil {
+			return nil, errors.New(fmt.Sprintf("Certificate %d of the chain is not signed by the certificate that follows it: %s", i+1, err.Error()))
+		}
+	}
+	return chain, nil
+}
+
+// Return the private key as an unencrypted PEM block.
+func decryptKey(data []byte, password string) ([]byte, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("The private key must be PEM encoded")
+	}
+	if block.Type == "ENCRYPTED PRIVATE KEY" {
+		return nil, errors.New("Encrypted PKCS#8 private keys are not supported, use a PEM encrypted key")
+	}
+	der := block.Bytes
+	if x509.IsEncryptedPEMBlock(block) {
+		if password == "" {
+			return nil, errors.New("The private key is encrypted and no password was given")
+		}
+		decrypted, err := x509.DecryptPEMBlock(block, []byte(password))
+		if err != nil {
+			return nil, errors.New("Unable to decrypt the private key: " + err.Error())
+		}
+		der = decrypted
+	}
+
+	if _, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: der}), nil
+	}
+	if _, err := x509.ParseECPrivateKey(der); err == nil {
+		return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), nil
+	}
+	if _, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+	}
+	return nil, errors.New("The private key is not an RSA, EC, or PKCS#8 key")
+}
+
+// Validate the certificate chain and private key, decrypting the key if
+// it is protected by a password, and return the PEM file HAProxy loads
+// for the certificate.  Expired certificates are rejected.
+func (c *Certificate) Prepare(now time.Time) ([]byte, *CertificateInfo, error) {
+	if _, err := NewIdentifier(string(c.Id)); err != nil {
+		return nil, nil, err
+	}
+	chain, err := parseChain(c.Contents)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := decryptKey(c.PrivateKey, c.PrivateKeyPassword)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var out bytes.Buffer
+	for _, cert := range chain {
+		pem.Encode(&out, &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	}
+	if _, err := tls.X509KeyPair(out.Bytes(), key); err != nil {
+		return nil, nil, errors.New("The private key does not match the certificate: " + err.Error())
+	}
+	if now.After(chain[0].NotAfter) {
+		return nil, nil, errors.New(fmt.Sprintf("The certificate expired on %s", chain[0].NotAfter.Format(time.RFC3339)))
+	}
+	out.Write(key)
+
+	return out.Bytes(), infoFor(c.Id, chain, now), nil
+}
+
+// Validate and store a certificate, replacing any existing certificate
+// with the same id.  The change is applied to the router configuration.
+func SaveCertificate(c *Certificate) (*CertificateInfo, error) {
+	data, info, err := c.Prepare(time.Now())
+	if err != nil {
+		return nil, InvalidCertificateError{err.Error()}
+	}
+
+	updateLock.Lock()
+	defer updateLock.Unlock()
+
+	change := func(routes *Routes) error {
+		routes.Certificates[c.Id] = info
+		return nil
+	}
+	if err := update(change, map[Identifier][]byte{c.Id: data}); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+func GetCertificate(id Identifier) (*CertificateInfo, error) {
+	data, err := ioutil.ReadFile(id.CertificatePathFor())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrCertificateNotFound
+		}
+		return nil, err
+	}
+	// the stored file is the chain followed by the private key
+	var certs bytes.Buffer
+	rest := data
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type == "CERTIFICATE" {
+			pem.Encode(&certs, block)
+		}
+	}
+	chain, err := parseChain(certs.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return infoFor(id, chain, time.Now()), nil
+}
+
+// Remove a certificate that is not used by any frontend.
+func RemoveCertificate(id Identifier) error {
+	return Update(func(routes *Routes) error {
+		if _, ok := routes.Certificates[id]; !ok {
+			return ErrCertificateNotFound
+		}
+		for i := range routes.Frontends {
+			for _, used := range routes.Frontends[i].CertificateIds {
+				if used == id {
+					return CertificateInUseError{id, routes.Frontends[i].Id}
+				}
+			}
+		}
+		delete(routes.Certificates, id)
+		return nil
+	})
+}
+
+type InvalidCertificateError struct {
+	Reason string
+}
+
+func (e InvalidCertificateError) Error() string {
+	return e.Reason
+}
+
+type CertificateInUseError struct {
+	Id       Identifier
+	Frontend Identifier
+}
+
+func (e CertificateInUseError) Error() string {
+	return fmt.Sprintf("The certificate %s is used by the frontend %s", e.Id, e.Frontend)
+}
+
+// The details of every stored certificate.
+func loadCertificates() (map[Identifier]*CertificateInfo, error) {
+	names, err := filepath.Glob(filepath.Join(CertificatesPath(), "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	certs := make(map[Identifier]*CertificateInfo)
+	for _, name := range names {
+		id := Identifier(strings.TrimSuffix(filepath.Base(name), ".pem"))
+		info, err := GetCertificate(id)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read certificate %s: %v", id, err)
+		}
+		certs[id] = info
+	}
+	return certs, nil
+}
+
+func writeFile(path string, data []byte, mode os.FileMode) error {
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -1,17 +1,54 @@
 package cmd
 
 import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
 	"github.com/spf13/cobra"
+
+	gcmd "github.com/openshift/geard/cmd"
+	"github.com/openshift/geard/router"
 )
 
 func RegisterRouter(parent *cobra.Command) {
 	testCmd := &cobra.Command{
 		Use:   "test-router",
-		Short: "(Local) Test router extension.",
+		Short: "(Local) Render the router configuration and check it with HAProxy.",
+		Long:  "Print the HAProxy configuration generated from the routes defined on this host and check that HAProxy accepts it.",
 		Run:   test,
 	}
 	parent.AddCommand(testCmd)
 }
 
 func test(cmd *cobra.Command, args []string) {
+	routes, err := router.LoadRoutes()
+	if err != nil {
+		gcmd.Fail(1, "Unable to read the routes on this host: %v", err)
+	}
+
+	for _, warning := range routes.CertificateWarnings() {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+
+	f, err := ioutil.TempFile("", "haproxy-cfg-")
+	if err != nil {
+		gcmd.Fail(1, "Unable to create a temporary file: %v", err)
+	}
+	err = routes.WriteConfigTo(io.MultiWriter(os.Stdout, f))
+	if err == nil {
+		err = f.Close()
+	} else {
+		f.Close()
+	}
+	if err == nil {
+		err = router.Validate(f.Name())
+	}
+	os.Remove(f.Name())
+
+	if err != nil {
+		gcmd.Fail(1, "%v", err)
+	}
+	fmt.Fprintln(os.Stderr, "The router configuration is valid.")
 }
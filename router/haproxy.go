@@ -0,0 +1,528 @@
+package router
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+var (
+	HAProxyPath = "/usr/sbin/haproxy"
+
+	// The public ports for plain and encrypted traffic.  Edge terminated
+	// https connections are passed on to a second frontend listening on
+	// the local TerminatePort.
+	HttpPort      = 80
+	HttpsPort     = 443
+	TerminatePort = 10443
+)
+
+var ErrRouterConfigurationMissing = errors.New("The router configuration has not been generated")
+
+// HAProxy rejected a generated configuration.
+type ConfigurationError struct {
+	Output string
+}
+
+func (e ConfigurationError) Error() string {
+	return "The router configuration is not valid: " + e.Output
+}
+
+func ConfigPath() string {
+	return filepath.Join(basePath(), "haproxy.cfg")
+}
+
+// The certificate served for each https frontend, selected by the host
+// name the client asks for.
+func CertificateListPath() string {
+	return filepath.Join(basePath(), "haproxy.crt-list")
+}
+
+func pidPath() string {
+	return filepath.Join(basePath(), "haproxy.pid")
+}
+
+var updateLock sync.Mutex
+
+// Load the stored routes, apply a change, and replace the running HAProxy
+// configuration.  The change is only persisted once HAProxy has accepted
+// and loaded the new configuration, and the previous configuration is
+// restored if it could not be loaded.
+func Update(change func(*Routes) error) error {
+	updateLock.Lock()
+	defer updateLock.Unlock()
+	return update(change, nil)
+}
+
+// Apply a change along with the contents of any certificates that are
+// added or replaced by it.  Certificates removed from the routes are
+// deleted after the new configuration is running.
+func update(change func(*Routes) error, staged map[Identifier][]byte) error {
+	routes, err := LoadRoutes()
+	if err != nil {
+		return err
+	}
+	loaded := make([]Identifier, 0, len(routes.Certificates))
+	for id := range routes.Certificates {
+		loaded = append(loaded, id)
+	}
+	if change != nil {
+		if err := change(routes); err != nil {
+			return err
+		}
+	}
+
+	for _, warning := range routes.CertificateWarnings() {
+		log.Printf("router: %s", warning)
+	}
+
+	// HAProxy checks a copy of the configuration that refers to the
+	// staged certificates and certificate list, so nothing the running
+	// process depends on is touched until the copy is accepted
+	temporary := []string{}
+	defer func() {
+		for _, path := range temporary {
+			os.Remove(path)
+		}
+	}()
+	stagedPathFor := func(id Identifier) string {
+		if _, ok := staged[id]; ok {
+			return id.CertificatePathFor() + ".tmp"
+		}
+		return id.CertificatePathFor()
+	}
+	for id, data := range staged {
+		path := stagedPathFor(id)
+		temporary = append(temporary, path)
+		if err := ioutil.WriteFile(path, data, 0600); err != nil {
+			return err
+		}
+	}
+	list, path := CertificateListPath(), ConfigPath()
+	var stagedList, stagedConfig bytes.Buffer
+	if err := routes.writeCertificateListTo(&stagedList, stagedPathFor); err != nil {
+		return err
+	}
+	if err := routes.writeConfigTo(&stagedConfig, list+".tmp"); err != nil {
+		return err
+	}
+	temporary = append(temporary, list+".tmp", path+".tmp")
+	if err := ioutil.WriteFile(list+".tmp", stagedList.Bytes(), 0640); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path+".tmp", stagedConfig.Bytes(), 0640); err != nil {
+		return err
+	}
+	if err := Validate(path + ".tmp"); err != nil {
+		return err
+	}
+
+	var certs, config bytes.Buffer
+	if err := routes.WriteCertificateListTo(&certs); err != nil {
+		return err
+	}
+	if err := routes.WriteConfigTo(&config); err != nil {
+		return err
+	}
+	previous := []*previousFile{}
+	restore := func() {
+		for i := len(previous) - 1; i >= 0; i-- {
+			previous[i].restore()
+		}
+	}
+	replace := func(path string, data []byte, mode os.FileMode) error {
+		p, err := readPreviousFile(path, mode)
+		if err != nil {
+			return err
+		}
+		previous = append(previous, p)
+		return writeFile(path, data, mode)
+	}
+	for id, data := range staged {
+		if err := replace(id.CertificatePathFor(), data, 0600); err != nil {
+			restore()
+			return err
+		}
+	}
+	if err := replace(list, certs.Bytes(), 0640); err != nil {
+		restore()
+		return err
+	}
+	if err := replace(path, config.Bytes(), 0640); err != nil {
+		restore()
+		return err
+	}
+	if err := Reload(); err != nil {
+		restore()
+		return err
+	}
+
+	if err := routes.save(); err != nil {
+		return err
+	}
+	for _, id := range loaded {
+		if _, ok := routes.Certificates[id]; !ok {
+			if err := os.Remove(id.CertificatePathFor()); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// The contents of a file before an update replaced it.
+type previousFile struct {
+	path   string
+	data   []byte
+	exists bool
+	mode   os.FileMode
+}
+
+func readPreviousFile(path string, mode os.FileMode) (*previousFile, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &previousFile{path: path, mode: mode}, nil
+		}
+		return nil, err
+	}
+	return &previousFile{path, data, true, mode}, nil
+}
+
+func (p *previousFile) restore() {
+	if !p.exists {
+		os.Remove(p.path)
+		return
+	}
+	if err := writeFile(p.path, p.data, p.mode); err != nil {
+		log.Printf("router: Unable to restore %s: %v", p.path, err)
+	}
+}
+
+// Check a configuration file with HAProxy.
+func Validate(path string) error {
+	out, err := exec.Command(HAProxyPath, "-c", "-q", "-f", path).CombinedOutput()
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); ok {
+			return ConfigurationError{strings.TrimSpace(string(out))}
+		}
+		return err
+	}
+	return nil
+}
+
+// Start HAProxy with the current configuration, asking any running
+// instance to finish its connections and exit once the new process is
+// listening.
+func Reload() error {
+	path := ConfigPath()
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return ErrRouterConfigurationMissing
+		}
+		return err
+	}
+	args := []string{"-f", path, "-p", pidPath()}
+	if data, err := ioutil.ReadFile(pidPath()); err == nil {
+		if pids := strings.Fields(string(data)); len(pids) > 0 {
+			args = append(args, "-sf")
+			args = append(args, pids...)
+		}
+	}
+	if out, err := exec.Command(HAProxyPath, args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("Unable to reload HAProxy: %v %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// Persist the routes, removing any that are no longer defined.
+func (r *Routes) save() error {
+	frontends := make(map[Identifier]bool)
+	for i := range r.Frontends {
+		if err := r.Frontends[i].Save(); err != nil {
+			return err
+		}
+		frontends[r.Frontends[i].Id] = true
+	}
+	backends := make(map[Identifier]bool)
+	for i := range r.Backends {
+		if err := r.Backends[i].Save(); err != nil {
+			return err
+		}
+		backends[r.Backends[i].Id] = true
+	}
+
+	ids, err := listIds(filepath.Join(basePath(), "frontends"))
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if !frontends[id] {
+			f := Frontend{Id: id}
+			if err := f.Remove(); err != nil {
+				return err
+			}
+		}
+	}
+	if ids, err = listIds(filepath.Join(basePath(), "backends")); err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if !backends[id] {
+			b := Backend{Id: id}
+			if err := b.Remove(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Warnings for certificates used by frontends that have expired or
+// will expire soon.
+func (r *Routes) CertificateWarnings() []string {
+	warnings := []string{}
+	seen := make(map[Identifier]bool)
+	for i := range r.Frontends {
+		for _, id := range r.Frontends[i].CertificateIds {
+			info, ok := r.Certificates[id]
+			if !ok || seen[id] {
+				continue
+			}
+			seen[id] = true
+			for _, warning := range info.Warnings {
+				warnings = append(warnings, fmt.Sprintf("certificate %s: %s", id, warning))
+			}
+		}
+	}
+	return warnings
+}
+
+// The certificate served for an https frontend - the first of its
+// certificates that is valid for the host, or the first that exists if
+// none are.
+func (r *Routes) certificateFor(f *Frontend, now time.Time) (Identifier, bool) {
+	var fallback Identifier
+	for _, id := range f.CertificateIds {
+		info, ok := r.Certificates[id]
+		if !ok {
+			continue
+		}
+		if info.Serves(f.Host, now) {
+			return id, true
+		}
+		if fallback == "" {
+			fallback = id
+		}
+	}
+	return fallback, fallback != ""
+}
+
+// Write the HAProxy crt-list for the https frontends.  Each line is the
+// certificate selected for one frontend host, and HAProxy serves the
+// first line whose host matches the SNI name sent by the client.  The
+// first line is also served to clients that send no name.
+func (r *Routes) WriteCertificateListTo(w io.Writer) error {
+	return r.writeCertificateListTo(w, Identifier.CertificatePathFor)
+}
+
+func (r *Routes) writeCertificateListTo(w io.Writer, pathFor func(Identifier) string) error {
+	now := time.Now()
+	hosts := make(map[string]bool)
+	frontends := make([]*Frontend, 0, len(r.Frontends))
+	for i := range r.Frontends {
+		frontends = append(frontends, &r.Frontends[i])
+	}
+	sort.Sort(frontendsById(frontends))
+	for _, f := range frontends {
+		host := strings.ToLower(f.Host)
+		if !f.Accepts(ProtocolHttps) || hosts[host] {
+			continue
+		}
+		id, ok := r.certificateFor(f, now)
+		if !ok {
+			continue
+		}
+		hosts[host] = true
+		if _, err := fmt.Fprintf(w, "%s %s\n", pathFor(id), host); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type frontendsById []*Frontend
+
+func (a frontendsById) Len() int           { return len(a) }
+func (a frontendsById) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a frontendsById) Less(i, j int) bool { return a[i].Id < a[j].Id }
+
+type haproxyConfig struct {
+	HttpPort            int
+	HttpsPort           int
+	TerminatePort       int
+	CertificateListPath string
+
+	Http     []haproxyFrontend
+	Https    []haproxyFrontend
+	Tls      []haproxyFrontend
+	Backends []haproxyBackend
+}
+
+type haproxyFrontend struct {
+	Id        Identifier
+	Host      string
+	Path      string
+	BackendId Identifier
+}
+
+type haproxyBackend struct {
+	Id   Identifier
+	Http []haproxyServer
+	Tls  []haproxyServer
+}
+
+type haproxyServer struct {
+	Name    string
+	Address string
+}
+
+// HAProxy uses the first matching rule, so longer paths for the same
+// host must come first.
+type byHostAndPath []haproxyFrontend
+
+func (a byHostAndPath) Len() int      { return len(a) }
+func (a byHostAndPath) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a byHostAndPath) Less(i, j int) bool {
+	if a[i].Host != a[j].Host {
+		return a[i].Host < a[j].Host
+	}
+	if len(a[i].Path) != len(a[j].Path) {
+		return len(a[i].Path) > len(a[j].Path)
+	}
+	return a[i].Id < a[j].Id
+}
+
+// Render the routes as an HAProxy configuration.  Frontends that refer
+// to a backend that is not defined are ignored.
+func (r *Routes) WriteConfigTo(w io.Writer) error {
+	return r.writeConfigTo(w, CertificateListPath())
+}
+
+func (r *Routes) writeConfigTo(w io.Writer, certificateList string) error {
+	conf := haproxyConfig{
+		HttpPort:            HttpPort,
+		HttpsPort:           HttpsPort,
+		TerminatePort:       TerminatePort,
+		CertificateListPath: certificateList,
+	}
+	now := time.Now()
+
+	for i := range r.Frontends {
+		f := &r.Frontends[i]
+		if r.Backend(f.BackendId) == nil {
+			continue
+		}
+		path := f.Path
+		if path == "/" {
+			path = ""
+		}
+		hf := haproxyFrontend{f.Id, strings.ToLower(f.Host), path, f.BackendId}
+		if f.Accepts(ProtocolHttp) {
+			conf.Http = append(conf.Http, hf)
+		}
+		if _, ok := r.certificateFor(f, now); ok && f.Accepts(ProtocolHttps) {
+			conf.Https = append(conf.Https, hf)
+		}
+		if f.Accepts(ProtocolTls) {
+			conf.Tls = append(conf.Tls, hf)
+		}
+	}
+	sort.Sort(byHostAndPath(conf.Http))
+	sort.Sort(byHostAndPath(conf.Https))
+	sort.Sort(byHostAndPath(conf.Tls))
+
+	for i := range r.Backends {
+		b := &r.Backends[i]
+		hb := haproxyBackend{Id: b.Id}
+		for j := range b.Servers {
+			server := &b.Servers[j]
+			for k := range server.Ports {
+				p := &server.Ports[k]
+				s := haproxyServer{fmt.Sprintf("%s_%d", server.Id, p.Port), fmt.Sprintf("%s:%d", server.Host, p.Port)}
+				if hasProtocol(p.Protocols, ProtocolHttp) {
+					hb.Http = append(hb.Http, s)
+				}
+				if hasProtocol(p.Protocols, ProtocolHttps) || hasProtocol(p.Protocols, ProtocolTls) {
+					hb.Tls = append(hb.Tls, s)
+				}
+			}
+		}
+		conf.Backends = append(conf.Backends, hb)
+	}
+
+	return haproxyTemplate.Execute(w, &conf)
+}
+
+var haproxyTemplate = template.Must(template.New("haproxy.cfg").Parse(`# Generated by geard, changes will be overwritten
+global
+    daemon
+    maxconn 4096
+
+defaults
+    mode http
+    timeout connect 5s
+    timeout client 30s
+    timeout server 30s
+    timeout tunnel 1h
+
+frontend public_http
+    bind :{{.HttpPort}}
+    mode http
+    option forwardfor
+{{range .Http}}    acl host_{{.Id}} hdr(host) -i {{.Host}} {{.Host}}:{{$.HttpPort}}
+{{if .Path}}    acl path_{{.Id}} path_beg {{.Path}}
+{{end}}    use_backend be_http_{{.BackendId}} if host_{{.Id}}{{if .Path}} path_{{.Id}}{{end}}
+{{end}}{{if or .Https .Tls}}
+frontend public_tls
+    bind :{{.HttpsPort}}
+    mode tcp
+    tcp-request inspect-delay 5s
+    tcp-request content accept if { req_ssl_hello_type 1 }
+{{range .Tls}}    use_backend be_tls_{{.BackendId}} if { req_ssl_sni -i {{.Host}} }
+{{end}}{{if .Https}}    default_backend be_https_terminate
+
+backend be_https_terminate
+    mode tcp
+    server terminate 127.0.0.1:{{.TerminatePort}} send-proxy
+
+frontend public_https
+    bind 127.0.0.1:{{.TerminatePort}} ssl crt-list {{.CertificateListPath}} accept-proxy
+    mode http
+    option forwardfor
+    http-request set-header X-Forwarded-Proto https
+{{range .Https}}    acl host_{{.Id}} hdr(host) -i {{.Host}} {{.Host}}:{{$.HttpsPort}}
+{{if .Path}}    acl path_{{.Id}} path_beg {{.Path}}
+{{end}}    use_backend be_http_{{.BackendId}} if host_{{.Id}}{{if .Path}} path_{{.Id}}{{end}}
+{{end}}{{end}}{{end}}{{range .Backends}}
+backend be_http_{{.Id}}
+    mode http
+    balance roundrobin
+{{range .Http}}    server {{.Name}} {{.Address}} check
+{{end}}
+backend be_tls_{{.Id}}
+    mode tcp
+    balance source
+{{range .Tls}}    server {{.Name}} {{.Address}} check
+{{end}}{{end}}`))
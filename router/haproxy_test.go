@@ -0,0 +1,148 @@
+package router
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testCertificateInfo(id Identifier, names ...string) *CertificateInfo {
+	now := time.Now()
+	return &CertificateInfo{Id: id, Names: names, NotBefore: now.Add(-time.Hour), NotAfter: now.Add(time.Hour)}
+}
+
+func testRoutes() *Routes {
+	return &Routes{
+		Certificates: map[Identifier]*CertificateInfo{
+			"example": testCertificateInfo("example", "example.com"),
+		},
+		Frontends: []Frontend{
+			{Id: "root", Host: "Example.com", Protocols: []string{ProtocolHttp, ProtocolHttps}, BackendId: "web", CertificateIds: []Identifier{"example"}},
+			{Id: "api", Host: "example.com", Path: "/api", Protocols: []string{ProtocolHttp}, BackendId: "web"},
+			{Id: "secure", Host: "secure.example.com", Protocols: []string{ProtocolTls}, BackendId: "web"},
+			{Id: "orphan", Host: "orphan.example.com", Protocols: []string{ProtocolHttp}, BackendId: "missing"},
+		},
+		Backends: []Backend{
+			{Id: "web", Servers: Servers{
+				{Id: "a", Host: "10.0.0.1", Ports: Ports{{Port: 8080, Protocols: []string{ProtocolHttp}}, {Port: 8443, Protocols: []string{ProtocolTls}}}},
+				{Id: "b", Host: "10.0.0.2", Ports: Ports{{Port: 8080, Protocols: []string{ProtocolHttp}}}},
+			}},
+		},
+	}
+}
+
+func TestWriteConfig(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := testRoutes().WriteConfigTo(buf); err != nil {
+		t.Fatal(err)
+	}
+	conf := buf.String()
+
+	for _, line := range []string{
+		"acl host_api hdr(host) -i example.com example.com:80",
+		"acl path_api path_beg /api",
+		"use_backend be_http_web if host_api path_api",
+		"use_backend be_http_web if host_root\n",
+		"use_backend be_tls_web if { req_ssl_sni -i secure.example.com }",
+		"default_backend be_https_terminate",
+		"acl host_root hdr(host) -i example.com example.com:443",
+		"server a_8080 10.0.0.1:8080 check",
+		"server b_8080 10.0.0.2:8080 check",
+		"server a_8443 10.0.0.1:8443 check",
+	} {
+		if !strings.Contains(conf, line) {
+			t.Errorf("Expected configuration to contain %q:\n%s", line, conf)
+		}
+	}
+	if strings.Contains(conf, "orphan") {
+		t.Errorf("Frontends without a backend should not be routed:\n%s", conf)
+	}
+	if strings.Index(conf, "use_backend be_http_web if host_api") > strings.Index(conf, "use_backend be_http_web if host_root") {
+		t.Errorf("Longer paths should be matched first:\n%s", conf)
+	}
+}
+
+func TestWriteConfigWithoutEncryptedRoutes(t *testing.T) {
+	routes := &Routes{
+		Frontends: []Frontend{{Id: "root", Host: "example.com", Protocols: []string{ProtocolHttp}, BackendId: "web"}},
+		Backends:  []Backend{{Id: "web"}},
+	}
+	buf := &bytes.Buffer{}
+	if err := routes.WriteConfigTo(buf); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "public_tls") || strings.Contains(buf.String(), "ssl crt") {
+		t.Errorf("No https or tls frontends should be generated:\n%s", buf.String())
+	}
+}
+
+func TestCheckFrontend(t *testing.T) {
+	valid := Frontend{Id: "a", Host: "example.com", Path: "/", Protocols: []string{ProtocolHttp}, BackendId: "web"}
+	if err := valid.Check(); err != nil {
+		t.Errorf("Expected frontend to be valid: %v", err)
+	}
+	for _, f := range []Frontend{
+		{Id: "a", Host: "example.com", Protocols: []string{ProtocolHttp}},
+		{Id: "a", Host: "bad host", Protocols: []string{ProtocolHttp}, BackendId: "web"},
+		{Id: "a", Host: "example.com", Path: "api", Protocols: []string{ProtocolHttp}, BackendId: "web"},
+		{Id: "a", Host: "example.com", Protocols: []string{ProtocolHttps}, BackendId: "web"},
+		{Id: "a", Host: "example.com", Path: "/api", Protocols: []string{ProtocolTls}, BackendId: "web"},
+		{Id: "a", Host: "example.com", Protocols: []string{"ftp"}, BackendId: "web"},
+		{Id: "a", Host: "example.com", BackendId: "web"},
+	} {
+		if err := f.Check(); err == nil {
+			t.Errorf("Expected frontend %+v to be invalid", f)
+		}
+	}
+}
+
+func TestUpdateRoutes(t *testing.T) {
+	routes := testRoutes()
+	routes.PutFrontend(Frontend{Id: "api", Host: "api.example.com", BackendId: "web"})
+	if len(routes.Frontends) != 4 || routes.Frontend("api").Host != "api.example.com" {
+		t.Errorf("Expected frontend to be replaced: %+v", routes.Frontends)
+	}
+	if !routes.RemoveFrontend("orphan") || routes.Frontend("orphan") != nil {
+		t.Errorf("Expected frontend to be removed: %+v", routes.Frontends)
+	}
+	if routes.RemoveBackend("missing") {
+		t.Errorf("Expected missing backend not to be removed")
+	}
+}
+
+func TestWriteCertificateList(t *testing.T) {
+	expired := testCertificateInfo("expired", "www.example.com")
+	expired.NotAfter = time.Now().Add(-time.Minute)
+	routes := &Routes{
+		Certificates: map[Identifier]*CertificateInfo{
+			"other":    testCertificateInfo("other", "other.com"),
+			"expired":  expired,
+			"wildcard": testCertificateInfo("wildcard", "*.example.com"),
+		},
+		Frontends: []Frontend{
+			{Id: "a", Host: "www.example.com", Protocols: []string{ProtocolHttps}, BackendId: "web", CertificateIds: []Identifier{"other", "expired", "wildcard"}},
+			{Id: "b", Host: "other.com", Protocols: []string{ProtocolHttps}, BackendId: "web", CertificateIds: []Identifier{"missing", "other"}},
+			{Id: "c", Host: "plain.com", Protocols: []string{ProtocolHttp}, BackendId: "web", CertificateIds: []Identifier{"other"}},
+			{Id: "d", Host: "unknown.com", Protocols: []string{ProtocolHttps}, BackendId: "web", CertificateIds: []Identifier{"missing"}},
+		},
+		Backends: []Backend{{Id: "web"}},
+	}
+	buf := &bytes.Buffer{}
+	if err := routes.WriteCertificateListTo(buf); err != nil {
+		t.Fatal(err)
+	}
+	expected := Identifier("wildcard").CertificatePathFor() + " www.example.com\n" +
+		Identifier("other").CertificatePathFor() + " other.com\n"
+	if buf.String() != expected {
+		t.Errorf("Expected certificate list\n%s\ngot\n%s", expected, buf.String())
+	}
+
+	buf.Reset()
+	if err := routes.WriteConfigTo(buf); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "unknown.com") {
+		t.Errorf("Https frontends without a stored certificate should not be routed:\n%s", buf.String())
+	}
+}
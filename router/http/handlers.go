@@ -0,0 +1,289 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	nethttp "net/http"
+
+	"github.com/openshift/geard/http"
+	"github.com/openshift/geard/jobs"
+	"github.com/openshift/geard/router"
+	rjobs "github.com/openshift/geard/router/jobs"
+	"github.com/openshift/go-json-rest"
+)
+
+type HttpExtension struct{}
+
+func (h *HttpExtension) Routes() []http.HttpJobHandler {
+	return []http.HttpJobHandler{
+		&HttpUpdateFrontendRequest{},
+		&HttpPutFrontendRequest{},
+		&HttpDeleteFrontendRequest{},
+		&HttpPutBackendRequest{},
+		&HttpDeleteBackendRequest{},
+		&HttpPutCertificateRequest{},
+		&HttpGetCertificateRequest{},
+		&HttpDeleteCertificateRequest{},
+	}
+}
+
+func (h *HttpExtension) HttpJobFor(job interface{}) (exc http.RemoteExecutable, err error) {
+	switch j := job.(type) {
+	case *rjobs.UpdateFrontendRequest:
+		exc = &HttpUpdateFrontendRequest{UpdateFrontendRequest: *j}
+	case *rjobs.PutFrontendRequest:
+		exc = &HttpPutFrontendRequest{PutFrontendRequest: *j}
+	case *rjobs.DeleteFrontendRequest:
+		exc = &HttpDeleteFrontendRequest{DeleteFrontendRequest: *j}
+	case *rjobs.PutBackendRequest:
+		exc = &HttpPutBackendRequest{PutBackendRequest: *j}
+	case *rjobs.DeleteBackendRequest:
+		exc = &HttpDeleteBackendRequest{DeleteBackendRequest: *j}
+	case *rjobs.PutCertificateRequest:
+		exc = &HttpPutCertificateRequest{PutCertificateRequest: *j}
+	case *rjobs.GetCertificateRequest:
+		exc = &HttpGetCertificateRequest{GetCertificateRequest: *j}
+	case *rjobs.DeleteCertificateRequest:
+		exc = &HttpDeleteCertificateRequest{DeleteCertificateRequest: *j}
+	default:
+		err = jobs.ErrNoJobForRequest
+	}
+	return
+}
+
+func decodeBody(r *rest.Request, value interface{}) error {
+	if r.Body == nil {
+		return nil
+	}
+	dec := json.NewDecoder(io.LimitReader(r.Body, 1024*1024))
+	if err := dec.Decode(value); err != nil && err != io.EOF {
+		return err
+	}
+	return nil
+}
+
+type HttpUpdateFrontendRequest struct {
+	rjobs.UpdateFrontendRequest
+	http.DefaultRequest
+}
+
+func (h *HttpUpdateFrontendRequest) HttpMethod() string { return "PUT" }
+func (h *HttpUpdateFrontendRequest) HttpPath() string   { return "/routes" }
+func (h *HttpUpdateFrontendRequest) Handler(conf *http.HttpConfiguration) http.JobHandler {
+	return func(context *jobs.JobContext, r *rest.Request) (interface{}, error) {
+		update := rjobs.UpdateFrontendRequest{}
+		if err := decodeBody(r, &update); err != nil {
+			return nil, err
+		}
+		for i := range update.Backends {
+			if err := update.Backends[i].Check(); err != nil {
+				return nil, err
+			}
+		}
+		for i := range update.Frontends {
+			frontend := &update.Frontends[i]
+			if frontend.BackendId == "" {
+				if _, err := router.NewIdentifier(string(frontend.Id)); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if err := frontend.Check(); err != nil {
+				return nil, err
+			}
+		}
+		for _, id := range update.RemoveBackends {
+			if _, err := router.NewIdentifier(string(id)); err != nil {
+				return nil, err
+			}
+		}
+		return &update, nil
+	}
+}
+func (h *HttpUpdateFrontendRequest) MarshalHttpRequestBody(w io.Writer) error {
+	return json.NewEncoder(w).Encode(&h.UpdateFrontendRequest)
+}
+
+type HttpPutFrontendRequest struct {
+	rjobs.PutFrontendRequest
+	http.DefaultRequest
+}
+
+func (h *HttpPutFrontendRequest) HttpMethod() string { return "PUT" }
+func (h *HttpPutFrontendRequest) HttpPath() string {
+	return http.Inline("/routes/frontends/:id", string(h.Id))
+}
+func (h *HttpPutFrontendRequest) Handler(conf *http.HttpConfiguration) http.JobHandler {
+	return func(context *jobs.JobContext, r *rest.Request) (interface{}, error) {
+		id, err := router.NewIdentifier(r.PathParam("id"))
+		if err != nil {
+			return nil, err
+		}
+		frontend := rjobs.FrontendDescription{}
+		if err := decodeBody(r, &frontend); err != nil {
+			return nil, err
+		}
+		frontend.Id = id
+		if err := frontend.Check(); err != nil {
+			return nil, err
+		}
+		return &rjobs.PutFrontendRequest{FrontendDescription: frontend}, nil
+	}
+}
+func (h *HttpPutFrontendRequest) MarshalHttpRequestBody(w io.Writer) error {
+	return json.NewEncoder(w).Encode(&h.FrontendDescription)
+}
+
+type HttpDeleteFrontendRequest struct {
+	rjobs.DeleteFrontendRequest
+	http.DefaultRequest
+}
+
+func (h *HttpDeleteFrontendRequest) HttpMethod() string { return "DELETE" }
+func (h *HttpDeleteFrontendRequest) HttpPath() string {
+	return http.Inline("/routes/frontends/:id", string(h.Id))
+}
+func (h *HttpDeleteFrontendRequest) Handler(conf *http.HttpConfiguration) http.JobHandler {
+	return func(context *jobs.JobContext, r *rest.Request) (interface{}, error) {
+		id, err := router.NewIdentifier(r.PathParam("id"))
+		if err != nil {
+			return nil, err
+		}
+		return &rjobs.DeleteFrontendRequest{Id: id}, nil
+	}
+}
+
+type HttpPutBackendRequest struct {
+	rjobs.PutBackendRequest
+	http.DefaultRequest
+}
+
+func (h *HttpPutBackendRequest) HttpMethod() string { return "PUT" }
+func (h *HttpPutBackendRequest) HttpPath() string {
+	return http.Inline("/routes/backends/:id", string(h.Id))
+}
+func (h *HttpPutBackendRequest) Handler(conf *http.HttpConfiguration) http.JobHandler {
+	return func(context *jobs.JobContext, r *rest.Request) (interface{}, error) {
+		id, err := router.NewIdentifier(r.PathParam("id"))
+		if err != nil {
+			return nil, err
+		}
+		backend := router.Backend{}
+		if err := decodeBody(r, &backend); err != nil {
+			return nil, err
+		}
+		backend.Id = id
+		if err := backend.Check(); err != nil {
+			return nil, err
+		}
+		return &rjobs.PutBackendRequest{Backend: backend}, nil
+	}
+}
+func (h *HttpPutBackendRequest) MarshalHttpRequestBody(w io.Writer) error {
+	return json.NewEncoder(w).Encode(&h.Backend)
+}
+
+type HttpDeleteBackendRequest struct {
+	rjobs.DeleteBackendRequest
+	http.DefaultRequest
+}
+
+func (h *HttpDeleteBackendRequest) HttpMethod() string { return "DELETE" }
+func (h *HttpDeleteBackendRequest) HttpPath() string {
+	return http.Inline("/routes/backends/:id", string(h.Id))
+}
+func (h *HttpDeleteBackendRequest) Handler(conf *http.HttpConfiguration) http.JobHandler {
+	return func(context *jobs.JobContext, r *rest.Request) (interface{}, error) {
+		id, err := router.NewIdentifier(r.PathParam("id"))
+		if err != nil {
+			return nil, err
+		}
+		return &rjobs.DeleteBackendRequest{Id: id}, nil
+	}
+}
+
+type HttpPutCertificateRequest struct {
+	rjobs.PutCertificateRequest
+	http.DefaultRequest
+}
+
+func (h *HttpPutCertificateRequest) HttpMethod() string { return "PUT" }
+func (h *HttpPutCertificateRequest) HttpPath() string {
+	return http.Inline("/routes/certificates/:id", string(h.Id))
+}
+func (h *HttpPutCertificateRequest) Handler(conf *http.HttpConfiguration) http.JobHandler {
+	return func(context *jobs.JobContext, r *rest.Request) (interface{}, error) {
+		id, err := router.NewIdentifier(r.PathParam("id"))
+		if err != nil {
+			return nil, err
+		}
+		cert := router.Certificate{}
+		if err := decodeBody(r, &cert); err != nil {
+			return nil, err
+		}
+		cert.Id = id
+		if err := cert.Check(); err != nil {
+			return nil, err
+		}
+		return &rjobs.PutCertificateRequest{Certificate: cert}, nil
+	}
+}
+func (h *HttpPutCertificateRequest) MarshalHttpRequestBody(w io.Writer) error {
+	return json.NewEncoder(w).Encode(&h.Certificate)
+}
+func (h *HttpPutCertificateRequest) UnmarshalHttpResponse(headers nethttp.Header, r io.Reader, mode http.ResponseContentMode) (interface{}, error) {
+	return unmarshalCertificateInfo(r)
+}
+
+type HttpGetCertificateRequest struct {
+	rjobs.GetCertificateRequest
+	http.DefaultRequest
+}
+
+func (h *HttpGetCertificateRequest) HttpMethod() string { return "GET" }
+func (h *HttpGetCertificateRequest) HttpPath() string {
+	return http.Inline("/routes/certificates/:id", string(h.Id))
+}
+func (h *HttpGetCertificateRequest) Handler(conf *http.HttpConfiguration) http.JobHandler {
+	return func(context *jobs.JobContext, r *rest.Request) (interface{}, error) {
+		id, err := router.NewIdentifier(r.PathParam("id"))
+		if err != nil {
+			return nil, err
+		}
+		return &rjobs.GetCertificateRequest{Id: id}, nil
+	}
+}
+func (h *HttpGetCertificateRequest) UnmarshalHttpResponse(headers nethttp.Header, r io.Reader, mode http.ResponseContentMode) (interface{}, error) {
+	return unmarshalCertificateInfo(r)
+}
+
+func unmarshalCertificateInfo(r io.Reader) (interface{}, error) {
+	if r == nil {
+		return nil, errors.New("Unexpected empty response body for a certificate")
+	}
+	info := &router.CertificateInfo{}
+	if err := json.NewDecoder(r).Decode(info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+type HttpDeleteCertificateRequest struct {
+	rjobs.DeleteCertificateRequest
+	http.DefaultRequest
+}
+
+func (h *HttpDeleteCertificateRequest) HttpMethod() string { return "DELETE" }
+func (h *HttpDeleteCertificateRequest) HttpPath() string {
+	return http.Inline("/routes/certificates/:id", string(h.Id))
+}
+func (h *HttpDeleteCertificateRequest) Handler(conf *http.HttpConfiguration) http.JobHandler {
+	return func(context *jobs.JobContext, r *rest.Request) (interface{}, error) {
+		id, err := router.NewIdentifier(r.PathParam("id"))
+		if err != nil {
+			return nil, err
+		}
+		return &rjobs.DeleteCertificateRequest{Id: id}, nil
+	}
+}
@@ -0,0 +1,228 @@
+// Jobs for changing the routes served by the router on a host.
+package jobs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/openshift/geard/config"
+	"github.com/openshift/geard/jobs"
+	"github.com/openshift/geard/router"
+)
+
+var (
+	ErrFrontendNotFound     = jobs.SimpleError{Failure: jobs.ResponseNotFound, Reason: "The specified frontend does not exist."}
+	ErrBackendNotFound      = jobs.SimpleError{Failure: jobs.ResponseNotFound, Reason: "The specified backend does not exist."}
+	ErrCertificateNotFound  = jobs.SimpleError{Failure: jobs.ResponseNotFound, Reason: "The specified certificate does not exist."}
+	ErrCertificateInUse     = jobs.SimpleError{Failure: jobs.ResponseAlreadyExists, Reason: "The certificate is used by one or more frontends."}
+	ErrCertificateInvalid   = jobs.SimpleError{Failure: jobs.ResponseInvalidRequest, Reason: "The certificate is not valid."}
+	ErrBackendInUse         = jobs.SimpleError{Failure: jobs.ResponseAlreadyExists, Reason: "The backend is used by one or more frontends."}
+	ErrRouterUpdateFailed   = jobs.SimpleError{Failure: jobs.ResponseError, Reason: "Unable to update the router configuration."}
+	ErrRouterConfigRejected = jobs.SimpleError{Failure: jobs.ResponseInvalidRequest, Reason: "The router configuration was rejected by HAProxy."}
+)
+
+var (
+	errNoSuchFrontend = errors.New("no such frontend")
+	errNoSuchBackend  = errors.New("no such backend")
+	errNoSuchCert     = errors.New("no such certificate")
+	errBackendInUse   = errors.New("backend in use")
+)
+
+// Return a job extension that casts requests directly to jobs
+func NewRouterExtension() jobs.JobExtension {
+	return &jobs.JobInitializer{
+		Extension: jobs.JobExtensionFunc(sharesImplementation),
+		Func:      config.HasRequiredDirectories,
+	}
+}
+
+func sharesImplementation(request interface{}) (jobs.Job, error) {
+	if job, ok := request.(jobs.Job); ok {
+		return job, nil
+	}
+	return nil, jobs.ErrNoJobForRequest
+}
+
+func updateFailure(err error) error {
+	switch err {
+	case errNoSuchFrontend:
+		return ErrFrontendNotFound
+	case errNoSuchBackend:
+		return ErrBackendNotFound
+	case errNoSuchCert, router.ErrCertificateNotFound:
+		return ErrCertificateNotFound
+	case errBackendInUse:
+		return ErrBackendInUse
+	}
+	switch e := err.(type) {
+	case router.ConfigurationError:
+		return jobs.StructuredJobError{SimpleError: ErrRouterConfigRejected, Data: e.Output}
+	case router.CertificateInUseError:
+		return jobs.StructuredJobError{SimpleError: ErrCertificateInUse, Data: e.Error()}
+	}
+	return ErrRouterUpdateFailed
+}
+
+func putFrontend(routes *router.Routes, f *FrontendDescription) error {
+	if routes.Backend(f.BackendId) == nil {
+		return errNoSuchBackend
+	}
+	if err := f.CheckCertificate(routes.Certificates); err != nil {
+		return errNoSuchCert
+	}
+	routes.PutFrontend(f.Frontend)
+	return nil
+}
+
+// Create or replace a frontend.  The backend it refers to must already
+// exist.
+type PutFrontendRequest struct {
+	FrontendDescription
+}
+
+func (j *PutFrontendRequest) Execute(resp jobs.Response) {
+	err := router.Update(func(routes *router.Routes) error {
+		return putFrontend(routes, &j.FrontendDescription)
+	})
+	if err != nil {
+		log.Printf("router: Unable to save frontend %s: %v", j.Id, err)
+		resp.Failure(updateFailure(err))
+		return
+	}
+	resp.Success(jobs.ResponseOk)
+}
+
+type DeleteFrontendRequest struct {
+	Id router.Identifier
+}
+
+func (j *DeleteFrontendRequest) Execute(resp jobs.Response) {
+	err := router.Update(func(routes *router.Routes) error {
+		if !routes.RemoveFrontend(j.Id) {
+			return errNoSuchFrontend
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("router: Unable to delete frontend %s: %v", j.Id, err)
+		resp.Failure(updateFailure(err))
+		return
+	}
+	resp.Success(jobs.ResponseOk)
+}
+
+// Create or replace a backend and the servers that answer for it.
+type PutBackendRequest struct {
+	router.Backend
+}
+
+func (j *PutBackendRequest) Execute(resp jobs.Response) {
+	err := router.Update(func(routes *router.Routes) error {
+		routes.PutBackend(j.Backend)
+		return nil
+	})
+	if err != nil {
+		log.Printf("router: Unable to save backend %s: %v", j.Id, err)
+		resp.Failure(updateFailure(err))
+		return
+	}
+	resp.Success(jobs.ResponseOk)
+}
+
+type DeleteBackendRequest struct {
+	Id router.Identifier
+}
+
+func (j *DeleteBackendRequest) Execute(resp jobs.Response) {
+	err := router.Update(func(routes *router.Routes) error {
+		for i := range routes.Frontends {
+			if routes.Frontends[i].BackendId == j.Id {
+				return errBackendInUse
+			}
+		}
+		if !routes.RemoveBackend(j.Id) {
+			return errNoSuchBackend
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("router: Unable to delete backend %s: %v", j.Id, err)
+		resp.Failure(updateFailure(err))
+		return
+	}
+	resp.Success(jobs.ResponseOk)
+}
+
+// Validate and store a certificate, decrypting its private key.  The
+// details of the certificate and any warnings about its expiry are
+// returned.
+type PutCertificateRequest struct {
+	router.Certificate
+}
+
+// The private key and its password are left out when the request is
+// serialized, so they are never recorded with the job.
+func (j *PutCertificateRequest) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct{ Id router.Identifier }{j.Id})
+}
+
+// Only the id is printed when the job is logged.
+func (j *PutCertificateRequest) String() string {
+	return fmt.Sprintf("{Id:%s}", j.Id)
+}
+
+func (j *PutCertificateRequest) GoString() string {
+	return j.String()
+}
+
+func (j *PutCertificateRequest) Execute(resp jobs.Response) {
+	info, err := router.SaveCertificate(&j.Certificate)
+	if err != nil {
+		if e, ok := err.(router.InvalidCertificateError); ok {
+			resp.Failure(jobs.StructuredJobError{SimpleError: ErrCertificateInvalid, Data: e.Reason})
+			return
+		}
+		log.Printf("router: Unable to save certificate %s: %v", j.Id, err)
+		resp.Failure(updateFailure(err))
+		return
+	}
+	for _, warning := range info.Warnings {
+		log.Printf("router: certificate %s: %s", j.Id, warning)
+	}
+	resp.SuccessWithData(jobs.ResponseOk, info)
+}
+
+type GetCertificateRequest struct {
+	Id router.Identifier
+}
+
+func (j *GetCertificateRequest) ReadOnly() bool {
+	return true
+}
+
+func (j *GetCertificateRequest) Execute(resp jobs.Response) {
+	info, err := router.GetCertificate(j.Id)
+	if err != nil {
+		if err != router.ErrCertificateNotFound {
+			log.Printf("router: Unable to read certificate %s: %v", j.Id, err)
+		}
+		resp.Failure(updateFailure(err))
+		return
+	}
+	resp.SuccessWithData(jobs.ResponseOk, info)
+}
+
+type DeleteCertificateRequest struct {
+	Id router.Identifier
+}
+
+func (j *DeleteCertificateRequest) Execute(resp jobs.Response) {
+	if err := router.RemoveCertificate(j.Id); err != nil {
+		log.Printf("router: Unable to delete certificate %s: %v", j.Id, err)
+		resp.Failure(updateFailure(err))
+		return
+	}
+	resp.Success(jobs.ResponseOk)
+}
@@ -3,29 +3,51 @@ package jobs
 import (
 	"errors"
 	"fmt"
+
 	"github.com/openshift/geard/router"
 )
 
+// A frontend that may add a certificate from the certificate store by
+// id, as the routes of a deployment do.
 type FrontendDescription struct {
 	router.Frontend
 	CertificateId router.Identifier `json:"CertificateId,omitempty"`
-	BackendId     router.Identifier `json:"BackendId,omitempty"`
 }
 
 type BackendDescription struct {
 	router.Backend
 }
 
-func (f *FrontendDescription) CheckCertificate(certs router.Certificates) error {
-	if f.CertificateId == "" {
-		return nil
+func (f *FrontendDescription) Check() error {
+	if f.CertificateId != "" {
+		return f.Frontend.CheckWithCertificateId(f.CertificateId)
+	}
+	return f.Frontend.Check()
+}
+
+func (b *BackendDescription) Check() error {
+	return b.Backend.Check()
+}
+
+// Add the certificate given by id to the certificates of the frontend.
+// The certificate and any the frontend already has must be available.
+func (f *FrontendDescription) CheckCertificate(available map[router.Identifier]*router.CertificateInfo) error {
+	if f.CertificateId != "" {
+		found := false
+		for _, id := range f.CertificateIds {
+			if id == f.CertificateId {
+				found = true
+			}
+		}
+		if !found {
+			f.CertificateIds = append(f.CertificateIds, f.CertificateId)
+		}
+		f.CertificateId = ""
 	}
-	for i := range certs {
-		if f.CertificateId == certs[i].Id {
-			f.Certificate = &certs[i]
-			f.CertificateId = ""
-			return nil
+	for _, id := range f.CertificateIds {
+		if _, ok := available[id]; !ok {
+			return errors.New(fmt.Sprintf("No certificate with id %s is defined", id))
 		}
 	}
-	return errors.New(fmt.Sprintf("No certificate with id %s is defined", f.CertificateId))
+	return nil
 }
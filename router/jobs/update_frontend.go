@@ -1,54 +1,50 @@
 package jobs
 
 import (
-	// "fmt"
-	// "github.com/openshift/geard/config"
-	jobs "github.com/openshift/geard/jobs"
+	"log"
+
+	"github.com/openshift/geard/jobs"
 	"github.com/openshift/geard/router"
-	"github.com/openshift/geard/utils"
-	// "io"
-	// "log"
-	// "os"
-	// "os/exec"
-	// "os/user"
-	// "path/filepath"
-	// "strconv"
-	// "time"
 )
 
-// var (
-// 	ErrRepositoryAlreadyExists = jobs.SimpleError{jobs.ResponseAlreadyExists, "A repository with this identifier already exists."}
-// 	ErrSubscribeToUnit         = jobs.SimpleError{jobs.ResponseError, "Unable to watch for the completion of this action."}
-// 	ErrRepositoryCreateFailed  = jobs.SimpleError{jobs.ResponseError, "Unable to create the repository."}
-// )
-
+// Replace a set of frontends and backends in a single change to the
+// router configuration.  Frontends without a backend are removed, and
+// then the backends in RemoveBackends, which no remaining frontend may
+// use.
 type UpdateFrontendRequest struct {
-	Frontends []FrontendDescription
-	Backends  []BackendDescription
-}
-
-type backendError struct {
-	Id    router.Identifier
-	Error error
+	Frontends      []FrontendDescription
+	Backends       []BackendDescription
+	RemoveBackends []router.Identifier `json:",omitempty"`
 }
 
-func (j UpdateFrontendRequest) Execute(resp jobs.Response) {
-	// detach empty frontends
-	for _, frontend := range j.Frontends {
-		if frontend.BackendId == "" {
-			frontend.Remove()
+func (j *UpdateFrontendRequest) Execute(resp jobs.Response) {
+	err := router.Update(func(routes *router.Routes) error {
+		for i := range j.Backends {
+			routes.PutBackend(j.Backends[i].Backend)
 		}
-	}
-
-	errs := []backendError{}
-	for _, backend := range j.Backends {
-		if err := utils.WriteToPathExclusive(backend.Id.BackendPathFor(), 0554, backend); err != nil {
-			errs = append(errs, backendError{backend.Id, err})
+		for i := range j.Frontends {
+			frontend := &j.Frontends[i]
+			if frontend.BackendId == "" {
+				routes.RemoveFrontend(frontend.Id)
+				continue
+			}
+			if err := putFrontend(routes, frontend); err != nil {
+				return err
+			}
 		}
-	}
-	if len(errs) != 0 {
-		log.Printf("Unable to persist some backends: %+v", errs)
-		resp.Failure(ErrBackendWriteFailed)
+		for _, id := range j.RemoveBackends {
+			for i := range routes.Frontends {
+				if routes.Frontends[i].BackendId == id {
+					return errBackendInUse
+				}
+			}
+			routes.RemoveBackend(id)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("router: Unable to update frontends: %v", err)
+		resp.Failure(updateFailure(err))
 		return
 	}
 	resp.Success(jobs.ResponseOk)
@@ -1,13 +1,22 @@
+// Route external HTTP and TLS traffic to the containers on a set of
+// hosts.  Frontends (a host name and optional path prefix) and backends
+// (the servers that answer for them) are persisted under the container
+// base path and rendered into an HAProxy configuration.
 package router
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
-	"github.com/openshift/geard/config"
-	"github.com/openshift/geard/port"
-	"github.com/openshift/geard/utils"
-	"io"
+	"io/ioutil"
+	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
+
+	"github.com/openshift/geard/config"
+	"github.com/openshift/geard/port"
 )
 
 const (
@@ -18,23 +27,44 @@ const (
 
 type Identifier string
 
+var allowedIdentifier = regexp.MustCompile("\\A[a-zA-Z0-9\\-_]{1,64}\\z")
+
+func NewIdentifier(s string) (Identifier, error) {
+	if !allowedIdentifier.MatchString(s) {
+		return "", errors.New("Route identifiers must be 1-64 characters of letters, numbers, '-', and '_'")
+	}
+	return Identifier(s), nil
+}
+
+var (
+	allowedHost = regexp.MustCompile("\\A[a-zA-Z0-9]([a-zA-Z0-9\\-]{0,62}\\.)*[a-zA-Z0-9\\-]{1,63}\\z")
+	allowedPath = regexp.MustCompile("\\A/[^\\s#?]*\\z")
+)
+
 type Backend struct {
 	Id      Identifier
 	Servers Servers
 }
 
+// A host name and optional path routed to a backend.  Frontends that
+// accept https are served with the first of their certificates that is
+// valid for the host.
 type Frontend struct {
-	Host        string
-	Path        string
-	Protocols   []string
-	Certificate *Certificate
+	Id             Identifier
+	Host           string
+	Path           string `json:",omitempty"`
+	Protocols      []string
+	BackendId      Identifier   `json:",omitempty"`
+	CertificateIds []Identifier `json:",omitempty"`
 }
 
+// A PEM encoded certificate chain, leaf first, and the private key of
+// the certificate.  The key may be encrypted with PrivateKeyPassword.
 type Certificate struct {
 	Id                 Identifier
 	Contents           []byte
 	PrivateKey         []byte
-	PrivateKeyPassword string
+	PrivateKeyPassword string `json:",omitempty"`
 }
 type Certificates []Certificate
 
@@ -51,22 +81,299 @@ type Port struct {
 }
 type Ports []Port
 
-func (i Identifier) BackendPathfor() string {
-	return utils.IsolateContentPath(filepath.Join(config.ContainerBasePath(), "routes", "backends"), string(i), "")
+func (f *Frontend) Check() error {
+	return f.check(len(f.CertificateIds) > 0)
+}
+
+// Check a frontend that will also be served with the certificate with
+// the given id when it is saved.
+func (f *Frontend) CheckWithCertificateId(id Identifier) error {
+	if _, err := NewIdentifier(string(id)); err != nil {
+		return err
+	}
+	return f.check(true)
+}
+
+func (f *Frontend) check(hasCertificate bool) error {
+	if _, err := NewIdentifier(string(f.Id)); err != nil {
+		return err
+	}
+	if _, err := NewIdentifier(string(f.BackendId)); err != nil {
+		return errors.New("A frontend must have a backend: " + err.Error())
+	}
+	if !allowedHost.MatchString(f.Host) {
+		return errors.New(fmt.Sprintf("The frontend host '%s' is not a valid host name", f.Host))
+	}
+	if f.Path != "" && !allowedPath.MatchString(f.Path) {
+		return errors.New("The frontend path must begin with '/' and may not contain whitespace, '#', or '?'")
+	}
+	if len(f.Protocols) == 0 {
+		return errors.New("A frontend must accept at least one protocol")
+	}
+	for _, protocol := range f.Protocols {
+		switch protocol {
+		case ProtocolHttp:
+		case ProtocolHttps:
+			if !hasCertificate {
+				return errors.New("A frontend accepting https must have a certificate")
+			}
+		case ProtocolTls:
+			if f.Path != "" && f.Path != "/" {
+				return errors.New("A frontend accepting tls is routed by host name only and may not have a path")
+			}
+		default:
+			return errors.New(fmt.Sprintf("The protocol '%s' is not one of http, https, or tls", protocol))
+		}
+	}
+	for _, id := range f.CertificateIds {
+		if _, err := NewIdentifier(string(id)); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func (f *Frontend) Remove() {
+func (f *Frontend) Accepts(protocol string) bool {
+	return hasProtocol(f.Protocols, protocol)
 }
 
-func (b *Backend) WriteTo(w io.Writer) error {
+func (c *Certificate) Check() error {
+	if _, err := NewIdentifier(string(c.Id)); err != nil {
+		return err
+	}
+	if len(c.Contents) == 0 || len(c.PrivateKey) == 0 {
+		return errors.New("A certificate must have contents and a private key")
+	}
+	return nil
+}
+
+func (b *Backend) Check() error {
+	if _, err := NewIdentifier(string(b.Id)); err != nil {
+		return err
+	}
 	for i := range b.Servers {
 		server := &b.Servers[i]
+		if _, err := NewIdentifier(string(server.Id)); err != nil {
+			return err
+		}
+		if server.Host == "" {
+			return errors.New(fmt.Sprintf("The server %s must have a host", server.Id))
+		}
+		if len(server.Ports) == 0 {
+			return errors.New(fmt.Sprintf("The server %s must have at least one port", server.Id))
+		}
 		for j := range server.Ports {
-			port := &server.Ports[j]
-			if _, err := fmt.Fprintf(w, "%d\t%s\t%s\t%s", port.Port, server.Id, server.Host, strings.Join(port.Protocols, ",")); err != nil {
-				return err
+			p := &server.Ports[j]
+			if p.Port == port.InvalidPort {
+				return errors.New(fmt.Sprintf("The server %s has an invalid port", server.Id))
+			}
+			for _, protocol := range p.Protocols {
+				if protocol != ProtocolHttp && protocol != ProtocolHttps && protocol != ProtocolTls {
+					return errors.New(fmt.Sprintf("The protocol '%s' is not one of http, https, or tls", protocol))
+				}
 			}
 		}
 	}
 	return nil
 }
+
+func hasProtocol(protocols []string, protocol string) bool {
+	for i := range protocols {
+		if protocols[i] == protocol {
+			return true
+		}
+	}
+	return false
+}
+
+func basePath() string {
+	return filepath.Join(config.ContainerBasePath(), "routes")
+}
+
+func (i Identifier) FrontendPathFor() string {
+	return filepath.Join(basePath(), "frontends", string(i)+".json")
+}
+
+func (i Identifier) BackendPathFor() string {
+	return filepath.Join(basePath(), "backends", string(i)+".json")
+}
+
+// The stored certificate chain followed by its unencrypted private key,
+// as loaded by HAProxy.
+func (i Identifier) CertificatePathFor() string {
+	return filepath.Join(CertificatesPath(), string(i)+".pem")
+}
+
+func CertificatesPath() string {
+	return filepath.Join(basePath(), "certificates")
+}
+
+func init() {
+	config.AddRequiredDirectory(
+		0750,
+		filepath.Join(basePath(), "frontends"),
+		filepath.Join(basePath(), "backends"),
+	)
+	config.AddRequiredDirectory(0700, CertificatesPath())
+}
+
+// Stop routing the frontend.  The configuration must be applied again
+// before the change is visible.
+func (f *Frontend) Remove() error {
+	if err := os.Remove(f.Id.FrontendPathFor()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (b *Backend) Remove() error {
+	if err := os.Remove(b.Id.BackendPathFor()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (f *Frontend) Save() error {
+	return writeJson(f.Id.FrontendPathFor(), f, 0640)
+}
+
+func (b *Backend) Save() error {
+	return writeJson(b.Id.BackendPathFor(), b, 0640)
+}
+
+func writeJson(path string, value interface{}, mode os.FileMode) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return writeFile(path, data, mode)
+}
+
+func readJson(path string, value interface{}) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, value)
+}
+
+func listIds(dir string) ([]Identifier, error) {
+	names, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	ids := make([]Identifier, 0, len(names))
+	for _, name := range names {
+		ids = append(ids, Identifier(strings.TrimSuffix(filepath.Base(name), ".json")))
+	}
+	return ids, nil
+}
+
+func GetFrontend(id Identifier) (*Frontend, error) {
+	f := &Frontend{}
+	if err := readJson(id.FrontendPathFor(), f); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func GetBackend(id Identifier) (*Backend, error) {
+	b := &Backend{}
+	if err := readJson(id.BackendPathFor(), b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// The routes currently defined on this host.
+type Routes struct {
+	Frontends    []Frontend
+	Backends     []Backend
+	Certificates map[Identifier]*CertificateInfo
+}
+
+func LoadRoutes() (*Routes, error) {
+	certs, err := loadCertificates()
+	if err != nil {
+		return nil, err
+	}
+	routes := &Routes{Certificates: certs}
+	ids, err := listIds(filepath.Join(basePath(), "frontends"))
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		f, err := GetFrontend(id)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read frontend %s: %v", id, err)
+		}
+		routes.Frontends = append(routes.Frontends, *f)
+	}
+	if ids, err = listIds(filepath.Join(basePath(), "backends")); err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		b, err := GetBackend(id)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read backend %s: %v", id, err)
+		}
+		routes.Backends = append(routes.Backends, *b)
+	}
+	return routes, nil
+}
+
+func (r *Routes) Backend(id Identifier) *Backend {
+	for i := range r.Backends {
+		if r.Backends[i].Id == id {
+			return &r.Backends[i]
+		}
+	}
+	return nil
+}
+
+func (r *Routes) Frontend(id Identifier) *Frontend {
+	for i := range r.Frontends {
+		if r.Frontends[i].Id == id {
+			return &r.Frontends[i]
+		}
+	}
+	return nil
+}
+
+// Add or replace a frontend.
+func (r *Routes) PutFrontend(f Frontend) {
+	if existing := r.Frontend(f.Id); existing != nil {
+		*existing = f
+		return
+	}
+	r.Frontends = append(r.Frontends, f)
+}
+
+func (r *Routes) PutBackend(b Backend) {
+	if existing := r.Backend(b.Id); existing != nil {
+		*existing = b
+		return
+	}
+	r.Backends = append(r.Backends, b)
+}
+
+func (r *Routes) RemoveFrontend(id Identifier) bool {
+	for i := range r.Frontends {
+		if r.Frontends[i].Id == id {
+			r.Frontends = append(r.Frontends[:i], r.Frontends[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Routes) RemoveBackend(id Identifier) bool {
+	for i := range r.Backends {
+		if r.Backends[i].Id == id {
+			r.Backends = append(r.Backends[:i], r.Backends[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
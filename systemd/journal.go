@@ -6,12 +6,122 @@ import (
 	"io"
 	"log"
 	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
 var ErrLogWriteTimeout = errors.New("journal: Maximum duration exceeded, timeout")
 var ErrLogComplete = errors.New("journal: Closed by caller")
 
+var journalPriorities = []string{"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug"}
+
+// The number of entries returned when neither a count nor a start is set
+const DefaultLogLines = 30
+
+// Filters applied to the journal entries of a unit.
+type LogOptions struct {
+	// The number of most recent entries to return.  If 0, all entries
+	// after Since are returned, or DefaultLogLines if Since is not set.
+	Lines int `json:",omitempty"`
+	Since time.Time
+	Until time.Time
+	// A priority name or number (0-7), or a range such as "err..warning".
+	// Only entries at least as important are returned.
+	Priority string `json:",omitempty"`
+	// Continue returning new entries as they are written
+	Follow bool `json:",omitempty"`
+}
+
+func (o *LogOptions) Check() error {
+	if o.Lines < 0 {
+		return errors.New("The number of lines may not be negative")
+	}
+	if !o.Since.IsZero() && !o.Until.IsZero() && o.Until.Before(o.Since) {
+		return errors.New("The end of the log range must be after the start")
+	}
+	if o.Follow && !o.Until.IsZero() {
+		return errors.New("Logs may not be followed when an end is set")
+	}
+	if o.Priority != "" {
+		for _, p := range strings.SplitN(o.Priority, "..", 2) {
+			if !isJournalPriority(p) {
+				return errors.New(fmt.Sprintf("The priority '%s' must be one of %s or 0-7", p, strings.Join(journalPriorities, ", ")))
+			}
+		}
+	}
+	return nil
+}
+
+func isJournalPriority(p string) bool {
+	if n, err := strconv.Atoi(p); err == nil {
+		return n >= 0 && n < len(journalPriorities)
+	}
+	for i := range journalPriorities {
+		if p == journalPriorities[i] {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *LogOptions) journalArgs() []string {
+	args := []string{}
+	if o.Lines > 0 {
+		args = append(args, "--lines="+strconv.Itoa(o.Lines))
+	} else if o.Since.IsZero() {
+		args = append(args, "--lines="+strconv.Itoa(DefaultLogLines))
+	}
+	// journalctl interprets times without a zone as local
+	if !o.Since.IsZero() {
+		args = append(args, "--since="+o.Since.Local().Format("2006-01-02 15:04:05"))
+	}
+	if !o.Until.IsZero() {
+		args = append(args, "--until="+o.Until.Local().Format("2006-01-02 15:04:05"))
+	}
+	if o.Priority != "" {
+		args = append(args, "--priority="+o.Priority)
+	}
+	if o.Follow {
+		args = append(args, "--follow")
+	}
+	return args
+}
+
+// The journal entries of one or more units, read as a stream of JSON
+// documents, merged in the order they were written.
+type Journal struct {
+	io.Reader
+	cmd   *exec.Cmd
+	close sync.Once
+}
+
+func OpenJournal(units []string, opts *LogOptions) (*Journal, error) {
+	args := append([]string{"--quiet", "--output=json"}, opts.journalArgs()...)
+	for i := range units {
+		args = append(args, "--unit="+units[i])
+	}
+	cmd := exec.Command("/usr/bin/journalctl", args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &Journal{Reader: stdout, cmd: cmd}, nil
+}
+
+// Stop reading the journal.  May be called more than once.
+func (j *Journal) Close() error {
+	j.close.Do(func() {
+		j.cmd.Process.Kill()
+		j.cmd.Wait()
+	})
+	return nil
+}
+
 func ProcessLogsForUnit(unit string) (io.ReadCloser, error) {
 	cmd := exec.Command("/usr/bin/journalctl", "--since=now", "-q", "-f", "--unit", unit)
 	stdout, err := cmd.StdoutPipe()
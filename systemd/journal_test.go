@@ -0,0 +1,53 @@
+package systemd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLogOptionsCheck(t *testing.T) {
+	now := time.Now()
+	valid := []LogOptions{
+		{},
+		{Lines: 10, Follow: true},
+		{Since: now.Add(-time.Hour), Until: now},
+		{Priority: "err"},
+		{Priority: "3"},
+		{Priority: "warning..err"},
+	}
+	for i := range valid {
+		if err := valid[i].Check(); err != nil {
+			t.Errorf("Expected %+v to be valid: %v", valid[i], err)
+		}
+	}
+
+	invalid := []LogOptions{
+		{Lines: -1},
+		{Since: now, Until: now.Add(-time.Hour)},
+		{Until: now, Follow: true},
+		{Priority: "error"},
+		{Priority: "8"},
+		{Priority: "err.."},
+	}
+	for i := range invalid {
+		if err := invalid[i].Check(); err == nil {
+			t.Errorf("Expected %+v to be invalid", invalid[i])
+		}
+	}
+}
+
+func TestLogOptionsJournalArgs(t *testing.T) {
+	since := time.Date(2014, 5, 1, 12, 30, 0, 0, time.Local)
+	opts := LogOptions{Lines: 20, Since: since, Priority: "info", Follow: true}
+	expected := []string{"--lines=20", "--since=2014-05-01 12:30:00", "--priority=info", "--follow"}
+	if args := opts.journalArgs(); !reflect.DeepEqual(args, expected) {
+		t.Errorf("Expected %v, got %v", expected, args)
+	}
+	if args := (&LogOptions{}).journalArgs(); !reflect.DeepEqual(args, []string{"--lines=30"}) {
+		t.Errorf("Expected the default number of lines, got %v", args)
+	}
+	if args := (&LogOptions{Since: since}).journalArgs(); !reflect.DeepEqual(args, []string{"--since=2014-05-01 12:30:00"}) {
+		t.Errorf("Expected every line since the start, got %v", args)
+	}
+}
@@ -35,3 +35,14 @@ func IsolateContentPathWithPerm(base, id, suffix string, perm os.FileMode) strin
 func IsolateContentPath(base, id, suffix string) string {
 	return IsolateContentPathWithPerm(base, id, suffix, 0770)
 }
+
+// Change the owner of a directory and everything beneath it, without
+// following symlinks.
+func ChownTree(root string, uid, gid int) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		return os.Lchown(path, uid, gid)
+	})
+}
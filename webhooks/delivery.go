@@ -0,0 +1,153 @@
+package webhooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/openshift/geard/containers"
+	csystemd "github.com/openshift/geard/containers/systemd"
+)
+
+const (
+	SignatureHeader = "X-Geard-Signature"
+	EventHeader     = "X-Geard-Event"
+	DeliveryHeader  = "X-Geard-Delivery"
+)
+
+// The body POSTed to a webhook.
+type Notification struct {
+	Delivery  string
+	Webhook   Identifier
+	Container containers.Identifier
+	Type      csystemd.EventType
+	Time      time.Time
+}
+
+// Delivers events to the registered webhooks.
+type Notifier struct {
+	Client *http.Client
+	// The number of times a notification is attempted before it is dropped
+	Attempts int
+	// The delay before the first retry, doubled on each attempt up to
+	// MaxBackoff
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+var DefaultNotifier = &Notifier{
+	Client:     &http.Client{Timeout: 30 * time.Second},
+	Attempts:   6,
+	Backoff:    time.Second,
+	MaxBackoff: 5 * time.Minute,
+}
+
+// Deliver events from this server to the registered webhooks until the
+// process exits.  The daemon is still started if events are unavailable.
+func StartNotifier() error {
+	if err := DefaultNotifier.Start(); err != nil {
+		log.Printf("webhooks: Unable to subscribe to container events, webhooks will not be notified: %v", err)
+	}
+	return nil
+}
+
+func (n *Notifier) Start() error {
+	subscription, err := csystemd.SubscribeToEvents()
+	if err != nil {
+		return err
+	}
+	go func() {
+		for event := range subscription.Events() {
+			n.Notify(event)
+		}
+	}()
+	return nil
+}
+
+// Send an event to every webhook that matches it.
+func (n *Notifier) Notify(event *csystemd.ContainerEvent) {
+	hooks, err := List()
+	if err != nil {
+		log.Printf("webhooks: Unable to load webhooks: %v", err)
+		return
+	}
+	now := time.Now()
+	for _, hook := range hooks {
+		if !hook.Matches(event) {
+			continue
+		}
+		notification := &Notification{newDeliveryId(), hook.Id, event.Id, event.Type, now}
+		go n.deliver(hook, notification)
+	}
+}
+
+func (n *Notifier) deliver(hook *Webhook, notification *Notification) {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		log.Printf("webhooks: Unable to encode notification for %s: %v", hook.Id, err)
+		return
+	}
+	backoff := n.Backoff
+	for attempt := 1; ; attempt++ {
+		err := n.send(hook, notification, body)
+		if err == nil {
+			return
+		}
+		if attempt >= n.Attempts {
+			log.Printf("webhooks: Giving up on delivery %s to %s after %d attempts: %v", notification.Delivery, hook.Id, attempt, err)
+			return
+		}
+		log.Printf("webhooks: Delivery %s to %s failed, retrying in %s: %v", notification.Delivery, hook.Id, backoff, err)
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > n.MaxBackoff {
+			backoff = n.MaxBackoff
+		}
+	}
+}
+
+func (n *Notifier) send(hook *Webhook, notification *Notification, body []byte) error {
+	req, err := http.NewRequest("POST", hook.Url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, notification.Type.String())
+	req.Header.Set(DeliveryHeader, notification.Delivery)
+	if hook.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(hook.Secret, body))
+	}
+
+	resp, err := n.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("callback returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// The signature sent with a body - the hex encoded HMAC-SHA256 of the
+// body using the webhook secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func newDeliveryId() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,109 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	nethttp "net/http"
+
+	"github.com/openshift/geard/http"
+	"github.com/openshift/geard/jobs"
+	"github.com/openshift/geard/webhooks"
+	wjobs "github.com/openshift/geard/webhooks/jobs"
+	"github.com/openshift/go-json-rest"
+)
+
+type HttpExtension struct{}
+
+func (h *HttpExtension) Routes() []http.HttpJobHandler {
+	return []http.HttpJobHandler{
+		&HttpPutWebhookRequest{},
+		&HttpDeleteWebhookRequest{},
+		&HttpListWebhooksRequest{},
+	}
+}
+
+func (h *HttpExtension) HttpJobFor(job interface{}) (exc http.RemoteExecutable, err error) {
+	switch j := job.(type) {
+	case *wjobs.PutWebhookRequest:
+		exc = &HttpPutWebhookRequest{PutWebhookRequest: *j}
+	case *wjobs.DeleteWebhookRequest:
+		exc = &HttpDeleteWebhookRequest{DeleteWebhookRequest: *j}
+	case *wjobs.ListWebhooksRequest:
+		exc = &HttpListWebhooksRequest{ListWebhooksRequest: *j}
+	default:
+		err = jobs.ErrNoJobForRequest
+	}
+	return
+}
+
+type HttpPutWebhookRequest struct {
+	wjobs.PutWebhookRequest
+	http.DefaultRequest
+}
+
+func (h *HttpPutWebhookRequest) HttpMethod() string { return "PUT" }
+func (h *HttpPutWebhookRequest) HttpPath() string   { return http.Inline("/webhooks/:id", string(h.Id)) }
+func (h *HttpPutWebhookRequest) Handler(conf *http.HttpConfiguration) http.JobHandler {
+	return func(context *jobs.JobContext, r *rest.Request) (interface{}, error) {
+		id, err := webhooks.NewIdentifier(r.PathParam("id"))
+		if err != nil {
+			return nil, err
+		}
+		hook := &webhooks.Webhook{}
+		if r.Body != nil {
+			dec := json.NewDecoder(io.LimitReader(r.Body, 100*1024))
+			if err := dec.Decode(hook); err != nil && err != io.EOF {
+				return nil, err
+			}
+		}
+		hook.Id = id
+		if err := hook.Check(); err != nil {
+			return nil, err
+		}
+		return &wjobs.PutWebhookRequest{Webhook: hook}, nil
+	}
+}
+func (h *HttpPutWebhookRequest) MarshalHttpRequestBody(w io.Writer) error {
+	return json.NewEncoder(w).Encode(h.Webhook)
+}
+
+type HttpDeleteWebhookRequest struct {
+	wjobs.DeleteWebhookRequest
+	http.DefaultRequest
+}
+
+func (h *HttpDeleteWebhookRequest) HttpMethod() string { return "DELETE" }
+func (h *HttpDeleteWebhookRequest) HttpPath() string   { return http.Inline("/webhooks/:id", string(h.Id)) }
+func (h *HttpDeleteWebhookRequest) Handler(conf *http.HttpConfiguration) http.JobHandler {
+	return func(context *jobs.JobContext, r *rest.Request) (interface{}, error) {
+		id, err := webhooks.NewIdentifier(r.PathParam("id"))
+		if err != nil {
+			return nil, err
+		}
+		return &wjobs.DeleteWebhookRequest{Id: id}, nil
+	}
+}
+
+type HttpListWebhooksRequest struct {
+	wjobs.ListWebhooksRequest
+	http.DefaultRequest
+}
+
+func (h *HttpListWebhooksRequest) HttpMethod() string { return "GET" }
+func (h *HttpListWebhooksRequest) HttpPath() string   { return "/webhooks" }
+func (h *HttpListWebhooksRequest) Handler(conf *http.HttpConfiguration) http.JobHandler {
+	return func(context *jobs.JobContext, r *rest.Request) (interface{}, error) {
+		return &wjobs.ListWebhooksRequest{}, nil
+	}
+}
+func (h *HttpListWebhooksRequest) UnmarshalHttpResponse(headers nethttp.Header, r io.Reader, mode http.ResponseContentMode) (interface{}, error) {
+	if r == nil {
+		return nil, errors.New("Unexpected empty response body to HttpListWebhooksRequest")
+	}
+	list := &wjobs.ListWebhooksResponse{}
+	if err := json.NewDecoder(r).Decode(list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
@@ -0,0 +1,101 @@
+// Jobs for registering and removing webhooks on a server.
+package jobs
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/openshift/geard/config"
+	"github.com/openshift/geard/jobs"
+	"github.com/openshift/geard/webhooks"
+)
+
+var (
+	ErrWebhookNotFound     = jobs.SimpleError{jobs.ResponseNotFound, "The specified webhook does not exist."}
+	ErrWebhookSaveFailed   = jobs.SimpleError{jobs.ResponseError, "Unable to save the webhook."}
+	ErrWebhookDeleteFailed = jobs.SimpleError{jobs.ResponseError, "Unable to delete the webhook."}
+	ErrListWebhooksFailed  = jobs.SimpleError{jobs.ResponseError, "Unable to list the registered webhooks."}
+)
+
+// Return a job extension that casts requests directly to jobs
+func NewWebhookExtension() jobs.JobExtension {
+	return &jobs.JobInitializer{
+		Extension: jobs.JobExtensionFunc(sharesImplementation),
+		Func:      config.HasRequiredDirectories,
+	}
+}
+
+func sharesImplementation(request interface{}) (jobs.Job, error) {
+	if job, ok := request.(jobs.Job); ok {
+		return job, nil
+	}
+	return nil, jobs.ErrNoJobForRequest
+}
+
+// Create or replace a webhook.  The request is not replayable, so the
+// secret is never written to the job journal.
+type PutWebhookRequest struct {
+	*webhooks.Webhook
+}
+
+func (j *PutWebhookRequest) Execute(resp jobs.Response) {
+	if err := webhooks.Save(j.Webhook); err != nil {
+		log.Printf("webhooks: Unable to save webhook %s: %v", j.Id, err)
+		resp.Failure(ErrWebhookSaveFailed)
+		return
+	}
+	resp.Success(jobs.ResponseOk)
+}
+
+type DeleteWebhookRequest struct {
+	Id webhooks.Identifier
+}
+
+func (j *DeleteWebhookRequest) Execute(resp jobs.Response) {
+	if err := webhooks.Remove(j.Id); err != nil {
+		if os.IsNotExist(err) {
+			resp.Failure(ErrWebhookNotFound)
+			return
+		}
+		log.Printf("webhooks: Unable to delete webhook %s: %v", j.Id, err)
+		resp.Failure(ErrWebhookDeleteFailed)
+		return
+	}
+	resp.Success(jobs.ResponseOk)
+}
+
+type ListWebhooksRequest struct{}
+
+type ListWebhooksResponse struct {
+	Webhooks []*webhooks.Webhook
+}
+
+func (j *ListWebhooksRequest) Execute(resp jobs.Response) {
+	hooks, err := webhooks.List()
+	if err != nil {
+		log.Printf("webhooks: Unable to list webhooks: %v", err)
+		resp.Failure(ErrListWebhooksFailed)
+		return
+	}
+	// secrets are never returned
+	for i := range hooks {
+		hooks[i].Secret = ""
+	}
+	resp.SuccessWithData(jobs.ResponseOk, &ListWebhooksResponse{hooks})
+}
+
+func (l *ListWebhooksResponse) WriteTableTo(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 8, 4, 1, ' ', tabwriter.DiscardEmptyColumns)
+	if _, err := fmt.Fprintf(tw, "ID\tURL\tCONTAINERS\tTYPES\n"); err != nil {
+		return err
+	}
+	for _, hook := range l.Webhooks {
+		if _, err := fmt.Fprintf(tw, "%s\t%s\t%v\t%v\n", hook.Id, hook.Url, hook.Ids, hook.Types); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,47 @@
+package jobs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/openshift/geard/dispatcher"
+	"github.com/openshift/geard/jobs"
+	"github.com/openshift/geard/webhooks"
+)
+
+func TestPutWebhookSecretIsNotJournaled(t *testing.T) {
+	dir, err := ioutil.TempDir("", "journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	journal := dispatcher.NewJournal(dir)
+
+	id := jobs.NewRequestIdentifier()
+	req := &PutWebhookRequest{&webhooks.Webhook{Id: "a", Url: "http://example.com/a", Secret: "topsecret"}}
+	if err := journal.Queued(id, "", req); err != nil {
+		t.Fatal(err)
+	}
+
+	found := false
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		found = true
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(data), "topsecret") {
+			t.Errorf("The webhook secret was written to the journal: %s", data)
+		}
+		return nil
+	})
+	if !found {
+		t.Fatal("No journal entry was written")
+	}
+}
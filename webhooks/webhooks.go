@@ -0,0 +1,148 @@
+// Deliver container lifecycle events to HTTP callbacks registered with
+// the server.  Each webhook is persisted under the container base path,
+// and every matching event is POSTed to its URL as JSON, signed with
+// the webhook secret and retried with backoff until it is accepted.
+package webhooks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/openshift/geard/config"
+	"github.com/openshift/geard/containers"
+	csystemd "github.com/openshift/geard/containers/systemd"
+)
+
+type Identifier string
+
+var allowedIdentifier = regexp.MustCompile("\\A[a-zA-Z0-9\\-_]{1,64}\\z")
+
+func NewIdentifier(s string) (Identifier, error) {
+	if !allowedIdentifier.MatchString(s) {
+		return "", errors.New("Webhook identifiers must be 1-64 characters of letters, numbers, '-', and '_'")
+	}
+	return Identifier(s), nil
+}
+
+// A registered callback.  If Ids or Types are set, only events for
+// those containers or of those types are delivered.
+type Webhook struct {
+	Id     Identifier
+	Url    string
+	Secret string                  `json:",omitempty"`
+	Ids    []containers.Identifier `json:",omitempty"`
+	Types  []string                `json:",omitempty"`
+}
+
+func (h *Webhook) Check() error {
+	if _, err := NewIdentifier(string(h.Id)); err != nil {
+		return err
+	}
+	u, err := url.ParseRequestURI(h.Url)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.New("The webhook URL must be http or https")
+	}
+	for i := range h.Types {
+		if _, err := csystemd.NewEventType(h.Types[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *Webhook) Matches(event *csystemd.ContainerEvent) bool {
+	if len(h.Ids) > 0 {
+		found := false
+		for i := range h.Ids {
+			if h.Ids[i] == event.Id {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(h.Types) > 0 {
+		name := event.Type.String()
+		for i := range h.Types {
+			if h.Types[i] == name {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func basePath() string {
+	return filepath.Join(config.ContainerBasePath(), "webhooks")
+}
+
+func (i Identifier) PathFor() string {
+	return filepath.Join(basePath(), string(i)+".json")
+}
+
+func init() {
+	config.AddRequiredDirectory(0750, basePath())
+}
+
+// Create or replace a webhook.
+func Save(hook *Webhook) error {
+	data, err := json.Marshal(hook)
+	if err != nil {
+		return err
+	}
+	path := hook.Id.PathFor()
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func Remove(id Identifier) error {
+	return os.Remove(id.PathFor())
+}
+
+func Get(id Identifier) (*Webhook, error) {
+	data, err := ioutil.ReadFile(id.PathFor())
+	if err != nil {
+		return nil, err
+	}
+	hook := &Webhook{}
+	if err := json.Unmarshal(data, hook); err != nil {
+		return nil, err
+	}
+	return hook, nil
+}
+
+// Return all registered webhooks ordered by identifier.
+func List() ([]*Webhook, error) {
+	names, err := filepath.Glob(filepath.Join(basePath(), "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	hooks := make([]*Webhook, 0, len(names))
+	for _, name := range names {
+		id := Identifier(strings.TrimSuffix(filepath.Base(name), ".json"))
+		hook, err := Get(id)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read webhook %s: %v", id, err)
+		}
+		hooks = append(hooks, hook)
+	}
+	return hooks, nil
+}
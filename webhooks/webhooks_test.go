@@ -0,0 +1,105 @@
+package webhooks
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/openshift/geard/config"
+	"github.com/openshift/geard/containers"
+	csystemd "github.com/openshift/geard/containers/systemd"
+)
+
+func TestWebhookStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "webhooks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	previous := config.ContainerBasePath()
+	config.SetContainerBasePath(dir)
+	defer config.SetContainerBasePath(previous)
+	os.MkdirAll(basePath(), 0750)
+
+	hook := &Webhook{Id: "b", Url: "http://example.com/b", Secret: "secret", Types: []string{"started"}}
+	if err := hook.Check(); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(hook); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(&Webhook{Id: "a", Url: "http://example.com/a"}); err != nil {
+		t.Fatal(err)
+	}
+
+	hooks, err := List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hooks) != 2 || hooks[0].Id != "a" || hooks[1].Secret != "secret" {
+		t.Errorf("Unexpected webhooks %+v", hooks)
+	}
+	if err := Remove("a"); err != nil {
+		t.Fatal(err)
+	}
+	if hooks, _ := List(); len(hooks) != 1 {
+		t.Errorf("Expected one webhook after removal, got %d", len(hooks))
+	}
+}
+
+func TestWebhookCheckAndMatch(t *testing.T) {
+	invalid := []*Webhook{
+		{Id: "a b", Url: "http://example.com"},
+		{Id: "a", Url: "ftp://example.com"},
+		{Id: "a", Url: "http://example.com", Types: []string{"exploded"}},
+	}
+	for i := range invalid {
+		if err := invalid[i].Check(); err == nil {
+			t.Errorf("Expected %+v to be invalid", invalid[i])
+		}
+	}
+
+	hook := &Webhook{Id: "a", Url: "http://example.com", Ids: []containers.Identifier{"foo"}, Types: []string{"error"}}
+	if !hook.Matches(&csystemd.ContainerEvent{Id: "foo", Type: csystemd.Errored}) {
+		t.Error("Expected a matching event")
+	}
+	if hook.Matches(&csystemd.ContainerEvent{Id: "bar", Type: csystemd.Errored}) {
+		t.Error("Expected an event for another container not to match")
+	}
+	if hook.Matches(&csystemd.ContainerEvent{Id: "foo", Type: csystemd.Started}) {
+		t.Error("Expected an event of another type not to match")
+	}
+}
+
+func TestDeliveryIsSignedAndRetried(t *testing.T) {
+	received := make(chan *http.Request, 1)
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.Header.Get(SignatureHeader) != Sign("secret", body) {
+			t.Errorf("Unexpected signature %s", r.Header.Get(SignatureHeader))
+		}
+		received <- r
+	}))
+	defer server.Close()
+
+	n := &Notifier{Client: &http.Client{}, Attempts: 3, Backoff: time.Millisecond, MaxBackoff: time.Millisecond}
+	n.deliver(&Webhook{Id: "a", Url: server.URL, Secret: "secret"}, &Notification{Delivery: "1", Webhook: "a", Container: "foo", Type: csystemd.Started})
+
+	select {
+	case r := <-received:
+		if r.Header.Get(EventHeader) != "started" {
+			t.Errorf("Unexpected event header %s", r.Header.Get(EventHeader))
+		}
+	default:
+		t.Fatalf("Expected delivery to succeed on the third attempt, got %d attempts", attempts)
+	}
+}